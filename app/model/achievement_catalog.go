@@ -0,0 +1,146 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// AchievementType adalah entri katalog jenis prestasi. Kode harus salah satu
+// AchievementTypes() karena schema details-nya didefinisikan di kode.
+type AchievementType struct {
+	Code        string      `json:"code"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	IsActive    bool        `json:"isActive"`
+	Rules       []PointRule `json:"rules,omitempty"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+type AchievementTypeRequest struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	IsActive    *bool  `json:"isActive"`
+}
+
+// PointRule memberi poin untuk prestasi dengan tipe tertentu. Level dan rentang
+// rank opsional (nil = semua); contoh: competition / national / rank 1-1 = 60,
+// rank 4- (finalis) = 20.
+type PointRule struct {
+	ID        uuid.UUID `json:"id"`
+	TypeCode  string    `json:"typeCode"`
+	Level     *string   `json:"level,omitempty"`
+	RankFrom  *int      `json:"rankFrom,omitempty"`
+	RankTo    *int      `json:"rankTo,omitempty"`
+	Label     string    `json:"label"`
+	Points    int       `json:"points"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type PointRuleRequest struct {
+	Level    *string `json:"level"`
+	RankFrom *int    `json:"rankFrom"`
+	RankTo   *int    `json:"rankTo"`
+	Label    string  `json:"label"`
+	Points   int     `json:"points"`
+}
+
+// PointAward adalah hasil perhitungan poin untuk satu prestasi
+type PointAward struct {
+	Points   int        `json:"points"`
+	RuleID   *uuid.UUID `json:"ruleId,omitempty"`
+	Previous int        `json:"previous"` // poin yang tersimpan sebelumnya (untuk audit)
+
+	// Override oleh dosen wali: Calculated = poin menurut aturan
+	Overridden    bool   `json:"overridden"`
+	Calculated    int    `json:"calculated"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// Alasan perubahan poin di audit trail
+const (
+	PointReasonVerify     = "verify"
+	PointReasonRuleChange = "rule_change"
+	PointReasonManual     = "recalculate"
+	PointReasonOverride   = "override" // poin disesuaikan dosen wali saat verify
+)
+
+// AchievementPointAudit adalah satu baris jejak perubahan poin prestasi
+type AchievementPointAudit struct {
+	ID            uuid.UUID  `json:"id"`
+	AchievementID string     `json:"achievementId"`
+	OldPoints     int        `json:"oldPoints"`
+	NewPoints     int        `json:"newPoints"`
+	RuleID        *uuid.UUID `json:"ruleId,omitempty"`
+	Reason        string     `json:"reason"`
+	Note          *string    `json:"note,omitempty"`
+	ActorID       *uuid.UUID `json:"actorId,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// RecalculationResult meringkas hasil hitung ulang poin setelah aturan berubah
+type RecalculationResult struct {
+	TypeCode string   `json:"typeCode"`
+	Checked  int      `json:"checked"`
+	Changed  int      `json:"changed"`
+	Failed   []string `json:"failed,omitempty"`
+}
+
+func (r PointRule) matches(level string, rank int, hasRank bool) bool {
+	if r.Level != nil && *r.Level != level {
+		return false
+	}
+	if r.RankFrom != nil || r.RankTo != nil {
+		if !hasRank {
+			return false
+		}
+		if r.RankFrom != nil && rank < *r.RankFrom {
+			return false
+		}
+		if r.RankTo != nil && rank > *r.RankTo {
+			return false
+		}
+	}
+	return true
+}
+
+func (r PointRule) specificity() int {
+	s := 0
+	if r.Level != nil {
+		s += 2
+	}
+	if r.RankFrom != nil || r.RankTo != nil {
+		s++
+	}
+	return s
+}
+
+// CalculatePoints memilih aturan yang cocok dan paling spesifik (level > rank > umum);
+// jika sama spesifik, poin tertinggi yang dipakai. Tanpa aturan yang cocok poinnya 0.
+func CalculatePoints(rules []PointRule, achievementType string, details bson.M) PointAward {
+	level, _ := details["level"].(string)
+	rank, hasRank := toInt(details["rank"])
+
+	var best *PointRule
+	for i := range rules {
+		r := rules[i]
+		if r.TypeCode != achievementType || !r.matches(level, rank, hasRank) {
+			continue
+		}
+		if best == nil ||
+			r.specificity() > best.specificity() ||
+			(r.specificity() == best.specificity() && r.Points > best.Points) {
+			best = &rules[i]
+		}
+	}
+
+	if best == nil {
+		return PointAward{}
+	}
+	id := best.ID
+	return PointAward{Points: best.Points, RuleID: &id}
+}
@@ -13,6 +13,7 @@ const (
 	StatusSubmitted AchievementStatus = "submitted"
 	StatusVerified  AchievementStatus = "verified"
 	StatusRejected  AchievementStatus = "rejected"
+	StatusDeleted   AchievementStatus = "deleted" // soft delete (di PostgreSQL: deleted_at)
 )
 
 type AchievementReference struct {
@@ -24,14 +25,29 @@ type AchievementReference struct {
 	VerifiedAt         *time.Time        `json:"verified_at,omitempty"`
 	VerifiedBy         *uuid.UUID        `json:"verified_by,omitempty"`
 	RejectionNote      *string           `json:"rejection_note,omitempty"`
+	DeletedAt          *time.Time        `json:"deleted_at,omitempty"`
+	SubmissionRound    int               `json:"submission_round"` // jumlah pengajuan (putaran) sejauh ini
+	Points             int               `json:"points"`
 	CreatedAt          time.Time         `json:"created_at"`
 	UpdatedAt          time.Time         `json:"updated_at"`
 }
 
-type AchievementStatusHistory struct {
-	Status        string     `json:"status"`
-	UpdatedAt     time.Time  `json:"updatedAt"`
-	VerifiedBy    *string    `json:"verifiedBy,omitempty"`
-	RejectionNote *string    `json:"rejectionNote,omitempty"`
+// CurrentStatus mengembalikan status workflow, termasuk soft delete
+func (r *AchievementReference) CurrentStatus() AchievementStatus {
+	if r.DeletedAt != nil {
+		return StatusDeleted
+	}
+	return r.Status
 }
 
+// AchievementStatusHistory adalah satu baris riwayat (append-only) perpindahan status
+type AchievementStatusHistory struct {
+	ID         uuid.UUID  `json:"id"`
+	Event      string     `json:"event"`
+	FromStatus *string    `json:"fromStatus,omitempty"`
+	ToStatus   string     `json:"toStatus"`
+	ActorID    *uuid.UUID `json:"actorId,omitempty"`
+	ActorRole  *string    `json:"actorRole,omitempty"`
+	Note       *string    `json:"note,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,146 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// AchievementSnapshot adalah isi prestasi yang dibekukan (saat diajukan / saat diedit)
+type AchievementSnapshot struct {
+	AchievementType string       `bson:"achievementType" json:"achievementType"`
+	Title           string       `bson:"title" json:"title"`
+	Description     string       `bson:"description" json:"description"`
+	Details         bson.M       `bson:"details" json:"details"`
+	Attachments     []Attachment `bson:"attachments" json:"attachments"`
+	Tags            []string     `bson:"tags" json:"tags"`
+	Points          int          `bson:"points" json:"points"`
+}
+
+// SnapshotOf membekukan isi prestasi. Details dinormalisasi lewat JSON agar
+// tipe BSON (primitive.A, int32, ...) bisa dibandingkan dengan hasil dari PostgreSQL.
+func SnapshotOf(a *Achievement) AchievementSnapshot {
+	s := AchievementSnapshot{
+		AchievementType: a.AchievementType,
+		Title:           a.Title,
+		Description:     a.Description,
+		Details:         bson.M{},
+		Attachments:     withoutPreviews(a.Attachments),
+		Tags:            a.Tags,
+		Points:          a.Points,
+	}
+	if raw, err := json.Marshal(a.Details); err == nil {
+		_ = json.Unmarshal(raw, &s.Details)
+	}
+	if s.Details == nil {
+		s.Details = bson.M{}
+	}
+	return s
+}
+
+// withoutPreviews: pratinjau diisi belakangan oleh worker, jadi tidak ikut dibekukan
+// (kalau ikut, putaran yang isinya sama akan terlihat berbeda)
+func withoutPreviews(attachments []Attachment) []Attachment {
+	if attachments == nil {
+		return nil
+	}
+	out := make([]Attachment, len(attachments))
+	for i, att := range attachments {
+		att.Preview = nil
+		out[i] = att
+	}
+	return out
+}
+
+// AchievementRound adalah satu putaran pengajuan: dibuka saat submit, ditutup
+// saat dosen wali memverifikasi atau menolak
+type AchievementRound struct {
+	ID            uuid.UUID           `json:"id"`
+	Round         int                 `json:"round"`
+	Snapshot      AchievementSnapshot `json:"snapshot"`
+	SubmittedBy   *uuid.UUID          `json:"submittedBy,omitempty"`
+	SubmittedAt   time.Time           `json:"submittedAt"`
+	Outcome       *string             `json:"outcome,omitempty"` // verified / rejected, nil = menunggu
+	ReviewedBy    *uuid.UUID          `json:"reviewedBy,omitempty"`
+	ReviewedAt    *time.Time          `json:"reviewedAt,omitempty"`
+	RejectionNote *string             `json:"rejectionNote,omitempty"`
+
+	RejectionReasons []string       `json:"rejectionReasons,omitempty"`
+	FieldComments    []FieldComment `json:"fieldComments,omitempty"`
+}
+
+// FieldChange adalah satu field yang berubah antara dua snapshot
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// RevisionDiff membandingkan isi prestasi sekarang dengan putaran yang terakhir ditolak
+type RevisionDiff struct {
+	RejectedRound int            `json:"rejectedRound"`
+	RejectionNote *string        `json:"rejectionNote,omitempty"`
+	Reasons       []string       `json:"reasons,omitempty"`
+	FieldComments []FieldComment `json:"fieldComments,omitempty"`
+	RejectedAt    *time.Time     `json:"rejectedAt,omitempty"`
+	CurrentStatus string         `json:"currentStatus"`
+	Changes       []FieldChange  `json:"changes"`
+}
+
+// DiffSnapshots mengembalikan field yang berbeda; isi details dibandingkan per key
+// ("details.<key>") supaya dosen langsung melihat bagian mana yang diperbaiki
+func DiffSnapshots(old, new AchievementSnapshot) []FieldChange {
+	changes := []FieldChange{}
+
+	add := func(field string, o, n interface{}) {
+		if !reflect.DeepEqual(o, n) {
+			changes = append(changes, FieldChange{Field: field, Old: o, New: n})
+		}
+	}
+
+	add("achievementType", old.AchievementType, new.AchievementType)
+	add("title", old.Title, new.Title)
+	add("description", old.Description, new.Description)
+	add("points", old.Points, new.Points)
+	add("tags", normalizeJSON(old.Tags), normalizeJSON(new.Tags))
+	add("attachments", normalizeJSON(old.Attachments), normalizeJSON(new.Attachments))
+
+	keys := map[string]bool{}
+	for k := range old.Details {
+		keys[k] = true
+	}
+	for k := range new.Details {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		add("details."+k, normalizeJSON(old.Details[k]), normalizeJSON(new.Details[k]))
+	}
+
+	return changes
+}
+
+// normalizeJSON menyamakan representasi (slice kosong vs nil, int vs float64)
+func normalizeJSON(v interface{}) interface{} {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return v
+	}
+	if arr, ok := out.([]interface{}); ok && len(arr) == 0 {
+		return nil
+	}
+	return out
+}
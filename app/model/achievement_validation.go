@@ -0,0 +1,414 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// FieldError adalah satu kesalahan validasi pada field tertentu (mis. "details.rank")
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Jenis prestasi yang dikenal
+const (
+	TypeCompetition   = "competition"
+	TypePublication   = "publication"
+	TypeCertification = "certification"
+	TypeOrganization  = "organization"
+)
+
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 5000
+	maxTags              = 20
+	maxTagLength         = 50
+	maxDetailTextLength  = 500
+)
+
+type detailKind int
+
+const (
+	detailText detailKind = iota
+	detailDate
+	detailPositiveInt
+	detailEnum
+	detailDOI
+	detailPeriod
+)
+
+type detailRule struct {
+	Kind     detailKind
+	Required bool
+	Values   []string // untuk detailEnum
+}
+
+// achievementSchemas: field details yang diizinkan per achievementType
+var achievementSchemas = map[string]map[string]detailRule{
+	TypeCompetition: {
+		"level":     {Kind: detailEnum, Required: true, Values: []string{"international", "national", "regional", "local"}},
+		"rank":      {Kind: detailPositiveInt, Required: true},
+		"organizer": {Kind: detailText, Required: true},
+		"date":      {Kind: detailDate, Required: true},
+	},
+	TypePublication: {
+		"venue": {Kind: detailText, Required: true},
+		"doi":   {Kind: detailDOI},
+	},
+	TypeCertification: {
+		"issuer": {Kind: detailText, Required: true},
+		"expiry": {Kind: detailDate},
+	},
+	TypeOrganization: {
+		"position": {Kind: detailText, Required: true},
+		"period":   {Kind: detailPeriod, Required: true},
+	},
+}
+
+// AchievementTypes mengembalikan daftar achievementType yang valid (terurut)
+func AchievementTypes() []string {
+	types := make([]string, 0, len(achievementSchemas))
+	for t := range achievementSchemas {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// achievementEditableFields: field yang boleh dikirim mahasiswa. status, points,
+// studentId dan attachments hanya diubah lewat workflow / upload.
+var achievementEditableFields = map[string]bool{
+	"achievementType": true,
+	"title":           true,
+	"description":     true,
+	"details":         true,
+	"tags":            true,
+}
+
+var doiPattern = regexp.MustCompile(`^10\.\d{4,9}/\S+$`)
+
+// ValidateAchievementCreate memvalidasi body create dan mengembalikan field yang
+// sudah dibersihkan (string di-trim, tags unik)
+func ValidateAchievementCreate(input map[string]interface{}) (bson.M, []FieldError) {
+	return validateAchievementInput(input, nil)
+}
+
+// ValidateAchievementUpdate memvalidasi body update parsial. Jika achievementType
+// berubah tanpa details baru, details lama divalidasi ulang dengan schema baru.
+func ValidateAchievementUpdate(input map[string]interface{}, current *Achievement) (bson.M, []FieldError) {
+	return validateAchievementInput(input, current)
+}
+
+func validateAchievementInput(input map[string]interface{}, current *Achievement) (bson.M, []FieldError) {
+	errs := []FieldError{}
+	set := bson.M{}
+	partial := current != nil
+
+	fail := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	keys := make([]string, 0, len(input))
+	for k := range input {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !achievementEditableFields[k] {
+			fail(k, "field is not allowed")
+		}
+	}
+
+	if v, ok := input["title"]; ok || !partial {
+		title, isString := v.(string)
+		title = strings.TrimSpace(title)
+		switch {
+		case !isString && ok:
+			fail("title", "must be a string")
+		case title == "":
+			fail("title", "is required")
+		case len([]rune(title)) > maxTitleLength:
+			fail("title", "must be at most %d characters", maxTitleLength)
+		default:
+			set["title"] = title
+		}
+	}
+
+	if v, ok := input["description"]; ok {
+		description, isString := v.(string)
+		switch {
+		case !isString && v != nil:
+			fail("description", "must be a string")
+		case len([]rune(description)) > maxDescriptionLength:
+			fail("description", "must be at most %d characters", maxDescriptionLength)
+		default:
+			set["description"] = strings.TrimSpace(description)
+		}
+	}
+
+	if v, ok := input["tags"]; ok {
+		if tags, tagErrs := validateTags(v); len(tagErrs) > 0 {
+			errs = append(errs, tagErrs...)
+		} else {
+			set["tags"] = tags
+		}
+	}
+
+	// achievementType & details divalidasi bersama
+	achievementType := ""
+	typeValid := false
+	if v, ok := input["achievementType"]; ok || !partial {
+		s, _ := v.(string)
+		achievementType = strings.TrimSpace(s)
+		if _, known := achievementSchemas[achievementType]; known {
+			typeValid = true
+			set["achievementType"] = achievementType
+		} else if achievementType == "" {
+			fail("achievementType", "is required")
+		} else {
+			fail("achievementType", "must be one of: %s", strings.Join(AchievementTypes(), ", "))
+		}
+	} else {
+		achievementType = current.AchievementType
+		_, typeValid = achievementSchemas[achievementType]
+	}
+
+	rawDetails, hasDetails := input["details"]
+	typeChanged := partial && achievementType != current.AchievementType
+	if !hasDetails && typeChanged {
+		rawDetails, hasDetails = current.Details, true
+	}
+
+	if hasDetails || !partial {
+		details, isObject := asObject(rawDetails)
+		switch {
+		case rawDetails != nil && !isObject:
+			fail("details", "must be an object")
+		case typeValid:
+			clean, detailErrs := validateDetails(achievementSchemas[achievementType], details)
+			if len(detailErrs) > 0 {
+				errs = append(errs, detailErrs...)
+			} else {
+				set["details"] = clean
+			}
+		case partial && achievementType == current.AchievementType:
+			// prestasi lama dengan tipe yang tidak dikenal: details baru butuh tipe yang valid
+			fail("achievementType", "must be set to one of: %s", strings.Join(AchievementTypes(), ", "))
+		}
+	}
+
+	return set, errs
+}
+
+// asObject menerima object dari JSON (map) maupun dari MongoDB (bson.M)
+func asObject(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case bson.M:
+		return m, true
+	}
+	return nil, false
+}
+
+func validateTags(v interface{}) ([]string, []FieldError) {
+	if v == nil {
+		return []string{}, nil
+	}
+
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, []FieldError{{Field: "tags", Message: "must be an array of strings"}}
+	}
+	if len(items) > maxTags {
+		return nil, []FieldError{{Field: "tags", Message: fmt.Sprintf("must contain at most %d tags", maxTags)}}
+	}
+
+	var errs []FieldError
+	tags := []string{}
+	seen := map[string]bool{}
+	for i, item := range items {
+		field := fmt.Sprintf("tags[%d]", i)
+		tag, isString := item.(string)
+		tag = strings.TrimSpace(tag)
+		switch {
+		case !isString:
+			errs = append(errs, FieldError{Field: field, Message: "must be a string"})
+		case tag == "":
+			errs = append(errs, FieldError{Field: field, Message: "must not be empty"})
+		case len([]rune(tag)) > maxTagLength:
+			errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf("must be at most %d characters", maxTagLength)})
+		case !seen[strings.ToLower(tag)]:
+			seen[strings.ToLower(tag)] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags, errs
+}
+
+func validateDetails(schema map[string]detailRule, details map[string]interface{}) (bson.M, []FieldError) {
+	var errs []FieldError
+	clean := bson.M{}
+
+	fail := func(key, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: "details." + key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	keys := make([]string, 0, len(details))
+	for k := range details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, ok := schema[k]; !ok {
+			fail(k, "field is not allowed for this achievement type")
+		}
+	}
+
+	names := make([]string, 0, len(schema))
+	for k := range schema {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, key := range names {
+		rule := schema[key]
+		v, ok := details[key]
+		if !ok || v == nil || v == "" {
+			if rule.Required {
+				fail(key, "is required")
+			}
+			continue
+		}
+
+		switch rule.Kind {
+		case detailText:
+			s, isString := v.(string)
+			s = strings.TrimSpace(s)
+			switch {
+			case !isString:
+				fail(key, "must be a string")
+			case s == "":
+				fail(key, "must not be empty")
+			case len([]rune(s)) > maxDetailTextLength:
+				fail(key, "must be at most %d characters", maxDetailTextLength)
+			default:
+				clean[key] = s
+			}
+
+		case detailEnum:
+			s, _ := v.(string)
+			s = strings.ToLower(strings.TrimSpace(s))
+			if !containsString(rule.Values, s) {
+				fail(key, "must be one of: %s", strings.Join(rule.Values, ", "))
+			} else {
+				clean[key] = s
+			}
+
+		case detailPositiveInt:
+			n, isNumber := toInt(v)
+			if !isNumber || n < 1 {
+				fail(key, "must be a positive integer")
+			} else {
+				clean[key] = n
+			}
+
+		case detailDate:
+			s, _ := v.(string)
+			if _, err := time.Parse("2006-01-02", strings.TrimSpace(s)); err != nil {
+				fail(key, "must be a date (YYYY-MM-DD)")
+			} else {
+				clean[key] = strings.TrimSpace(s)
+			}
+
+		case detailDOI:
+			s, _ := v.(string)
+			s = strings.TrimPrefix(strings.TrimSpace(s), "https://doi.org/")
+			if !doiPattern.MatchString(s) {
+				fail(key, "must be a DOI (e.g. 10.1000/xyz123)")
+			} else {
+				clean[key] = s
+			}
+
+		case detailPeriod:
+			period, perr := validatePeriod(v)
+			if perr != "" {
+				fail(key, "%s", perr)
+			} else {
+				clean[key] = period
+			}
+		}
+	}
+
+	return clean, errs
+}
+
+// validatePeriod: {"start": "YYYY-MM-DD", "end": "YYYY-MM-DD"}, end boleh kosong (masih menjabat)
+func validatePeriod(v interface{}) (bson.M, string) {
+	period, ok := asObject(v)
+	if !ok {
+		return nil, "must be an object with start and optional end date"
+	}
+
+	startRaw, _ := period["start"].(string)
+	start, err := time.Parse("2006-01-02", strings.TrimSpace(startRaw))
+	if err != nil {
+		return nil, "start must be a date (YYYY-MM-DD)"
+	}
+	clean := bson.M{"start": start.Format("2006-01-02")}
+
+	if endRaw, ok := period["end"]; ok && endRaw != nil && endRaw != "" {
+		s, _ := endRaw.(string)
+		end, err := time.Parse("2006-01-02", strings.TrimSpace(s))
+		if err != nil {
+			return nil, "end must be a date (YYYY-MM-DD)"
+		}
+		if end.Before(start) {
+			return nil, "end must not be before start"
+		}
+		clean["end"] = end.Format("2006-01-02")
+	}
+
+	for k := range period {
+		if k != "start" && k != "end" {
+			return nil, fmt.Sprintf("unknown period field %q", k)
+		}
+	}
+
+	return clean, ""
+}
+
+// toInt menerima angka JSON (float64) maupun angka dari MongoDB (int32/int64)
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, false
+		}
+		return int(n), true
+	case int:
+		return n, true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Aksi yang menghasilkan versi baru
+const (
+	VersionCreate   = "create"
+	VersionUpdate   = "update"
+	VersionRestore  = "restore"
+	VersionBaseline = "baseline" // isi lama prestasi yang dibuat sebelum versioning ada
+)
+
+// AchievementVersion adalah snapshot immutable satu revisi isi prestasi
+// (koleksi achievement_versions)
+type AchievementVersion struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	AchievementID string              `bson:"achievementId" json:"achievementId"`
+	Version       int                 `bson:"version" json:"version"`
+	Action        string              `bson:"action" json:"action"`
+	RestoredFrom  *int                `bson:"restoredFrom,omitempty" json:"restoredFrom,omitempty"`
+	Snapshot      AchievementSnapshot `bson:"snapshot" json:"snapshot"`
+	EditedBy      string              `bson:"editedBy,omitempty" json:"editedBy,omitempty"`
+	EditedAt      time.Time           `bson:"editedAt" json:"editedAt"`
+}
+
+// VersionDiff adalah hasil perbandingan dua versi
+type VersionDiff struct {
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// RestoreInput mengubah snapshot menjadi body edit (bentuk JSON seperti dari client),
+// supaya pemulihan melewati validasi yang sama dengan UpdateAchievement. Lampiran tidak
+// ikut dipulihkan karena file fisiknya dikelola terpisah; poin milik PostgreSQL.
+func RestoreInput(s AchievementSnapshot) map[string]interface{} {
+	details := map[string]interface{}{}
+	for k, v := range s.Details {
+		details[k] = v
+	}
+	tags := make([]interface{}, 0, len(s.Tags))
+	for _, t := range s.Tags {
+		tags = append(tags, t)
+	}
+
+	return map[string]interface{}{
+		"achievementType": s.AchievementType,
+		"title":           s.Title,
+		"description":     s.Description,
+		"details":         details,
+		"tags":            tags,
+	}
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// AchievementEvent adalah aksi yang memicu perpindahan status prestasi
+type AchievementEvent string
+
+const (
+	EventSubmit AchievementEvent = "submit" // draft -> submitted (mahasiswa)
+	EventVerify AchievementEvent = "verify" // submitted -> verified (dosen wali)
+	EventReject AchievementEvent = "reject" // submitted -> rejected (dosen wali)
+	EventRevise AchievementEvent = "revise" // rejected -> draft (mahasiswa memperbaiki, catatan penolakan tetap disimpan)
+	EventDelete AchievementEvent = "delete" // draft -> deleted (soft delete)
+
+	// EventCreate hanya dicatat di riwayat (awal draft), bukan transisi workflow
+	EventCreate AchievementEvent = "create"
+	// EventBackfill: baris awal riwayat untuk prestasi lama (status saat riwayat mulai dicatat)
+	EventBackfill AchievementEvent = "backfill"
+)
+
+type transitionRule struct {
+	From AchievementStatus
+	To   AchievementStatus
+}
+
+// achievementTransitions adalah satu-satunya sumber aturan workflow prestasi
+var achievementTransitions = map[AchievementEvent]transitionRule{
+	EventSubmit: {From: StatusDraft, To: StatusSubmitted},
+	EventVerify: {From: StatusSubmitted, To: StatusVerified},
+	EventReject: {From: StatusSubmitted, To: StatusRejected},
+	EventRevise: {From: StatusRejected, To: StatusDraft},
+	EventDelete: {From: StatusDraft, To: StatusDeleted},
+}
+
+// InvalidTransitionError dikembalikan jika event tidak berlaku untuk status saat ini
+type InvalidTransitionError struct {
+	Event AchievementEvent
+	From  AchievementStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	if _, ok := achievementTransitions[e.Event]; !ok {
+		return fmt.Sprintf("unknown achievement event %q", e.Event)
+	}
+	return fmt.Sprintf("cannot %s achievement with status %s", e.Event, e.From)
+}
+
+// NextStatus menghitung status tujuan dari status saat ini dan event
+func NextStatus(from AchievementStatus, event AchievementEvent) (AchievementStatus, error) {
+	rule, ok := achievementTransitions[event]
+	if !ok || rule.From != from {
+		return "", &InvalidTransitionError{Event: event, From: from}
+	}
+	return rule.To, nil
+}
+
+// IsEditable: isi prestasi hanya boleh diubah selama masih draft
+func IsEditable(status AchievementStatus) bool {
+	return status == StatusDraft
+}
+
+// TransitionPayload adalah data tambahan untuk sebuah transisi
+type TransitionPayload struct {
+	Note string `json:"note"`
+
+	// verify: poin yang disesuaikan dosen wali (wajib disertai justification)
+	Points        *int   `json:"points,omitempty"`
+	Justification string `json:"justification,omitempty"`
+
+	// reject: kode alasan dari daftar rejection reason dan komentar per field
+	Reasons       []string       `json:"reasons,omitempty"`
+	FieldComments []FieldComment `json:"fieldComments,omitempty"`
+}
+
+// FieldComment adalah komentar dosen wali untuk satu field (mis. "details.date")
+type FieldComment struct {
+	Field   string `json:"field"`
+	Comment string `json:"comment"`
+}
+
+// AchievementTransition adalah event yang dipancarkan setiap kali status berpindah
+type AchievementTransition struct {
+	AchievementID string            `json:"achievementId"`
+	StudentID     string            `json:"studentId"`
+	Event         AchievementEvent  `json:"event"`
+	From          AchievementStatus `json:"from"`
+	To            AchievementStatus `json:"to"`
+	ActorID       string            `json:"actorId"`
+	Note          string            `json:"note,omitempty"`
+	At            time.Time         `json:"at"`
+
+	// Snapshot diisi saat submit: isi prestasi yang diajukan pada putaran ini
+	Snapshot *AchievementSnapshot `json:"-"`
+
+	// Award diisi saat verify: poin hasil katalog aturan poin (atau override dosen wali)
+	Award *PointAward `json:"-"`
+
+	// Reasons & FieldComments diisi saat reject
+	Reasons       []string       `json:"reasons,omitempty"`
+	FieldComments []FieldComment `json:"fieldComments,omitempty"`
+}
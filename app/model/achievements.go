@@ -1,6 +1,8 @@
 package model
 
 import (
+	"path"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -41,10 +43,121 @@ type AchievementResponse struct {
 	UpdatedAt       time.Time     `json:"updatedAt"`
 }
 
+// Hanya untuk Swagger: body create / update prestasi
+type AchievementInput struct {
+	AchievementType string                 `json:"achievementType" example:"competition"`
+	Title           string                 `json:"title"`
+	Description     string                 `json:"description"`
+	Details         map[string]interface{} `json:"details"`
+	Tags            []string               `json:"tags"`
+}
 
+// Attachment menyimpan key pada storage (bukan path lokal) agar bisa dibaca dari instance mana pun
 type Attachment struct {
-	FileName   string    `bson:"fileName" json:"fileName"`
-	FilePath    string    `bson:"filePath" json:"filePath"`
-	FileType   string    `bson:"fileType" json:"fileType"`
-	UploadedAt time.Time `bson:"uploadedAt" json:"uploadedAt"`
+	ID          string    `bson:"id,omitempty" json:"id"`
+	FileName    string    `bson:"fileName" json:"fileName"`
+	StorageKey  string    `bson:"storageKey,omitempty" json:"storageKey"`
+	FileType    string    `bson:"fileType" json:"fileType"`
+	ContentType string    `bson:"contentType,omitempty" json:"contentType,omitempty"`
+	Size        int64     `bson:"size,omitempty" json:"size,omitempty"`
+	Checksum    string    `bson:"sha256,omitempty" json:"sha256,omitempty"`
+	UploadedAt  time.Time `bson:"uploadedAt" json:"uploadedAt"`
+
+	// Preview diisi worker background setelah upload (thumbnail gambar / metadata PDF)
+	Preview *AttachmentPreview `bson:"preview,omitempty" json:"preview,omitempty"`
+
+	// FilePath hanya ada pada dokumen lama (./uploads/achievements/<id>/<file>)
+	FilePath string `bson:"filePath,omitempty" json:"-"`
+}
+
+const (
+	PreviewPending = "pending"
+	PreviewReady   = "ready"
+	PreviewFailed  = "failed"
+)
+
+// AttachmentPreview adalah hasil pratinjau lampiran. Gambar mendapat thumbnail,
+// PDF mendapat metadata halaman pertama (jumlah halaman, judul, ukuran halaman).
+type AttachmentPreview struct {
+	Status     string                `bson:"status" json:"status"` // pending, ready, failed
+	Thumbnails []AttachmentThumbnail `bson:"thumbnails,omitempty" json:"thumbnails,omitempty"`
+
+	PageCount  int     `bson:"pageCount,omitempty" json:"pageCount,omitempty"`
+	Title      string  `bson:"title,omitempty" json:"title,omitempty"`
+	PageWidth  float64 `bson:"pageWidth,omitempty" json:"pageWidth,omitempty"` // point (1/72 inci)
+	PageHeight float64 `bson:"pageHeight,omitempty" json:"pageHeight,omitempty"`
+
+	Error       string     `bson:"error,omitempty" json:"error,omitempty"`
+	GeneratedAt *time.Time `bson:"generatedAt,omitempty" json:"generatedAt,omitempty"`
+}
+
+// AttachmentThumbnail adalah satu ukuran thumbnail; URL mengarah ke endpoint
+// yang memeriksa hak akses yang sama dengan download lampiran
+type AttachmentThumbnail struct {
+	Size       string `bson:"size" json:"size"` // small, medium
+	Width      int    `bson:"width" json:"width"`
+	Height     int    `bson:"height" json:"height"`
+	URL        string `bson:"url" json:"url"`
+	StorageKey string `bson:"storageKey" json:"-"`
+}
+
+// ThumbnailURL adalah path API thumbnail sebuah lampiran
+func ThumbnailURL(achievementID, attachmentID, size string) string {
+	return "/api/v1/achievements/" + achievementID + "/attachments/" + attachmentID + "/thumbnails/" + size
+}
+
+// NeedsPreview: hanya gambar dan PDF yang punya pratinjau
+func (a Attachment) NeedsPreview() bool {
+	switch a.ContentType {
+	case "image/png", "image/jpeg", "application/pdf":
+		return true
+	}
+	return false
+}
+
+// PreviewKeys mengembalikan storage key semua thumbnail lampiran
+func (a Attachment) PreviewKeys() []string {
+	if a.Preview == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(a.Preview.Thumbnails))
+	for _, t := range a.Preview.Thumbnails {
+		keys = append(keys, t.StorageKey)
+	}
+	return keys
+}
+
+// FindThumbnail mencari thumbnail dengan ukuran tertentu
+func (a Attachment) FindThumbnail(size string) (*AttachmentThumbnail, bool) {
+	if a.Preview == nil {
+		return nil, false
+	}
+	for i := range a.Preview.Thumbnails {
+		if a.Preview.Thumbnails[i].Size == size {
+			return &a.Preview.Thumbnails[i], true
+		}
+	}
+	return nil, false
+}
+
+// Key mengembalikan storage key; dokumen lama dipetakan dari filePath relatif ke root "uploads"
+func (a Attachment) Key() string {
+	if a.StorageKey != "" {
+		return a.StorageKey
+	}
+	if a.FilePath == "" {
+		return ""
+	}
+	p := path.Clean(strings.ReplaceAll(a.FilePath, "\\", "/"))
+	return strings.TrimPrefix(p, "uploads/")
+}
+
+// FindAttachment mencari lampiran berdasarkan ID-nya
+func (a *Achievement) FindAttachment(id string) (*Attachment, bool) {
+	for i := range a.Attachments {
+		if a.Attachments[i].ID == id {
+			return &a.Attachments[i], true
+		}
+	}
+	return nil, false
 }
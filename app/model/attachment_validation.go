@@ -0,0 +1,66 @@
+package model
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+	ErrFileContentMismatch = errors.New("file content does not match its extension")
+)
+
+// attachmentContentTypes: ekstensi yang diizinkan beserta MIME hasil sniffing yang wajib cocok
+var attachmentContentTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+}
+
+// AttachmentExtensions mengembalikan ekstensi lampiran yang diizinkan (terurut)
+func AttachmentExtensions() []string {
+	exts := make([]string, 0, len(attachmentContentTypes))
+	for ext := range attachmentContentTypes {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// SniffAttachment menentukan MIME dari magic bytes (512 byte pertama) dan memastikan
+// cocok dengan ekstensi, supaya file executable yang di-rename menjadi .pdf ditolak
+func SniffAttachment(ext string, head []byte) (string, error) {
+	expected, ok := attachmentContentTypes[strings.ToLower(ext)]
+	if !ok {
+		return "", ErrUnsupportedFileType
+	}
+
+	sniffed := http.DetectContentType(head)
+	if i := strings.Index(sniffed, ";"); i >= 0 {
+		sniffed = sniffed[:i]
+	}
+	if sniffed != expected {
+		return "", ErrFileContentMismatch
+	}
+
+	return sniffed, nil
+}
+
+// AttachmentQuarantine adalah file yang ditandai scanner dan disimpan terpisah (tidak dilampirkan)
+type AttachmentQuarantine struct {
+	ID            uuid.UUID  `json:"id"`
+	AchievementID string     `json:"achievementId"`
+	StorageKey    string     `json:"storageKey"`
+	FileName      string     `json:"fileName"`
+	Size          int64      `json:"size"`
+	Checksum      string     `json:"sha256"`
+	Signature     string     `json:"signature"`
+	UploadedBy    *uuid.UUID `json:"uploadedBy,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Jenis key yang dihitung untuk percobaan login gagal
+const (
+	LoginKeyAccount = "account"
+	LoginKeyIP      = "ip"
+)
+
+// LoginAttempt menyimpan hitungan login gagal per akun (email) atau per IP
+type LoginAttempt struct {
+	KeyType       string     `json:"key_type"`
+	KeyValue      string     `json:"key_value"`
+	FailedCount   int        `json:"failed_count"`
+	FirstFailedAt time.Time  `json:"first_failed_at"`
+	LastFailedAt  time.Time  `json:"last_failed_at"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
+
+// LoginLockout adalah catatan audit setiap kali akun / IP terkunci
+type LoginLockout struct {
+	ID          uuid.UUID  `json:"id"`
+	KeyType     string     `json:"key_type"`
+	KeyValue    string     `json:"key_value"`
+	FailedCount int        `json:"failed_count"`
+	LockedUntil time.Time  `json:"locked_until"`
+	ClearedBy   *uuid.UUID `json:"cleared_by,omitempty"`
+	ClearedAt   *time.Time `json:"cleared_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
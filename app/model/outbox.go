@@ -0,0 +1,46 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Jenis event outbox untuk sinkronisasi PostgreSQL -> MongoDB
+const (
+	OutboxAchievementStatus = "achievement.status" // set status dokumen achievement
+	OutboxAchievementDelete = "achievement.delete" // kompensasi: hapus dokumen yatim
+	OutboxAchievementPoints = "achievement.points" // set poin dokumen achievement (hitung ulang)
+)
+
+// Status event outbox
+const (
+	OutboxPending   = "pending"
+	OutboxProcessed = "processed"
+	OutboxDead      = "dead" // melebihi batas percobaan, perlu ditangani manual
+)
+
+type OutboxEvent struct {
+	ID            int64           `json:"id"`
+	AggregateID   string          `json:"aggregateId"` // mongo_achievement_id
+	EventType     string          `json:"eventType"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     *string         `json:"lastError,omitempty"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	ProcessedAt   *time.Time      `json:"processedAt,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+// AchievementStatusPayload adalah payload event OutboxAchievementStatus
+type AchievementStatusPayload struct {
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
+	Points *int      `json:"points,omitempty"` // diisi saat verify / sinkronisasi ulang
+}
+
+// AchievementPointsPayload adalah payload event OutboxAchievementPoints
+type AchievementPointsPayload struct {
+	Points int       `json:"points"`
+	At     time.Time `json:"at"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Jenis selisih antara achievement_references (PostgreSQL) dan achievements (MongoDB)
+const (
+	DriftMissingReference = "missing_reference" // reference menunjuk dokumen Mongo yang tidak ada
+	DriftOrphanDocument   = "orphan_document"   // dokumen Mongo tanpa reference
+	DriftStatusMismatch   = "status_mismatch"   // status / poin berbeda, PostgreSQL yang dipakai
+)
+
+// AchievementSyncState adalah proyeksi ringan dokumen achievement untuk rekonsiliasi
+type AchievementSyncState struct {
+	ID        string
+	StudentID string
+	Status    string
+	Points    int
+	CreatedAt time.Time
+}
+
+// ReconcileOptions mengatur apa yang boleh diperbaiki oleh rekonsiliasi.
+// Reference PostgreSQL (sumber kebenaran) hanya boleh dihapus dengan Force, dan
+// hanya reference draft yang belum pernah diajukan.
+type ReconcileOptions struct {
+	Apply bool
+	Force bool
+}
+
+type ReconciliationItem struct {
+	Kind           string     `json:"kind"`
+	MongoID        string     `json:"mongoId"`
+	ReferenceID    *uuid.UUID `json:"referenceId,omitempty"`
+	StudentID      string     `json:"studentId,omitempty"`
+	PostgresStatus string     `json:"postgresStatus,omitempty"`
+	MongoStatus    string     `json:"mongoStatus,omitempty"`
+	PostgresPoints *int       `json:"postgresPoints,omitempty"`
+	MongoPoints    *int       `json:"mongoPoints,omitempty"`
+	Action         string     `json:"action"`
+	Repaired       bool       `json:"repaired"`
+	Error          string     `json:"error,omitempty"`
+}
+
+type ReconciliationReport struct {
+	Mode              string               `json:"mode"` // dry-run / apply
+	CheckedReferences int                  `json:"checkedReferences"`
+	CheckedDocuments  int                  `json:"checkedDocuments"`
+	MissingReferences []ReconciliationItem `json:"missingReferences"`
+	OrphanDocuments   []ReconciliationItem `json:"orphanDocuments"`
+	StatusMismatches  []ReconciliationItem `json:"statusMismatches"`
+	Repaired          int                  `json:"repaired"`
+	StartedAt         time.Time            `json:"startedAt"`
+	FinishedAt        time.Time            `json:"finishedAt"`
+}
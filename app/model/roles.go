@@ -10,6 +10,29 @@ type Role struct {
 	ID          uuid.UUID `json:"id"`
 	Name        string    `json:"role"`
 	Description string    `json:"description"`
+	Require2FA  bool      `json:"require_2fa"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
+// Role bawaan sistem, tidak boleh dihapus atau diganti namanya
+var BuiltinRoles = []string{"Admin", "Mahasiswa", "Dosen Wali"}
+
+func IsBuiltinRole(name string) bool {
+	for _, r := range BuiltinRoles {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+type RoleDetail struct {
+	Role
+	Permissions []Permission `json:"permissions"`
+}
+
+type RoleRequest struct {
+	Name        string `json:"role"`
+	Description string `json:"description"`
+}
+
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tujuan challenge login 2FA
+const (
+	ChallengeTOTP   = "totp"   // user sudah enroll, tinggal kirim kode
+	ChallengeEnroll = "enroll" // role mewajibkan 2FA tapi user belum enroll
+)
+
+// UserTwoFactor menyimpan secret TOTP (terenkripsi) per user
+type UserTwoFactor struct {
+	UserID       uuid.UUID  `json:"user_id"`
+	SecretEnc    string     `json:"-"`
+	Enabled      bool       `json:"enabled"`
+	LastUsedStep int64      `json:"-"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// LoginChallenge adalah langkah kedua login setelah password benar
+type LoginChallenge struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Purpose   string     `json:"purpose"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	Attempts  int        `json:"attempts"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recoveryCode"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
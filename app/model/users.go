@@ -28,5 +28,6 @@ type JWTClaims struct {
 	UserID      string   `json:"user_id"`
 	RoleID      string   `json:"role_id"`
 	Permissions []string `json:"permissions"`
+	TokenVersion int     `json:"token_version"`
 	jwt.RegisteredClaims
 }
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	maxOverridePoints    = 1000
+	maxFeedbackComment   = 1000
+	maxJustificationSize = 1000
+)
+
+// RejectionReason adalah entri daftar alasan penolakan yang dikelola admin
+type RejectionReason struct {
+	Code        string    `json:"code"`
+	Label       string    `json:"label"`
+	Description string    `json:"description"`
+	IsActive    bool      `json:"isActive"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type RejectionReasonRequest struct {
+	Code        string `json:"code"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	IsActive    *bool  `json:"isActive"`
+}
+
+// ValidationError membungkus kesalahan validasi per field agar bisa dikembalikan sebagai error
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return "validation failed"
+}
+
+// AchievementFeedback adalah umpan balik penolakan terakhir yang ditampilkan ke mahasiswa
+type AchievementFeedback struct {
+	Round         int               `json:"round"`
+	Reasons       []RejectionReason `json:"reasons"`
+	FieldComments []FieldComment    `json:"fieldComments"`
+	Note          *string           `json:"note,omitempty"`
+	ReviewedBy    *string           `json:"reviewedBy,omitempty"`
+	ReviewedAt    *time.Time        `json:"reviewedAt,omitempty"`
+}
+
+// ValidateVerifyPayload: override poin wajib disertai justification
+func ValidateVerifyPayload(p TransitionPayload) []FieldError {
+	var errs []FieldError
+	if p.Points == nil {
+		return nil
+	}
+
+	if *p.Points < 0 || *p.Points > maxOverridePoints {
+		errs = append(errs, FieldError{Field: "points", Message: fmt.Sprintf("must be between 0 and %d", maxOverridePoints)})
+	}
+	justification := strings.TrimSpace(p.Justification)
+	switch {
+	case justification == "":
+		errs = append(errs, FieldError{Field: "justification", Message: "is required when points are adjusted"})
+	case len([]rune(justification)) > maxJustificationSize:
+		errs = append(errs, FieldError{Field: "justification", Message: fmt.Sprintf("must be at most %d characters", maxJustificationSize)})
+	}
+
+	return errs
+}
+
+// ValidateRejectPayload: minimal satu alasan aktif, dan komentar hanya untuk field
+// yang memang ada pada prestasi dengan tipe tersebut
+func ValidateRejectPayload(p TransitionPayload, achievement *Achievement, activeReasons map[string]bool) []FieldError {
+	var errs []FieldError
+
+	if len(p.Reasons) == 0 {
+		errs = append(errs, FieldError{Field: "reasons", Message: "at least one rejection reason is required"})
+	}
+	seen := map[string]bool{}
+	for i, code := range p.Reasons {
+		if !activeReasons[code] {
+			errs = append(errs, FieldError{Field: fmt.Sprintf("reasons[%d]", i), Message: fmt.Sprintf("unknown rejection reason %q", code)})
+		}
+		if seen[code] {
+			errs = append(errs, FieldError{Field: fmt.Sprintf("reasons[%d]", i), Message: "duplicate rejection reason"})
+		}
+		seen[code] = true
+	}
+
+	for i, fc := range p.FieldComments {
+		prefix := fmt.Sprintf("fieldComments[%d]", i)
+		if !IsReviewableField(achievement, fc.Field) {
+			errs = append(errs, FieldError{Field: prefix + ".field", Message: fmt.Sprintf("unknown field %q", fc.Field)})
+		}
+		comment := strings.TrimSpace(fc.Comment)
+		switch {
+		case comment == "":
+			errs = append(errs, FieldError{Field: prefix + ".comment", Message: "is required"})
+		case len([]rune(comment)) > maxFeedbackComment:
+			errs = append(errs, FieldError{Field: prefix + ".comment", Message: fmt.Sprintf("must be at most %d characters", maxFeedbackComment)})
+		}
+	}
+
+	return errs
+}
+
+// IsReviewableField: field yang bisa diberi komentar, yaitu field yang bisa diedit
+// mahasiswa, lampiran, dan setiap key details dari schema tipenya
+func IsReviewableField(achievement *Achievement, field string) bool {
+	if achievementEditableFields[field] || field == "attachments" {
+		return true
+	}
+
+	key := strings.TrimPrefix(field, "details.")
+	if key == field || key == "" {
+		return false
+	}
+	if _, ok := achievementSchemas[achievement.AchievementType][key]; ok {
+		return true
+	}
+	_, ok := achievement.Details[key]
+	return ok
+}
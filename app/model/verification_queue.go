@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PendingVerification adalah satu prestasi yang menunggu verifikasi dosen wali
+type PendingVerification struct {
+	AchievementID   string    `json:"achievementId"`
+	Title           string    `json:"title"`
+	AchievementType string    `json:"achievementType"`
+	Round           int       `json:"round"`
+	StudentUUID     uuid.UUID `json:"studentUuid"`
+	StudentID       string    `json:"studentId"` // NIM
+	StudentName     string    `json:"studentName"`
+	ProgramStudy    string    `json:"programStudy"`
+	SubmittedAt     time.Time `json:"submittedAt"`
+	WaitingDays     int       `json:"waitingDays"`
+}
+
+// VerificationQueueFilter: filter & urutan antrean verifikasi seorang dosen wali
+type VerificationQueueFilter struct {
+	AdvisorID       uuid.UUID
+	AchievementType string
+	ProgramStudy    string
+	NewestFirst     bool // default: yang paling lama menunggu lebih dulu
+	Limit           int
+	Offset          int
+}
+
+// VerificationQueueCounts dipakai untuk badge jumlah pengajuan yang menunggu
+type VerificationQueueCounts struct {
+	Total          int            `json:"total"`
+	Overdue        int            `json:"overdue"` // menunggu lebih dari OverdueAfterDays hari
+	ByType         map[string]int `json:"byType"`
+	ByProgramStudy map[string]int `json:"byProgramStudy"`
+}
+
+// OverdueAfterDays: batas hari sebelum pengajuan dianggap terlambat diverifikasi
+const OverdueAfterDays = 7
@@ -0,0 +1,201 @@
+package policy
+
+import (
+	"errors"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+	"UAS/app/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Action adalah jenis akses terhadap data milik seorang mahasiswa
+type Action string
+
+const (
+	// View: membaca data (pemilik, dosen wali, atau admin)
+	View Action = "view"
+	// Modify: mengubah data milik sendiri (hanya mahasiswa pemilik)
+	Modify Action = "modify"
+	// Verify: verifikasi / tolak prestasi (hanya dosen wali mahasiswa tsb)
+	Verify Action = "verify"
+)
+
+var (
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrStudentNotFound = errors.New("student not found")
+)
+
+// Actor adalah user yang sedang login beserta profil mahasiswa / dosennya
+type Actor struct {
+	UserID      string
+	Permissions []string
+	Students    []model.StudentResponse
+	Lecturer    *model.LecturerResponse
+}
+
+// IsAdmin: admin boleh membaca semua data, tetapi tidak bertindak sebagai pemilik / dosen wali
+func (a *Actor) IsAdmin() bool {
+	return utils.HasPermission(a.Permissions, "user:manage")
+}
+
+func (a *Actor) IsStudent() bool {
+	return len(a.Students) > 0
+}
+
+func (a *Actor) IsLecturer() bool {
+	return a.Lecturer != nil
+}
+
+// StudentNumbers mengembalikan NIM milik actor (dipakai sebagai studentId di MongoDB)
+func (a *Actor) StudentNumbers() []string {
+	var nims []string
+	for _, s := range a.Students {
+		nims = append(nims, s.StudentID)
+	}
+	return nims
+}
+
+// Owns mengecek apakah mahasiswa s adalah profil milik actor
+func (a *Actor) Owns(s *model.StudentResponse) bool {
+	return s != nil && s.UserID.String() == a.UserID
+}
+
+// Advises mengecek apakah actor adalah dosen wali mahasiswa s
+func (a *Actor) Advises(s *model.StudentResponse) bool {
+	return s != nil && a.Lecturer != nil && s.AdvisorID != nil && *s.AdvisorID == a.Lecturer.ID
+}
+
+// Can menentukan apakah actor boleh melakukan action terhadap data mahasiswa s
+func (a *Actor) Can(action Action, s *model.StudentResponse) bool {
+	switch action {
+	case View:
+		return a.IsAdmin() || a.Owns(s) || a.Advises(s)
+	case Modify:
+		return a.Owns(s)
+	case Verify:
+		return a.Advises(s)
+	}
+	return false
+}
+
+// Policy memeriksa kepemilikan data dan relasi dosen wali (students.advisor_id)
+type Policy struct {
+	Students  *repository.StudentRepository
+	Lecturers *repository.LecturerRepository
+}
+
+func New(students *repository.StudentRepository, lecturers *repository.LecturerRepository) *Policy {
+	return &Policy{Students: students, Lecturers: lecturers}
+}
+
+// Actor memuat actor dari token pada request; hasilnya disimpan di Locals
+// agar tidak di-query ulang dalam request yang sama
+func (p *Policy) Actor(c *fiber.Ctx) (*Actor, error) {
+	if cached, ok := c.Locals("actor").(*Actor); ok {
+		return cached, nil
+	}
+
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return nil, ErrUnauthorized
+	}
+	perms, _ := c.Locals("permissions").([]string)
+
+	students, err := p.Students.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	lecturer, err := p.Lecturers.GetByUserID(userID)
+	if err != nil && err.Error() != "lecturer not found" {
+		return nil, err
+	}
+
+	actor := &Actor{
+		UserID:      userID,
+		Permissions: perms,
+		Students:    students,
+		Lecturer:    lecturer,
+	}
+	c.Locals("actor", actor)
+
+	return actor, nil
+}
+
+func (p *Policy) authorize(c *fiber.Ctx, student *model.StudentResponse, action Action) (*model.StudentResponse, error) {
+	actor, err := p.Actor(c)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.Can(action, student) {
+		return nil, ErrForbidden
+	}
+	return student, nil
+}
+
+// AuthorizeStudent memeriksa akses ke mahasiswa berdasarkan UUID students.id
+func (p *Policy) AuthorizeStudent(c *fiber.Ctx, studentID string, action Action) (*model.StudentResponse, error) {
+	student, err := p.Students.GetByID(studentID)
+	if err != nil {
+		if err.Error() == "student not found" {
+			return nil, ErrStudentNotFound
+		}
+		return nil, err
+	}
+	return p.authorize(c, student, action)
+}
+
+// AuthorizeStudentNumber memeriksa akses ke mahasiswa berdasarkan NIM
+// (studentId pada dokumen achievement di MongoDB)
+func (p *Policy) AuthorizeStudentNumber(c *fiber.Ctx, nim string, action Action) (*model.StudentResponse, error) {
+	student, err := p.Students.GetByStudentNumber(nim)
+	if err != nil {
+		if err.Error() == "student not found" {
+			return nil, ErrStudentNotFound
+		}
+		return nil, err
+	}
+	return p.authorize(c, student, action)
+}
+
+// AuthorizeLecturer: data dosen (mis. daftar mahasiswa bimbingan) hanya untuk
+// dosen yang bersangkutan atau admin
+func (p *Policy) AuthorizeLecturer(c *fiber.Ctx, lecturerID uuid.UUID) error {
+	actor, err := p.Actor(c)
+	if err != nil {
+		return err
+	}
+	if actor.IsAdmin() || (actor.Lecturer != nil && actor.Lecturer.ID == lecturerID) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// RequireAdmin untuk data agregat seluruh mahasiswa
+func (p *Policy) RequireAdmin(c *fiber.Ctx) error {
+	actor, err := p.Actor(c)
+	if err != nil {
+		return err
+	}
+	if !actor.IsAdmin() {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// Status memetakan error policy ke HTTP status
+func Status(err error) int {
+	switch err {
+	case ErrUnauthorized:
+		return fiber.StatusUnauthorized
+	case ErrForbidden:
+		return fiber.StatusForbidden
+	case ErrStudentNotFound:
+		return fiber.StatusNotFound
+	}
+	return fiber.StatusInternalServerError
+}
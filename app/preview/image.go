@@ -0,0 +1,158 @@
+package preview
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+// Size adalah satu ukuran thumbnail; Max = sisi terpanjang dalam pixel
+type Size struct {
+	Name string
+	Max  int
+}
+
+// DefaultSizes: small untuk daftar antrean verifikasi, medium untuk pratinjau detail
+var DefaultSizes = []Size{
+	{Name: "small", Max: 160},
+	{Name: "medium", Max: 640},
+}
+
+// MaxPixels membatasi dimensi gambar yang mau didekode. File 5 MiB bisa berisi PNG
+// puluhan ribu pixel per sisi (decompression bomb), jadi dimensi dicek sebelum decode.
+var MaxPixels = 24_000_000
+
+// JPEGQuality untuk thumbnail yang dihasilkan
+var JPEGQuality = 80
+
+var ErrImageTooLarge = errors.New("image dimensions exceed the preview limit")
+
+// Thumbnail adalah satu hasil resize yang sudah di-encode JPEG
+type Thumbnail struct {
+	Size   Size
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Thumbnails mendekode gambar PNG/JPEG lalu membuat satu thumbnail JPEG per ukuran.
+// Gambar tidak pernah diperbesar; transparansi diratakan ke latar putih.
+func Thumbnails(data []byte, sizes []Size) ([]Thumbnail, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, fmt.Errorf("invalid image dimensions %dx%d", cfg.Width, cfg.Height)
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	flat := flatten(src)
+
+	thumbs := make([]Thumbnail, 0, len(sizes))
+	for _, size := range sizes {
+		w, h := Fit(flat.Bounds().Dx(), flat.Bounds().Dy(), size.Max)
+
+		var buf bytes.Buffer
+		if err := encodeJPEG(&buf, Resize(flat, w, h)); err != nil {
+			return nil, err
+		}
+		thumbs = append(thumbs, Thumbnail{Size: size, Width: w, Height: h, Data: buf.Bytes()})
+	}
+
+	return thumbs, nil
+}
+
+// Fit menghitung dimensi baru dengan sisi terpanjang <= limit, rasio dipertahankan
+func Fit(w, h, limit int) (int, int) {
+	if w <= limit && h <= limit {
+		return w, h
+	}
+	if w >= h {
+		nh := h * limit / w
+		if nh < 1 {
+			nh = 1
+		}
+		return limit, nh
+	}
+	nw := w * limit / h
+	if nw < 1 {
+		nw = 1
+	}
+	return nw, limit
+}
+
+// flatten menyalin gambar ke RGBA di atas latar putih (JPEG tidak punya alpha)
+func flatten(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Over)
+	return dst
+}
+
+// Resize mengecilkan gambar dengan box filter: setiap pixel tujuan adalah rata-rata
+// seluruh pixel sumber yang tertutup olehnya. Cukup tajam untuk teks sertifikat dan
+// tidak menimbulkan aliasing seperti nearest-neighbour.
+func Resize(src *image.RGBA, w, h int) *image.RGBA {
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for dy := 0; dy < h; dy++ {
+		y0, y1 := span(dy, h, sh)
+		for dx := 0; dx < w; dx++ {
+			x0, x1 := span(dx, w, sw)
+
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				row := src.Pix[src.PixOffset(sb.Min.X+x0, sb.Min.Y+y):]
+				for x := 0; x < x1-x0; x++ {
+					p := row[x*4 : x*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					b += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+
+			o := dst.PixOffset(dx, dy)
+			dst.Pix[o+0] = uint8(r / n)
+			dst.Pix[o+1] = uint8(g / n)
+			dst.Pix[o+2] = uint8(b / n)
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// span: rentang pixel sumber [lo, hi) untuk pixel tujuan i (minimal satu pixel)
+func span(i, dstLen, srcLen int) (int, int) {
+	lo := i * srcLen / dstLen
+	hi := (i + 1) * srcLen / dstLen
+	if hi <= lo {
+		hi = lo + 1
+	}
+	if hi > srcLen {
+		hi = srcLen
+	}
+	return lo, hi
+}
+
+func encodeJPEG(w io.Writer, img image.Image) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: JPEGQuality})
+}
@@ -0,0 +1,382 @@
+package preview
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+)
+
+// PDFInfo adalah metadata dokumen dan halaman pertama PDF
+type PDFInfo struct {
+	PageCount int
+	Title     string
+	// Ukuran halaman pertama dalam point (1/72 inci), dari MediaBox
+	PageWidth  float64
+	PageHeight float64
+}
+
+var (
+	ErrNotPDF            = errors.New("not a PDF document")
+	ErrPDFTooLarge       = errors.New("PDF object streams exceed the preview inflate limit")
+	ErrPDFTooManyObjects = errors.New("PDF exceeds the preview object limit")
+)
+
+// maxObjectStream membatasi hasil inflate satu object stream
+const maxObjectStream = 16 << 20
+
+// MaxPDFInflatedBytes membatasi total hasil inflate seluruh object stream. Satu file
+// 5 MiB bisa berisi ratusan stream kecil yang masing-masing mengembang jadi belasan MiB.
+var MaxPDFInflatedBytes = 32 << 20
+
+// MaxPDFObjects membatasi jumlah objek (langsung maupun di dalam object stream)
+var MaxPDFObjects = 100_000
+
+var (
+	pdfObject    = regexp.MustCompile(`(?s)(\d+)\s+\d+\s+obj\b(.*?)\bendobj`)
+	pdfStream    = regexp.MustCompile(`(?s)^(.*?)\bstream\r?\n(.*)\bendstream`)
+	pdfRootRef   = refPattern("Root")
+	pdfInfoRef   = refPattern("Info")
+	pdfPagesRef  = refPattern("Pages")
+	pdfTitleRef  = refPattern("Title")
+	pdfFirstKid  = regexp.MustCompile(`/Kids\s*\[\s*(\d+)\s+\d+\s+R`)
+	pdfCount     = regexp.MustCompile(`/Count\s+(\d+)`)
+	pdfTypePages = regexp.MustCompile(`/Type\s*/Pages\b`)
+	pdfTypePage  = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfObjStm    = regexp.MustCompile(`/Type\s*/ObjStm\b`)
+	pdfFlate     = regexp.MustCompile(`/Filter\s*\[?\s*/FlateDecode\s*\]?`)
+	pdfFirst     = regexp.MustCompile(`/First\s+(\d+)`)
+	pdfMediaBox  = regexp.MustCompile(`/MediaBox\s*\[\s*(-?[\d.]+)\s+(-?[\d.]+)\s+(-?[\d.]+)\s+(-?[\d.]+)\s*\]`)
+	pdfTitle     = regexp.MustCompile(`/Title\s*([(<])`)
+)
+
+// refPattern mencocokkan referensi tidak langsung "/Key n 0 R"
+func refPattern(key string) *regexp.Regexp {
+	return regexp.MustCompile(`/` + key + `\s+(\d+)\s+\d+\s+R`)
+}
+
+// ReadPDFInfo membaca jumlah halaman, judul dan ukuran halaman pertama tanpa merender.
+// Objek dalam object stream (PDF 1.5+) yang dikompres FlateDecode ikut dibaca.
+// Field yang tidak bisa ditemukan dibiarkan kosong.
+func ReadPDFInfo(data []byte) (*PDFInfo, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data[:min(len(data), 1024)], "\x00\t\r\n "), []byte("%PDF-")) {
+		return nil, ErrNotPDF
+	}
+
+	objects, err := pdfObjects(data)
+	if err != nil {
+		return nil, err
+	}
+	info := &PDFInfo{}
+
+	// Catalog -> Pages -> Kids[0] ... sampai halaman pertama
+	if root, ok := resolve(objects, pdfRootRef, string(data)); ok {
+		if node, ok := resolve(objects, pdfPagesRef, root); ok {
+			if m := pdfCount.FindStringSubmatch(node); m != nil {
+				info.PageCount, _ = strconv.Atoi(m[1])
+			}
+			info.PageWidth, info.PageHeight = firstPageSize(objects, node)
+		}
+	}
+
+	// cadangan bila rantai objek rusak: hitung objek /Type /Page
+	if info.PageCount == 0 {
+		for _, body := range objects {
+			if pdfTypePage.MatchString(body) {
+				info.PageCount++
+			}
+		}
+	}
+
+	// dokumen terenkripsi: string judul ikut terenkripsi
+	if !bytes.Contains(data, []byte("/Encrypt")) {
+		if dict, ok := resolve(objects, pdfInfoRef, string(data)); ok {
+			info.Title = titleOf(objects, dict)
+		}
+	}
+
+	return info, nil
+}
+
+// pdfObjects memetakan nomor objek ke isinya (dictionary, tanpa data stream).
+// Definisi yang lebih akhir (incremental update) menimpa yang lama.
+// Berhenti dengan error bila total inflate atau jumlah objek melewati batas.
+func pdfObjects(data []byte) (map[int]string, error) {
+	objects := map[int]string{}
+
+	matches := pdfObject.FindAllSubmatch(data, MaxPDFObjects+1)
+	if len(matches) > MaxPDFObjects {
+		return nil, ErrPDFTooManyObjects
+	}
+	count, inflated := len(matches), 0
+
+	for _, m := range matches {
+		num, err := strconv.Atoi(string(m[1]))
+		if err != nil {
+			continue
+		}
+		body := m[2]
+
+		s := pdfStream.FindSubmatch(body)
+		if s == nil {
+			objects[num] = string(body)
+			continue
+		}
+		objects[num] = string(s[1])
+
+		dict := s[1]
+		if pdfObjStm.Match(dict) && pdfFlate.Match(dict) {
+			inner, n, err := objectStream(dict, s[2], MaxPDFInflatedBytes-inflated, MaxPDFObjects-count)
+			if err != nil {
+				return nil, err
+			}
+			inflated += n
+			count += len(inner)
+			for n, obj := range inner {
+				if _, ok := objects[n]; !ok {
+					objects[n] = obj
+				}
+			}
+		}
+	}
+
+	return objects, nil
+}
+
+// objectStream membongkar object stream: header "num offset ..." lalu isi objek mulai /First.
+// Mengembalikan juga jumlah byte hasil inflate; maxBytes dan maxObjects adalah sisa batas.
+func objectStream(dict, raw []byte, maxBytes, maxObjects int) (map[int]string, int, error) {
+	out := map[int]string{}
+
+	m := pdfFirst.FindSubmatch(dict)
+	if m == nil {
+		return out, 0, nil
+	}
+	first, _ := strconv.Atoi(string(m[1]))
+
+	zr, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return out, 0, nil
+	}
+	defer zr.Close()
+
+	// satu byte lebih dari sisa batas total untuk mendeteksi pelanggaran
+	limit := int64(maxObjectStream)
+	if maxBytes < maxObjectStream {
+		limit = int64(maxBytes) + 1
+	}
+	content, err := io.ReadAll(io.LimitReader(zr, limit))
+	if len(content) > maxBytes {
+		return nil, len(content), ErrPDFTooLarge
+	}
+	if err != nil && len(content) == 0 {
+		return out, 0, nil
+	}
+	if first <= 0 || first > len(content) {
+		return out, len(content), nil
+	}
+
+	header := bytes.Fields(content[:first])
+	type entry struct{ num, offset int }
+	entries := []entry{}
+	for i := 0; i+1 < len(header); i += 2 {
+		num, err1 := strconv.Atoi(string(header[i]))
+		offset, err2 := strconv.Atoi(string(header[i+1]))
+		// offset negatif / overflow dari file rusak atau sengaja dibuat: berhenti
+		if err1 != nil || err2 != nil || offset < 0 || first+offset < first || first+offset > len(content) {
+			break
+		}
+		if len(entries) == maxObjects {
+			return nil, len(content), ErrPDFTooManyObjects
+		}
+		entries = append(entries, entry{num, first + offset})
+	}
+
+	for i, e := range entries {
+		end := len(content)
+		if i+1 < len(entries) && entries[i+1].offset >= e.offset {
+			end = entries[i+1].offset
+		}
+		if e.offset < 0 || e.offset > end || end > len(content) {
+			continue
+		}
+		out[e.num] = string(content[e.offset:end])
+	}
+
+	return out, len(content), nil
+}
+
+// resolve mengikuti referensi "/Key n 0 R" di src ke isi objek n
+func resolve(objects map[int]string, ref *regexp.Regexp, src string) (string, bool) {
+	all := ref.FindAllStringSubmatch(src, -1)
+	if len(all) == 0 {
+		return "", false
+	}
+	// trailer terakhir yang berlaku
+	num, err := strconv.Atoi(all[len(all)-1][1])
+	if err != nil {
+		return "", false
+	}
+	body, ok := objects[num]
+	return body, ok
+}
+
+// firstPageSize turun lewat Kids pertama; MediaBox boleh diwarisi dari node Pages
+func firstPageSize(objects map[int]string, node string) (float64, float64) {
+	var w, h float64
+	for depth := 0; depth < 32; depth++ {
+		if m := pdfMediaBox.FindStringSubmatch(node); m != nil {
+			x0, _ := strconv.ParseFloat(m[1], 64)
+			y0, _ := strconv.ParseFloat(m[2], 64)
+			x1, _ := strconv.ParseFloat(m[3], 64)
+			y1, _ := strconv.ParseFloat(m[4], 64)
+			w, h = abs(x1-x0), abs(y1-y0)
+		}
+		if !pdfTypePages.MatchString(node) {
+			break
+		}
+		kid, ok := resolve(objects, pdfFirstKid, node)
+		if !ok {
+			break
+		}
+		node = kid
+	}
+	return w, h
+}
+
+// titleOf membaca /Title (literal, hex, atau referensi ke objek string)
+func titleOf(objects map[int]string, dict string) string {
+	if target, ok := resolve(objects, pdfTitleRef, dict); ok {
+		dict = "/Title " + target
+	}
+
+	m := pdfTitle.FindStringSubmatchIndex(dict)
+	if m == nil {
+		return ""
+	}
+	s := dict[m[2]:]
+	if s[0] == '(' {
+		return decodeText(literalString(s))
+	}
+	return decodeText(hexString(s))
+}
+
+// literalString mendekode string "( ... )" dengan kurung bersarang dan escape
+func literalString(s string) []byte {
+	out := []byte{}
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch e := s[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if i+1 < len(s) && s[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					j := i
+					for ; j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7'; j++ {
+						v = v*8 + int(s[j]-'0')
+					}
+					out = append(out, byte(v))
+					i = j - 1
+				} else {
+					out = append(out, e)
+				}
+			}
+		case c == '(':
+			if depth > 0 {
+				out = append(out, c)
+			}
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// hexString mendekode string "<48656C6C6F>"; digit terakhir yang ganjil dianggap diikuti 0
+func hexString(s string) []byte {
+	out := []byte{}
+	var hi byte
+	odd := false
+	for i := 1; i < len(s) && s[i] != '>'; i++ {
+		v, ok := hexDigit(s[i])
+		if !ok {
+			continue
+		}
+		if !odd {
+			hi = v
+		} else {
+			out = append(out, hi<<4|v)
+		}
+		odd = !odd
+	}
+	if odd {
+		out = append(out, hi<<4)
+	}
+	return out
+}
+
+func hexDigit(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// decodeText: UTF-16BE dengan BOM, UTF-8 dengan BOM, selain itu PDFDocEncoding (~Latin-1)
+func decodeText(b []byte) string {
+	switch {
+	case len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF:
+		units := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(units))
+	case len(b) >= 3 && b[0] == 0xEF && b[1] == 0xBB && b[2] == 0xBF:
+		return string(b[3:])
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
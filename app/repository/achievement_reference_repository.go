@@ -2,6 +2,8 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -18,8 +20,9 @@ func NewAchievementReferenceRepository(db *sql.DB) *AchievementReferenceReposito
 	return &AchievementReferenceRepository{DB: db}
 }
 
-// CreateReference membuat reference prestasi baru
-func (r *AchievementReferenceRepository) CreateReference(studentUUID uuid.UUID, mongoID string) (*model.AchievementReference, error) {
+// CreateReference membuat reference prestasi baru (draft) beserta baris
+// pertama riwayat statusnya
+func (r *AchievementReferenceRepository) CreateReference(studentUUID uuid.UUID, mongoID string, actorID string) (*model.AchievementReference, error) {
 	query := `
 		INSERT INTO achievement_references
 		(id, student_id, mongo_achievement_id, status, created_at, updated_at)
@@ -30,7 +33,13 @@ func (r *AchievementReferenceRepository) CreateReference(studentUUID uuid.UUID,
 	now := time.Now()
 	ref := &model.AchievementReference{}
 
-	err := r.DB.QueryRow(query, id, studentUUID, mongoID, "draft", now, now).Scan(
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(query, id, studentUUID, mongoID, "draft", now, now).Scan(
 		&ref.ID,
 		&ref.StudentID,
 		&ref.MongoAchievementID,
@@ -46,113 +55,511 @@ func (r *AchievementReferenceRepository) CreateReference(studentUUID uuid.UUID,
 		return nil, fmt.Errorf("failed create reference: %v", err)
 	}
 
+	if err := insertStatusHistory(tx, model.AchievementTransition{
+		AchievementID: mongoID,
+		Event:         model.EventCreate,
+		To:            model.StatusDraft,
+		ActorID:       actorID,
+		At:            now,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return ref, nil
 }
 
+// insertStatusHistory menulis satu baris riwayat. Role actor diambil saat itu
+// juga dari tabel users/roles agar riwayat tidak berubah jika role user diganti.
+func insertStatusHistory(tx *sql.Tx, t model.AchievementTransition) error {
+	_, err := tx.Exec(`
+		INSERT INTO achievement_status_history
+		(id, mongo_achievement_id, event, from_status, to_status, actor_id, actor_role, note, created_at)
+		VALUES (
+			$1, $2, $3, NULLIF($4, ''), $5, $6,
+			(SELECT ro.name FROM users u JOIN roles ro ON ro.id = u.role_id WHERE u.id = $6),
+			NULLIF($7, ''), $8
+		)
+	`, uuid.New(), t.AchievementID, t.Event, string(t.From), t.To, t.ActorID, t.Note, t.At)
+	return err
+}
 
-func (r *AchievementReferenceRepository) StatusHistory(mongoID string) (*model.AchievementStatusHistory, error) {
-	query := `
-		SELECT status, updated_at, verified_by, rejection_note
-		FROM achievement_references
+// History mengambil riwayat status secara kronologis (paling lama dulu) beserta total baris
+func (r *AchievementReferenceRepository) History(mongoID string, limit, offset int) ([]model.AchievementStatusHistory, int, error) {
+	var total int
+	if err := r.DB.QueryRow(`
+		SELECT COUNT(*) FROM achievement_status_history WHERE mongo_achievement_id = $1
+	`, mongoID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT id, event, from_status, to_status, actor_id, actor_role, note, created_at
+		FROM achievement_status_history
 		WHERE mongo_achievement_id = $1
-	`
+		ORDER BY created_at ASC, id ASC
+		LIMIT $2 OFFSET $3
+	`, mongoID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
 
-	var h model.AchievementStatusHistory
-	err := r.DB.QueryRow(query, mongoID).Scan(
-		&h.Status,
-		&h.UpdatedAt,
-		&h.VerifiedBy,
-		&h.RejectionNote,
+	history := []model.AchievementStatusHistory{}
+	for rows.Next() {
+		var h model.AchievementStatusHistory
+		if err := rows.Scan(
+			&h.ID,
+			&h.Event,
+			&h.FromStatus,
+			&h.ToStatus,
+			&h.ActorID,
+			&h.ActorRole,
+			&h.Note,
+			&h.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		history = append(history, h)
+	}
+
+	return history, total, rows.Err()
+}
+
+// ErrTransitionConflict: status reference sudah berubah sejak dibaca (request lain lebih dulu)
+var ErrTransitionConflict = errors.New("achievement status has changed, please reload")
+
+const referenceColumns = `
+	id, student_id, mongo_achievement_id, status, submitted_at, verified_at, verified_by, rejection_note, deleted_at, submission_round, points, created_at, updated_at
+`
+
+func scanReference(row interface{ Scan(...interface{}) error }, ref *model.AchievementReference) error {
+	return row.Scan(
+		&ref.ID,
+		&ref.StudentID,
+		&ref.MongoAchievementID,
+		&ref.Status,
+		&ref.SubmittedAt,
+		&ref.VerifiedAt,
+		&ref.VerifiedBy,
+		&ref.RejectionNote,
+		&ref.DeletedAt,
+		&ref.SubmissionRound,
+		&ref.Points,
+		&ref.CreatedAt,
+		&ref.UpdatedAt,
 	)
+}
+
+// GetByMongoID mengambil reference berdasarkan id dokumen MongoDB
+func (r *AchievementReferenceRepository) GetByMongoID(mongoID string) (*model.AchievementReference, error) {
+	var ref model.AchievementReference
+	err := scanReference(r.DB.QueryRow(`
+		SELECT `+referenceColumns+`
+		FROM achievement_references
+		WHERE mongo_achievement_id = $1
+	`, mongoID), &ref)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil
+			return nil, fmt.Errorf("achievement reference not found")
 		}
 		return nil, err
 	}
 
-	return &h, nil
+	return &ref, nil
 }
 
-func (r *AchievementReferenceRepository) Submit(mongoID string) error {
-	now := time.Now()
+// ApplyTransition menyimpan perpindahan status, riwayatnya, dan event outbox dalam
+// satu transaksi, sehingga status di MongoDB pasti menyusul. Update bersyarat pada status
+// asal (t.From) mencegah dua transisi yang saling balapan sama-sama berhasil.
+func (r *AchievementReferenceRepository) ApplyTransition(t model.AchievementTransition) error {
+	// $1 = waktu transisi, kolom tambahan mulai $2
+	var (
+		set  string
+		args []interface{}
+	)
+	switch t.Event {
+	case model.EventSubmit:
+		set = "status = $2, submitted_at = $1, submission_round = submission_round + 1"
+		args = []interface{}{t.To}
+	case model.EventVerify:
+		set = "status = $2, verified_at = $1, verified_by = $3, points = $4, points_overridden = $5"
+		args = []interface{}{t.To, t.ActorID, awardedPoints(t), t.Award != nil && t.Award.Overridden}
+	case model.EventReject:
+		set = "status = $2, verified_at = $1, verified_by = $3, rejection_note = $4"
+		args = []interface{}{t.To, t.ActorID, t.Note}
+	case model.EventRevise:
+		set = "status = $2"
+		args = []interface{}{t.To}
+	case model.EventDelete:
+		set = "deleted_at = $1"
+	default:
+		return &model.InvalidTransitionError{Event: t.Event, From: t.From}
+	}
 
-	query := `
+	n := len(args) + 1
+	query := fmt.Sprintf(`
 		UPDATE achievement_references
-		SET
-			status = $1,
-			submitted_at = $2,
-			updated_at = $2
-		WHERE mongo_achievement_id = $3
-	`
+		SET %s, updated_at = $1
+		WHERE mongo_achievement_id = $%d
+		  AND status = $%d
+		  AND deleted_at IS NULL
+	`, set, n+1, n+2)
 
-	_, err := r.DB.Exec(
-		query,
-		model.StatusSubmitted,
-		now,
-		mongoID,
-	)
+	params := append([]interface{}{t.At}, args...)
+	params = append(params, t.AchievementID, t.From)
 
-	return err
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(query, params...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrTransitionConflict
+	}
+
+	if err := insertStatusHistory(tx, t); err != nil {
+		return err
+	}
+
+	if err := recordRound(tx, t); err != nil {
+		return err
+	}
+
+	payload := model.AchievementStatusPayload{
+		Status: string(t.To),
+		At:     t.At,
+	}
+	if t.Event == model.EventVerify {
+		points := awardedPoints(t)
+		payload.Points = &points
+
+		var ruleID *uuid.UUID
+		previous, reason, note := 0, model.PointReasonVerify, ""
+		if t.Award != nil {
+			ruleID, previous = t.Award.RuleID, t.Award.Previous
+			if t.Award.Overridden {
+				reason = model.PointReasonOverride
+				note = fmt.Sprintf("calculated %d: %s", t.Award.Calculated, t.Award.Justification)
+			}
+		}
+		if err := insertPointAudit(tx, t.AchievementID, previous, points, ruleID, reason, note, t.ActorID, t.At); err != nil {
+			return err
+		}
+	}
+
+	if _, err := enqueueOutbox(tx, t.AchievementID, model.OutboxAchievementStatus, payload); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func awardedPoints(t model.AchievementTransition) int {
+	if t.Award == nil {
+		return 0
+	}
+	return t.Award.Points
 }
 
-func (r *AchievementReferenceRepository) Verify(
-	mongoID string,
-	verifiedBy uuid.UUID,
-) error {
+// RecalculatePoints menyimpan poin baru untuk prestasi yang sudah verified, mencatat
+// audit, dan menjadwalkan poin ke MongoDB lewat outbox. Mengembalikan false jika
+// prestasi tidak (lagi) verified, poinnya di-override dosen wali, atau tidak berubah.
+func (r *AchievementReferenceRepository) RecalculatePoints(mongoID string, award model.PointAward, reason, actorID string) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var current int
+	err = tx.QueryRow(`
+		SELECT points
+		FROM achievement_references
+		WHERE mongo_achievement_id = $1 AND status = 'verified' AND deleted_at IS NULL
+		  AND points_overridden = FALSE
+		FOR UPDATE
+	`, mongoID).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if current == award.Points {
+		return false, nil
+	}
 
 	now := time.Now()
+	if _, err := tx.Exec(`
+		UPDATE achievement_references SET points = $1, updated_at = $2 WHERE mongo_achievement_id = $3
+	`, award.Points, now, mongoID); err != nil {
+		return false, err
+	}
 
-	query := `
-		UPDATE achievement_references
-		SET
-			status = $1,
-			verified_at = $2,
-			verified_by = $3,
-			updated_at = $2
-		WHERE mongo_achievement_id = $4
-	`
+	if err := insertPointAudit(tx, mongoID, current, award.Points, award.RuleID, reason, "", actorID, now); err != nil {
+		return false, err
+	}
 
-	_, err := r.DB.Exec(
-		query,
-		model.StatusVerified,
-		now,
-		verifiedBy,
-		mongoID,
-	)
+	if _, err := enqueueOutbox(tx, mongoID, model.OutboxAchievementPoints, model.AchievementPointsPayload{
+		Points: award.Points,
+		At:     now,
+	}); err != nil {
+		return false, err
+	}
 
-	return err
+	return true, tx.Commit()
 }
 
-func (r *AchievementReferenceRepository) Reject(
-	mongoID string,
-	rejectionNote string,
-) error {
+// recordRound membuka putaran baru saat submit dan menutupnya saat verify/reject.
+// Nomor putaran diambil dari submission_round yang baru saja dinaikkan oleh ApplyTransition.
+func recordRound(tx *sql.Tx, t model.AchievementTransition) error {
+	switch t.Event {
+	case model.EventSubmit:
+		snapshot := model.AchievementSnapshot{}
+		if t.Snapshot != nil {
+			snapshot = *t.Snapshot
+		}
+		raw, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			INSERT INTO achievement_rounds
+			(id, mongo_achievement_id, round, snapshot, submitted_by, submitted_at)
+			VALUES (
+				$1, $2,
+				(SELECT submission_round FROM achievement_references WHERE mongo_achievement_id = $2),
+				$3, $4, $5
+			)
+		`, uuid.New(), t.AchievementID, raw, t.ActorID, t.At)
+		return err
 
-	now := time.Now()
+	case model.EventVerify, model.EventReject:
+		var reasons, comments []byte
+		if len(t.Reasons) > 0 {
+			reasons, _ = json.Marshal(t.Reasons)
+		}
+		if len(t.FieldComments) > 0 {
+			comments, _ = json.Marshal(t.FieldComments)
+		}
 
-	query := `
-		UPDATE achievement_references
-		SET
-			status = $1,
-			verified_at = $2,
-			rejection_note = $3,
-			updated_at = $2
-		WHERE mongo_achievement_id = $4
-	`
+		// reference lama (diajukan sebelum putaran dicatat) tidak punya baris, jadi 0 baris bukan error
+		_, err := tx.Exec(`
+			UPDATE achievement_rounds
+			SET outcome = $1, reviewed_by = $2, reviewed_at = $3, rejection_note = NULLIF($4, ''),
+			    rejection_reasons = $5, field_comments = $6
+			WHERE mongo_achievement_id = $7
+			  AND round = (SELECT submission_round FROM achievement_references WHERE mongo_achievement_id = $7)
+		`, t.To, t.ActorID, t.At, t.Note, reasons, comments, t.AchievementID)
+		return err
+	}
 
-	_, err := r.DB.Exec(
-		query,
-		model.StatusRejected,
-		now,
-		rejectionNote,
-		mongoID,
-	)
+	return nil
+}
 
-	return err
+const roundColumns = `id, round, snapshot, submitted_by, submitted_at, outcome, reviewed_by, reviewed_at, rejection_note, rejection_reasons, field_comments`
+
+func scanRound(row interface{ Scan(...interface{}) error }, round *model.AchievementRound) error {
+	var raw, reasons, comments []byte
+	if err := row.Scan(
+		&round.ID,
+		&round.Round,
+		&raw,
+		&round.SubmittedBy,
+		&round.SubmittedAt,
+		&round.Outcome,
+		&round.ReviewedBy,
+		&round.ReviewedAt,
+		&round.RejectionNote,
+		&reasons,
+		&comments,
+	); err != nil {
+		return err
+	}
+	if len(reasons) > 0 {
+		if err := json.Unmarshal(reasons, &round.RejectionReasons); err != nil {
+			return err
+		}
+	}
+	if len(comments) > 0 {
+		if err := json.Unmarshal(comments, &round.FieldComments); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(raw, &round.Snapshot)
 }
 
+// GetRounds mengambil seluruh putaran pengajuan, dari yang pertama
+func (r *AchievementReferenceRepository) GetRounds(mongoID string) ([]model.AchievementRound, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+roundColumns+`
+		FROM achievement_rounds
+		WHERE mongo_achievement_id = $1
+		ORDER BY round ASC
+	`, mongoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rounds := []model.AchievementRound{}
+	for rows.Next() {
+		var round model.AchievementRound
+		if err := scanRound(rows, &round); err != nil {
+			return nil, err
+		}
+		rounds = append(rounds, round)
+	}
 
+	return rounds, rows.Err()
+}
 
+// GetLastRejectedRound mengambil putaran terakhir yang ditolak
+func (r *AchievementReferenceRepository) GetLastRejectedRound(mongoID string) (*model.AchievementRound, error) {
+	var round model.AchievementRound
+	err := scanRound(r.DB.QueryRow(`
+		SELECT `+roundColumns+`
+		FROM achievement_rounds
+		WHERE mongo_achievement_id = $1 AND outcome = 'rejected'
+		ORDER BY round DESC
+		LIMIT 1
+	`, mongoID), &round)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("rejected round not found")
+		}
+		return nil, err
+	}
 
+	return &round, nil
+}
 
+// GetAll mengambil seluruh reference (dipakai oleh rekonsiliasi)
+func (r *AchievementReferenceRepository) GetAll() ([]model.AchievementReference, error) {
+	rows, err := r.DB.Query(`
+		SELECT ` + referenceColumns + `
+		FROM achievement_references
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []model.AchievementReference
+	for rows.Next() {
+		var ref model.AchievementReference
+		if err := scanReference(rows, &ref); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
+
+// GetVerifiedIDsForRecalculation mengambil satu halaman ID prestasi verified (poin tidak
+// di-override) yang mungkin bertipe code, urut ID setelah afterID. Tipe dibaca dari snapshot
+// putaran yang diverifikasi; reference lama tanpa putaran ikut diambil dan tipenya
+// dipastikan pemanggil dari dokumen MongoDB.
+func (r *AchievementReferenceRepository) GetVerifiedIDsForRecalculation(code, afterID string, limit int) ([]string, error) {
+	rows, err := r.DB.Query(`
+		SELECT r.mongo_achievement_id
+		FROM achievement_references r
+		LEFT JOIN achievement_rounds ar
+		  ON ar.mongo_achievement_id = r.mongo_achievement_id AND ar.round = r.submission_round
+		WHERE r.status = 'verified' AND r.deleted_at IS NULL AND r.points_overridden = FALSE
+		  AND (ar.id IS NULL OR ar.snapshot->>'achievementType' = $1)
+		  AND r.mongo_achievement_id > $2
+		ORDER BY r.mongo_achievement_id
+		LIMIT $3
+	`, code, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// DeleteDraftByMongoID menghapus reference draft yang dokumen Mongo-nya sudah tidak ada.
+// Reference yang pernah diajukan (punya riwayat / poin) tidak pernah dihapus.
+func (r *AchievementReferenceRepository) DeleteDraftByMongoID(mongoID string) error {
+	res, err := r.DB.Exec(`
+		DELETE FROM achievement_references
+		WHERE mongo_achievement_id = $1
+		  AND status = 'draft'
+		  AND submission_round = 0
+		  AND deleted_at IS NULL
+	`, mongoID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("reference is not an unsubmitted draft")
+	}
+	return nil
+}
+
+// EnqueueStatusSync menjadwalkan ulang status & poin reference saat ini ke MongoDB lewat
+// outbox. Baris dibaca ulang dengan FOR UPDATE di transaksi yang sama, jadi payload tidak
+// pernah berasal dari data basi: transisi yang commit lebih dulu sudah terlihat, dan yang
+// datang sesudahnya menunggu lalu mendapat urutan outbox lebih baru.
+func (r *AchievementReferenceRepository) EnqueueStatusSync(mongoID string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var ref model.AchievementReference
+	err = scanReference(tx.QueryRow(`
+		SELECT `+referenceColumns+`
+		FROM achievement_references
+		WHERE mongo_achievement_id = $1
+		FOR UPDATE
+	`, mongoID), &ref)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("achievement reference not found")
+		}
+		return err
+	}
+
+	if _, err := enqueueOutbox(tx, ref.MongoAchievementID, model.OutboxAchievementStatus, model.AchievementStatusPayload{
+		Status: string(ref.CurrentStatus()),
+		At:     ref.UpdatedAt,
+		Points: &ref.Points,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"UAS/app/model"
+
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -100,7 +103,17 @@ func (r *AchievementRepository) GetByID(id string) (*model.Achievement, error) {
 	return &achievement, nil
 }
 
-// Update achievement by ID
+// ErrNotDraft: update bersyarat ditolak karena prestasi sudah tidak draft
+var ErrNotDraft = errors.New("achievement is not a draft")
+
+// notDraft membedakan "sudah tidak draft" dari "tidak ditemukan" setelah update bersyarat gagal
+func (r *AchievementRepository) notDraft(objID primitive.ObjectID) bool {
+	n, err := r.Collection.CountDocuments(r.Ctx, bson.M{"_id": objID, "status": bson.M{"$ne": string(model.StatusDraft)}})
+	return err == nil && n > 0
+}
+
+// Update mengubah isi prestasi by ID. Hanya draft yang diubah: edit yang balapan dengan
+// submit tidak boleh masuk setelah prestasi diajukan.
 func (r *AchievementRepository) Update(id string, updateData bson.M) (*model.Achievement, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -111,9 +124,12 @@ func (r *AchievementRepository) Update(id string, updateData bson.M) (*model.Ach
 
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	var updated model.Achievement
-	err = r.Collection.FindOneAndUpdate(r.Ctx, bson.M{"_id": oid}, bson.M{"$set": updateData}, opts).Decode(&updated)
+	err = r.Collection.FindOneAndUpdate(r.Ctx, bson.M{"_id": oid, "status": string(model.StatusDraft)}, bson.M{"$set": updateData}, opts).Decode(&updated)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
+			if r.notDraft(oid) {
+				return nil, ErrNotDraft
+			}
 			return nil, nil
 		}
 		return nil, fmt.Errorf("failed to update achievement: %v", err)
@@ -130,7 +146,7 @@ func (r *AchievementRepository) Delete(id string) error {
 	}
 
 	_, err = r.Collection.UpdateOne(r.Ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{
-		"status":    "deleted",
+		"status":    string(model.StatusDeleted),
 		"updatedAt": time.Now(),
 	}})
 	if err != nil {
@@ -139,6 +155,127 @@ func (r *AchievementRepository) Delete(id string) error {
 	return nil
 }
 
+// ApplyStatus menerapkan event outbox status secara idempotent. Field outboxSeq
+// mencegah event lama (retry yang tertunda) menimpa status yang lebih baru.
+// Mengembalikan false jika dokumen tidak ada atau sudah menerima event yang lebih baru.
+func (r *AchievementRepository) ApplyStatus(id string, status string, at time.Time, seq int64, points *int) (bool, error) {
+	fields := bson.M{"status": status}
+	if points != nil {
+		fields["points"] = *points
+	}
+	return r.applyOutbox(id, fields, at, seq)
+}
+
+// ApplyPoints menerapkan event outbox poin (hasil hitung ulang) secara idempotent
+func (r *AchievementRepository) ApplyPoints(id string, points int, at time.Time, seq int64) (bool, error) {
+	return r.applyOutbox(id, bson.M{"points": points}, at, seq)
+}
+
+func (r *AchievementRepository) applyOutbox(id string, fields bson.M, at time.Time, seq int64) (bool, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid ID format: %v", err)
+	}
+
+	filter := bson.M{
+		"_id":       oid,
+		"outboxSeq": bson.M{"$not": bson.M{"$gte": seq}},
+	}
+	fields["updatedAt"] = at
+	fields["outboxSeq"] = seq
+	update := bson.M{"$set": fields}
+
+	res, err := r.Collection.UpdateOne(r.Ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to apply outbox event: %v", err)
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// GetSyncStates mengambil id, studentId, status, points, createdAt seluruh dokumen
+// (tanpa details / attachments) untuk rekonsiliasi dengan PostgreSQL
+func (r *AchievementRepository) GetSyncStates(ctx context.Context) ([]model.AchievementSyncState, error) {
+	opts := options.Find().SetProjection(bson.M{
+		"_id":       1,
+		"studentId": 1,
+		"status":    1,
+		"points":    1,
+		"createdAt": 1,
+	})
+
+	cur, err := r.Collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var states []model.AchievementSyncState
+	for cur.Next(ctx) {
+		var doc struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			StudentID string             `bson:"studentId"`
+			Status    string             `bson:"status"`
+			Points    int                `bson:"points"`
+			CreatedAt time.Time          `bson:"createdAt"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		states = append(states, model.AchievementSyncState{
+			ID:        doc.ID.Hex(),
+			StudentID: doc.StudentID,
+			Status:    doc.Status,
+			Points:    doc.Points,
+			CreatedAt: doc.CreatedAt,
+		})
+	}
+
+	return states, cur.Err()
+}
+
+// HardDelete menghapus dokumen secara permanen (kompensasi saat reference
+// di PostgreSQL gagal dibuat). Idempotent: dokumen yang sudah tidak ada bukan error.
+func (r *AchievementRepository) HardDelete(id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid ID format: %v", err)
+	}
+
+	if _, err := r.Collection.DeleteOne(r.Ctx, bson.M{"_id": oid}); err != nil {
+		return fmt.Errorf("failed to delete achievement: %v", err)
+	}
+	return nil
+}
+
+// GetByIDs mengambil beberapa prestasi sekaligus; ID yang tidak valid / tidak ada dilewati
+func (r *AchievementRepository) GetByIDs(ids []string) ([]model.Achievement, error) {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	if len(oids) == 0 {
+		return nil, nil
+	}
+
+	cur, err := r.Collection.Find(r.Ctx, bson.M{"_id": bson.M{"$in": oids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	var achievements []model.Achievement
+	for cur.Next(r.Ctx) {
+		var a model.Achievement
+		if err := cur.Decode(&a); err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, a)
+	}
+	return achievements, cur.Err()
+}
+
 // UpdateStatus mengubah status achievement di MongoDB
 func (r *AchievementRepository) UpdateStatus(id string, status string) (*model.Achievement, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
@@ -186,10 +323,13 @@ func (r *AchievementRepository) GetVerifiedOnly() ([]model.Achievement, error) {
 	return achievements, nil
 }
 
-// repository/achievement_repository.go
+// AddAttachment menambahkan lampiran. Filter ikut memastikan prestasi masih draft, batas
+// jumlah lampiran dan checksum yang sama belum ada, supaya upload yang balapan (dengan
+// upload lain atau dengan submit) tidak lolos pengecekan.
 func (r *AchievementRepository) AddAttachment(
 	achievementID string,
 	attachment model.Attachment,
+	maxCount int,
 ) error {
 
 	objID, err := primitive.ObjectIDFromHex(achievementID)
@@ -197,17 +337,182 @@ func (r *AchievementRepository) AddAttachment(
 		return err
 	}
 
-	_, err = r.Collection.UpdateOne(
+	filter := bson.M{"_id": objID, "status": string(model.StatusDraft)}
+	if maxCount > 0 {
+		filter[fmt.Sprintf("attachments.%d", maxCount-1)] = bson.M{"$exists": false}
+	}
+	if attachment.Checksum != "" {
+		filter["attachments.sha256"] = bson.M{"$ne": attachment.Checksum}
+	}
+
+	res, err := r.Collection.UpdateOne(
 		r.Ctx,
-		bson.M{"_id": objID},
+		filter,
 		bson.M{
 			"$push": bson.M{
 				"attachments": attachment,
 			},
 		},
 	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		if r.notDraft(objID) {
+			return ErrNotDraft
+		}
+		return errors.New("attachment conflict")
+	}
+
+	return nil
+}
+
+// ReplaceAttachment mengganti lampiran dengan ID yang sama dan mengembalikan lampiran lama
+func (r *AchievementRepository) ReplaceAttachment(achievementID string, attachment model.Attachment) (*model.Attachment, error) {
+	objID, err := primitive.ObjectIDFromHex(achievementID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	var before model.Achievement
+	err = r.Collection.FindOneAndUpdate(
+		r.Ctx,
+		bson.M{"_id": objID, "status": string(model.StatusDraft), "attachments.id": attachment.ID},
+		bson.M{"$set": bson.M{"attachments.$": attachment, "updatedAt": time.Now()}},
+		opts,
+	).Decode(&before)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			if r.notDraft(objID) {
+				return nil, ErrNotDraft
+			}
+			return nil, errors.New("attachment not found")
+		}
+		return nil, err
+	}
+
+	old, _ := before.FindAttachment(attachment.ID)
+	return old, nil
+}
+
+// RemoveAttachment menghapus lampiran dan mengembalikan data lampiran yang dihapus
+func (r *AchievementRepository) RemoveAttachment(achievementID, attachmentID string) (*model.Attachment, error) {
+	objID, err := primitive.ObjectIDFromHex(achievementID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	var before model.Achievement
+	err = r.Collection.FindOneAndUpdate(
+		r.Ctx,
+		bson.M{"_id": objID, "status": string(model.StatusDraft), "attachments.id": attachmentID},
+		bson.M{
+			"$pull": bson.M{"attachments": bson.M{"id": attachmentID}},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+		opts,
+	).Decode(&before)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			if r.notDraft(objID) {
+				return nil, ErrNotDraft
+			}
+			return nil, errors.New("attachment not found")
+		}
+		return nil, err
+	}
+
+	removed, _ := before.FindAttachment(attachmentID)
+	return removed, nil
+}
+
+// SetAttachmentPreview menyimpan hasil pratinjau. Filter ikut mencocokkan storageKey:
+// kalau lampiran sudah diganti / dihapus selama diproses, hasilnya false dan tidak disimpan.
+func (r *AchievementRepository) SetAttachmentPreview(
+	achievementID string,
+	attachmentID string,
+	storageKey string,
+	preview model.AttachmentPreview,
+) (bool, error) {
+
+	objID, err := primitive.ObjectIDFromHex(achievementID)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := r.Collection.UpdateOne(
+		r.Ctx,
+		bson.M{
+			"_id": objID,
+			"attachments": bson.M{"$elemMatch": bson.M{
+				"id":         attachmentID,
+				"storageKey": storageKey,
+			}},
+		},
+		bson.M{"$set": bson.M{"attachments.$.preview": preview}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return res.MatchedCount > 0, nil
+}
+
+// GetPendingPreviews mengambil prestasi yang masih punya lampiran dengan pratinjau pending
+func (r *AchievementRepository) GetPendingPreviews(limit int) ([]model.Achievement, error) {
+	opts := options.Find().SetLimit(int64(limit))
+	cur, err := r.Collection.Find(r.Ctx, bson.M{
+		"attachments.preview.status": model.PreviewPending,
+		"status":                     bson.M{"$ne": string(model.StatusDeleted)},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	var achievements []model.Achievement
+	if err := cur.All(r.Ctx, &achievements); err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+// BackfillAttachmentIDs memberi ID pada lampiran lama yang belum punya ID
+// (dijalankan saat startup, aman diulang)
+func (r *AchievementRepository) BackfillAttachmentIDs() (int, error) {
+	cur, err := r.Collection.Find(r.Ctx, bson.M{
+		"attachments": bson.M{"$elemMatch": bson.M{"id": bson.M{"$exists": false}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(r.Ctx)
+
+	updated := 0
+	for cur.Next(r.Ctx) {
+		var a model.Achievement
+		if err := cur.Decode(&a); err != nil {
+			return updated, err
+		}
+		for i, att := range a.Attachments {
+			if att.ID != "" {
+				continue
+			}
+			field := fmt.Sprintf("attachments.%d.id", i)
+			res, err := r.Collection.UpdateOne(r.Ctx,
+				bson.M{"_id": a.ID, field: bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{field: uuid.NewString()}},
+			)
+			if err != nil {
+				return updated, err
+			}
+			updated += int(res.ModifiedCount)
+		}
+	}
 
-	return err
+	return updated, cur.Err()
 }
 
 func (r *AchievementRepository) GetStatistics(
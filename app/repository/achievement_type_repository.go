@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"UAS/app/model"
+
+	"github.com/google/uuid"
+)
+
+// AchievementTypeRepository mengelola katalog jenis prestasi dan aturan poinnya
+type AchievementTypeRepository struct {
+	DB *sql.DB
+}
+
+func NewAchievementTypeRepository(db *sql.DB) *AchievementTypeRepository {
+	return &AchievementTypeRepository{DB: db}
+}
+
+const pointRuleColumns = `id, type_code, level, rank_from, rank_to, label, points, created_at, updated_at`
+
+func scanPointRule(row interface{ Scan(...interface{}) error }, rule *model.PointRule) error {
+	return row.Scan(
+		&rule.ID,
+		&rule.TypeCode,
+		&rule.Level,
+		&rule.RankFrom,
+		&rule.RankTo,
+		&rule.Label,
+		&rule.Points,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+}
+
+// GetTypes mengambil seluruh katalog beserta aturan poinnya
+func (r *AchievementTypeRepository) GetTypes() ([]model.AchievementType, error) {
+	rows, err := r.DB.Query(`
+		SELECT code, name, COALESCE(description, ''), is_active, created_at, updated_at
+		FROM achievement_types
+		ORDER BY code ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := []model.AchievementType{}
+	for rows.Next() {
+		var t model.AchievementType
+		if err := rows.Scan(&t.Code, &t.Name, &t.Description, &t.IsActive, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rules, err := r.GetRules("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range types {
+		types[i].Rules = []model.PointRule{}
+		for _, rule := range rules {
+			if rule.TypeCode == types[i].Code {
+				types[i].Rules = append(types[i].Rules, rule)
+			}
+		}
+	}
+
+	return types, nil
+}
+
+// GetType mengambil satu jenis prestasi beserta aturannya
+func (r *AchievementTypeRepository) GetType(code string) (*model.AchievementType, error) {
+	var t model.AchievementType
+	err := r.DB.QueryRow(`
+		SELECT code, name, COALESCE(description, ''), is_active, created_at, updated_at
+		FROM achievement_types
+		WHERE code = $1
+	`, code).Scan(&t.Code, &t.Name, &t.Description, &t.IsActive, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("achievement type not found")
+		}
+		return nil, err
+	}
+
+	t.Rules, err = r.GetRules(code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (r *AchievementTypeRepository) CreateType(t *model.AchievementType) (*model.AchievementType, error) {
+	err := r.DB.QueryRow(`
+		INSERT INTO achievement_types (code, name, description, is_active)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		RETURNING created_at, updated_at
+	`, t.Code, t.Name, t.Description, t.IsActive).Scan(&t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Rules = []model.PointRule{}
+	return t, nil
+}
+
+func (r *AchievementTypeRepository) UpdateType(t *model.AchievementType) (*model.AchievementType, error) {
+	err := r.DB.QueryRow(`
+		UPDATE achievement_types
+		SET name = $1, description = NULLIF($2, ''), is_active = $3, updated_at = NOW()
+		WHERE code = $4
+		RETURNING created_at, updated_at
+	`, t.Name, t.Description, t.IsActive, t.Code).Scan(&t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("achievement type not found")
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// GetRules mengambil aturan poin untuk satu tipe (code kosong = semua tipe)
+func (r *AchievementTypeRepository) GetRules(code string) ([]model.PointRule, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+pointRuleColumns+`
+		FROM achievement_point_rules
+		WHERE $1 = '' OR type_code = $1
+		ORDER BY type_code ASC, level ASC NULLS LAST, rank_from ASC NULLS LAST
+	`, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []model.PointRule{}
+	for rows.Next() {
+		var rule model.PointRule
+		if err := scanPointRule(rows, &rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func (r *AchievementTypeRepository) CreateRule(rule *model.PointRule) (*model.PointRule, error) {
+	rule.ID = uuid.New()
+	err := r.DB.QueryRow(`
+		INSERT INTO achievement_point_rules (id, type_code, level, rank_from, rank_to, label, points)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at, updated_at
+	`, rule.ID, rule.TypeCode, rule.Level, rule.RankFrom, rule.RankTo, rule.Label, rule.Points).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (r *AchievementTypeRepository) UpdateRule(rule *model.PointRule) (*model.PointRule, error) {
+	err := r.DB.QueryRow(`
+		UPDATE achievement_point_rules
+		SET level = $1, rank_from = $2, rank_to = $3, label = $4, points = $5, updated_at = NOW()
+		WHERE id = $6 AND type_code = $7
+		RETURNING created_at, updated_at
+	`, rule.Level, rule.RankFrom, rule.RankTo, rule.Label, rule.Points, rule.ID, rule.TypeCode).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("point rule not found")
+		}
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (r *AchievementTypeRepository) DeleteRule(code string, id uuid.UUID) error {
+	res, err := r.DB.Exec(`DELETE FROM achievement_point_rules WHERE id = $1 AND type_code = $2`, id, code)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("point rule not found")
+	}
+
+	return nil
+}
+
+// insertPointAudit mencatat perubahan poin prestasi (dipakai saat verify dan hitung ulang)
+func insertPointAudit(tx *sql.Tx, mongoID string, oldPoints, newPoints int, ruleID *uuid.UUID, reason, note, actorID string, at time.Time) error {
+	_, err := tx.Exec(`
+		INSERT INTO achievement_point_audit
+		(id, mongo_achievement_id, old_points, new_points, rule_id, reason, note, actor_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, '')::uuid, $9)
+	`, uuid.New(), mongoID, oldPoints, newPoints, ruleID, reason, note, actorID, at)
+	return err
+}
+
+// GetPointAudit mengambil jejak perubahan poin sebuah prestasi, dari yang paling lama
+func (r *AchievementTypeRepository) GetPointAudit(mongoID string) ([]model.AchievementPointAudit, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, mongo_achievement_id, old_points, new_points, rule_id, reason, note, actor_id, created_at
+		FROM achievement_point_audit
+		WHERE mongo_achievement_id = $1
+		ORDER BY created_at ASC
+	`, mongoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	audit := []model.AchievementPointAudit{}
+	for rows.Next() {
+		var a model.AchievementPointAudit
+		if err := rows.Scan(&a.ID, &a.AchievementID, &a.OldPoints, &a.NewPoints, &a.RuleID, &a.Reason, &a.Note, &a.ActorID, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		audit = append(audit, a)
+	}
+
+	return audit, rows.Err()
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"UAS/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// versionInsertRetries: dua edit bersamaan bisa mendapat nomor versi yang sama,
+// index unik menolak salah satunya lalu nomor dihitung ulang
+const versionInsertRetries = 3
+
+type AchievementVersionRepository struct {
+	Collection *mongo.Collection
+	Ctx        context.Context
+}
+
+func NewAchievementVersionRepository(coll *mongo.Collection) *AchievementVersionRepository {
+	return &AchievementVersionRepository{
+		Collection: coll,
+		Ctx:        context.Background(),
+	}
+}
+
+// EnsureIndexes membuat index unik (achievementId, version)
+func (r *AchievementVersionRepository) EnsureIndexes() error {
+	_, err := r.Collection.Indexes().CreateOne(r.Ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "achievementId", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *AchievementVersionRepository) latestVersion(achievementID string) (int, error) {
+	opts := options.FindOne().
+		SetSort(bson.M{"version": -1}).
+		SetProjection(bson.M{"version": 1})
+
+	var doc struct {
+		Version int `bson:"version"`
+	}
+	err := r.Collection.FindOne(r.Ctx, bson.M{"achievementId": achievementID}, opts).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return doc.Version, nil
+}
+
+// Create menyimpan versi baru dengan nomor berikutnya. Versi tidak pernah diubah / dihapus.
+func (r *AchievementVersionRepository) Create(v *model.AchievementVersion) (*model.AchievementVersion, error) {
+	for attempt := 0; ; attempt++ {
+		latest, err := r.latestVersion(v.AchievementID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read latest version: %v", err)
+		}
+		v.Version = latest + 1
+
+		_, err = r.Collection.InsertOne(r.Ctx, v)
+		if err == nil {
+			return v, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) || attempt+1 >= versionInsertRetries {
+			return nil, fmt.Errorf("failed to create version: %v", err)
+		}
+	}
+}
+
+// Count menghitung jumlah versi sebuah prestasi
+func (r *AchievementVersionRepository) Count(achievementID string) (int64, error) {
+	return r.Collection.CountDocuments(r.Ctx, bson.M{"achievementId": achievementID})
+}
+
+// GetByAchievementID mengambil seluruh versi, dari yang paling lama
+func (r *AchievementVersionRepository) GetByAchievementID(achievementID string) ([]model.AchievementVersion, error) {
+	cur, err := r.Collection.Find(r.Ctx, bson.M{"achievementId": achievementID}, options.Find().SetSort(bson.M{"version": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	versions := []model.AchievementVersion{}
+	for cur.Next(r.Ctx) {
+		var v model.AchievementVersion
+		if err := cur.Decode(&v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, cur.Err()
+}
+
+// GetVersion mengambil satu versi
+func (r *AchievementVersionRepository) GetVersion(achievementID string, version int) (*model.AchievementVersion, error) {
+	var v model.AchievementVersion
+	err := r.Collection.FindOne(r.Ctx, bson.M{"achievementId": achievementID, "version": version}).Decode(&v)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("version not found")
+		}
+		return nil, err
+	}
+	return &v, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"UAS/app/model"
 )
 
@@ -71,4 +72,30 @@ func (r *LecturerRepository) GetAdvisees(lecturerID string) ([]model.AdviseeResp
 	}
 
 	return advisees, nil
-}
\ No newline at end of file
+}
+
+// GetByUserID mengambil profil dosen berdasarkan user_id
+func (r *LecturerRepository) GetByUserID(userID string) (*model.LecturerResponse, error) {
+	var l model.LecturerResponse
+	err := r.DB.QueryRow(`
+		SELECT l.id, l.user_id, u.full_name, l.lecturer_id, l.department, l.created_at
+		FROM lecturers l
+		JOIN users u ON u.id = l.user_id
+		WHERE l.user_id = $1
+	`, userID).Scan(
+		&l.ID,
+		&l.UserID,
+		&l.FullName,
+		&l.LecturerID,
+		&l.Department,
+		&l.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("lecturer not found")
+		}
+		return nil, err
+	}
+
+	return &l, nil
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"UAS/app/model"
+
+	"github.com/google/uuid"
+)
+
+type LoginAttemptRepository struct {
+	DB *sql.DB
+
+	MaxAttempts     int           // jumlah gagal sebelum akun dikunci
+	IPMaxAttempts   int           // jumlah gagal sebelum IP dikunci (banyak user bisa berbagi satu IP / NAT kampus)
+	Window          time.Duration // hitungan gagal di-reset setelah window ini
+	LockoutDuration time.Duration // lama penguncian
+	BackoffBase     time.Duration // jeda awal, dikali 2 setiap kali gagal
+}
+
+func NewLoginAttemptRepository(db *sql.DB, maxAttempts, ipMaxAttempts int, window, lockout, backoffBase time.Duration) *LoginAttemptRepository {
+	return &LoginAttemptRepository{
+		DB:              db,
+		MaxAttempts:     maxAttempts,
+		IPMaxAttempts:   ipMaxAttempts,
+		Window:          window,
+		LockoutDuration: lockout,
+		BackoffBase:     backoffBase,
+	}
+}
+
+// Get mengambil data percobaan login, nil jika belum pernah gagal
+func (r *LoginAttemptRepository) Get(keyType, keyValue string) (*model.LoginAttempt, error) {
+	var a model.LoginAttempt
+	err := r.DB.QueryRow(`
+		SELECT key_type, key_value, failed_count, first_failed_at, last_failed_at, next_attempt_at, locked_until
+		FROM login_attempts
+		WHERE key_type = $1 AND key_value = $2
+	`, keyType, keyValue).Scan(
+		&a.KeyType,
+		&a.KeyValue,
+		&a.FailedCount,
+		&a.FirstFailedAt,
+		&a.LastFailedAt,
+		&a.NextAttemptAt,
+		&a.LockedUntil,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// RetryAfter mengembalikan sisa waktu tunggu sebelum boleh mencoba login lagi
+func (r *LoginAttemptRepository) RetryAfter(keyType, keyValue string) (time.Duration, error) {
+	a, err := r.Get(keyType, keyValue)
+	if err != nil || a == nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	var wait time.Duration
+	if a.LockedUntil != nil && a.LockedUntil.After(now) {
+		wait = a.LockedUntil.Sub(now)
+	}
+	if a.NextAttemptAt != nil && a.NextAttemptAt.Sub(now) > wait {
+		wait = a.NextAttemptAt.Sub(now)
+	}
+	return wait, nil
+}
+
+// RecordFailure menambah hitungan gagal, menghitung back-off eksponensial dan
+// mengunci key (beserta catatan audit) jika batas percobaan tercapai
+func (r *LoginAttemptRepository) RecordFailure(keyType, keyValue string) (*model.LoginAttempt, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	a := model.LoginAttempt{KeyType: keyType, KeyValue: keyValue}
+
+	err = tx.QueryRow(`
+		SELECT failed_count, first_failed_at
+		FROM login_attempts
+		WHERE key_type = $1 AND key_value = $2
+		FOR UPDATE
+	`, keyType, keyValue).Scan(&a.FailedCount, &a.FirstFailedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// Di luar window -> mulai hitungan baru
+	if err == sql.ErrNoRows || now.Sub(a.FirstFailedAt) > r.Window {
+		a.FailedCount = 0
+		a.FirstFailedAt = now
+	}
+	a.FailedCount++
+	a.LastFailedAt = now
+
+	// back-off per percobaan hanya untuk akun; IP cukup dikunci saat batasnya tercapai,
+	// supaya salah ketik satu user tidak memperlambat semua user di balik IP yang sama
+	if a.FailedCount > 1 && keyType != model.LoginKeyIP {
+		next := now.Add(r.backoff(a.FailedCount))
+		a.NextAttemptAt = &next
+	}
+
+	if a.FailedCount >= r.maxAttempts(keyType) {
+		lockedUntil := now.Add(r.LockoutDuration)
+		a.LockedUntil = &lockedUntil
+
+		_, err = tx.Exec(`
+			INSERT INTO login_lockouts (id, key_type, key_value, failed_count, locked_until)
+			VALUES ($1,$2,$3,$4,$5)
+		`, uuid.New(), keyType, keyValue, a.FailedCount, lockedUntil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO login_attempts (key_type, key_value, failed_count, first_failed_at, last_failed_at, next_attempt_at, locked_until)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		ON CONFLICT (key_type, key_value) DO UPDATE
+		SET failed_count = EXCLUDED.failed_count,
+		    first_failed_at = EXCLUDED.first_failed_at,
+		    last_failed_at = EXCLUDED.last_failed_at,
+		    next_attempt_at = EXCLUDED.next_attempt_at,
+		    locked_until = EXCLUDED.locked_until
+	`, a.KeyType, a.KeyValue, a.FailedCount, a.FirstFailedAt, a.LastFailedAt, a.NextAttemptAt, a.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// maxAttempts mengembalikan batas gagal untuk jenis key
+func (r *LoginAttemptRepository) maxAttempts(keyType string) int {
+	if keyType == model.LoginKeyIP && r.IPMaxAttempts > 0 {
+		return r.IPMaxAttempts
+	}
+	return r.MaxAttempts
+}
+
+// backoff = BackoffBase * 2^(n-2), maksimal sebesar LockoutDuration
+func (r *LoginAttemptRepository) backoff(failedCount int) time.Duration {
+	d := r.BackoffBase
+	for i := 2; i < failedCount; i++ {
+		d *= 2
+		if d >= r.LockoutDuration {
+			return r.LockoutDuration
+		}
+	}
+	return d
+}
+
+// Reset menghapus hitungan gagal (dipanggil setelah login berhasil)
+func (r *LoginAttemptRepository) Reset(keyType, keyValue string) error {
+	_, err := r.DB.Exec(`
+		DELETE FROM login_attempts
+		WHERE key_type = $1 AND key_value = $2
+	`, keyType, keyValue)
+	return err
+}
+
+// GetActiveLockouts mengambil semua akun / IP yang sedang terkunci
+func (r *LoginAttemptRepository) GetActiveLockouts() ([]model.LoginAttempt, error) {
+	rows, err := r.DB.Query(`
+		SELECT key_type, key_value, failed_count, first_failed_at, last_failed_at, next_attempt_at, locked_until
+		FROM login_attempts
+		WHERE locked_until > NOW()
+		ORDER BY locked_until DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.LoginAttempt
+	for rows.Next() {
+		var a model.LoginAttempt
+		if err := rows.Scan(
+			&a.KeyType,
+			&a.KeyValue,
+			&a.FailedCount,
+			&a.FirstFailedAt,
+			&a.LastFailedAt,
+			&a.NextAttemptAt,
+			&a.LockedUntil,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+
+	return result, nil
+}
+
+// GetLockoutHistory mengambil catatan audit penguncian terbaru
+func (r *LoginAttemptRepository) GetLockoutHistory(limit int) ([]model.LoginLockout, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, key_type, key_value, failed_count, locked_until, cleared_by, cleared_at, created_at
+		FROM login_lockouts
+		ORDER BY created_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.LoginLockout
+	for rows.Next() {
+		var l model.LoginLockout
+		if err := rows.Scan(
+			&l.ID,
+			&l.KeyType,
+			&l.KeyValue,
+			&l.FailedCount,
+			&l.LockedUntil,
+			&l.ClearedBy,
+			&l.ClearedAt,
+			&l.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, l)
+	}
+
+	return result, nil
+}
+
+// ClearLockout membuka kunci secara manual oleh admin dan mencatatnya di audit
+func (r *LoginAttemptRepository) ClearLockout(keyType, keyValue string, clearedBy uuid.UUID) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		DELETE FROM login_attempts
+		WHERE key_type = $1 AND key_value = $2
+	`, keyType, keyValue); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE login_lockouts
+		SET cleared_by = $1,
+		    cleared_at = NOW()
+		WHERE key_type = $2 AND key_value = $3
+		  AND cleared_at IS NULL AND locked_until > NOW()
+	`, clearedBy, keyType, keyValue); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"UAS/app/model"
+)
+
+// OutboxRepository menyimpan event yang harus diterapkan ke MongoDB. Event ditulis
+// dalam transaksi yang sama dengan perubahan di PostgreSQL, lalu diterapkan oleh
+// dispatcher (langsung setelah commit, dan diulang oleh worker jika gagal).
+type OutboxRepository struct {
+	DB *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{DB: db}
+}
+
+// rowQuerier dipenuhi oleh *sql.DB maupun *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func enqueueOutbox(q rowQuerier, aggregateID, eventType string, payload interface{}) (int64, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = q.QueryRow(`
+		INSERT INTO outbox_events (aggregate_id, event_type, payload)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, aggregateID, eventType, data).Scan(&id)
+	return id, err
+}
+
+// Enqueue menulis event di luar transaksi (mis. kompensasi yang gagal diterapkan langsung)
+func (r *OutboxRepository) Enqueue(aggregateID, eventType string, payload interface{}) (int64, error) {
+	return enqueueOutbox(r.DB, aggregateID, eventType, payload)
+}
+
+const outboxColumns = `
+	id, aggregate_id, event_type, payload, status, attempts, last_error,
+	next_attempt_at, processed_at, created_at
+`
+
+func scanOutboxEvents(rows *sql.Rows) ([]model.OutboxEvent, error) {
+	defer rows.Close()
+
+	var events []model.OutboxEvent
+	for rows.Next() {
+		var e model.OutboxEvent
+		if err := rows.Scan(
+			&e.ID,
+			&e.AggregateID,
+			&e.EventType,
+			&e.Payload,
+			&e.Status,
+			&e.Attempts,
+			&e.LastError,
+			&e.NextAttemptAt,
+			&e.ProcessedAt,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// GetDue mengambil event pending yang sudah waktunya diproses, urut sesuai penulisan
+func (r *OutboxRepository) GetDue(limit int) ([]model.OutboxEvent, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+outboxColumns+`
+		FROM outbox_events
+		WHERE status = $1 AND next_attempt_at <= NOW()
+		ORDER BY id ASC
+		LIMIT $2
+	`, model.OutboxPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanOutboxEvents(rows)
+}
+
+// GetPendingByAggregate mengambil event pending milik satu achievement
+// (dipakai untuk menerapkan event langsung setelah commit)
+func (r *OutboxRepository) GetPendingByAggregate(aggregateID string) ([]model.OutboxEvent, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+outboxColumns+`
+		FROM outbox_events
+		WHERE status = $1 AND aggregate_id = $2
+		ORDER BY id ASC
+	`, model.OutboxPending, aggregateID)
+	if err != nil {
+		return nil, err
+	}
+	return scanOutboxEvents(rows)
+}
+
+func (r *OutboxRepository) MarkProcessed(id int64) error {
+	_, err := r.DB.Exec(`
+		UPDATE outbox_events
+		SET status = $1, processed_at = NOW(), last_error = NULL
+		WHERE id = $2 AND status = $3
+	`, model.OutboxProcessed, id, model.OutboxPending)
+	return err
+}
+
+// MarkFailed mencatat kegagalan dan menjadwalkan percobaan berikutnya.
+// Setelah maxAttempts, event ditandai dead.
+func (r *OutboxRepository) MarkFailed(id int64, cause error, retryIn time.Duration, maxAttempts int) error {
+	_, err := r.DB.Exec(`
+		UPDATE outbox_events
+		SET attempts = attempts + 1,
+		    last_error = $1,
+		    next_attempt_at = $2,
+		    status = CASE WHEN attempts + 1 >= $3 THEN $4 ELSE status END
+		WHERE id = $5 AND status = $6
+	`, cause.Error(), time.Now().Add(retryIn), maxAttempts, model.OutboxDead, id, model.OutboxPending)
+	return err
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PasswordResetRepository struct {
+	DB *sql.DB
+}
+
+func NewPasswordResetRepository(db *sql.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{DB: db}
+}
+
+// Create menyimpan hash token reset baru. Token lama user yang belum dipakai
+// langsung tidak berlaku.
+func (r *PasswordResetRepository) Create(userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE password_reset_tokens
+		SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at)
+		VALUES ($1,$2,$3,$4)
+	`, uuid.New(), userID, tokenHash, expiresAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetUserID mengambil user_id dari token yang masih berlaku tanpa memakainya
+func (r *PasswordResetRepository) GetUserID(tokenHash string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.DB.QueryRow(`
+		SELECT user_id
+		FROM password_reset_tokens
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+	`, tokenHash).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, errors.New("invalid or expired reset token")
+		}
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
+
+const consumeResetTokenQuery = `
+	UPDATE password_reset_tokens
+	SET used_at = NOW()
+	WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+	RETURNING user_id
+`
+
+// Consume menandai token sebagai terpakai (sekali pakai) dan mengembalikan user_id-nya
+func (r *PasswordResetRepository) Consume(tokenHash string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.DB.QueryRow(consumeResetTokenQuery, tokenHash).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, errors.New("invalid or expired reset token")
+		}
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
+
+// ResetPassword memakai token dan mengganti password dalam satu transaksi:
+// jika update password gagal, token tidak ikut hangus
+func (r *PasswordResetRepository) ResetPassword(tokenHash string, hashedPassword string) (uuid.UUID, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+
+	var userID uuid.UUID
+	if err := tx.QueryRow(consumeResetTokenQuery, tokenHash).Scan(&userID); err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, errors.New("invalid or expired reset token")
+		}
+		return uuid.Nil, err
+	}
+
+	if _, err := updatePasswordTx(tx, userID, hashedPassword); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"UAS/app/model"
+
+	"github.com/google/uuid"
+)
+
+type PermissionRepository struct {
+	DB *sql.DB
+}
+
+func NewPermissionRepository(db *sql.DB) *PermissionRepository {
+	return &PermissionRepository{DB: db}
+}
+
+func (r *PermissionRepository) GetAll() ([]model.Permission, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, name, resource, action, COALESCE(description, ''), created_at
+		FROM permissions
+		ORDER BY resource, action
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var perms []model.Permission
+	for rows.Next() {
+		var p model.Permission
+		if err := rows.Scan(&p.ID, &p.Name, &p.Resource, &p.Action, &p.Description, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		perms = append(perms, p)
+	}
+
+	return perms, nil
+}
+
+func (r *PermissionRepository) GetByID(id uuid.UUID) (*model.Permission, error) {
+	var p model.Permission
+	err := r.DB.QueryRow(`
+		SELECT id, name, resource, action, COALESCE(description, ''), created_at
+		FROM permissions
+		WHERE id = $1
+	`, id).Scan(&p.ID, &p.Name, &p.Resource, &p.Action, &p.Description, &p.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("permission not found")
+		}
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (r *PermissionRepository) Create(p *model.Permission) (*model.Permission, error) {
+	p.ID = uuid.New()
+	err := r.DB.QueryRow(`
+		INSERT INTO permissions (id, name, resource, action, description)
+		VALUES ($1,$2,$3,$4,$5)
+		RETURNING created_at
+	`, p.ID, p.Name, p.Resource, p.Action, p.Description).Scan(&p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (r *PermissionRepository) Update(p *model.Permission) (*model.Permission, error) {
+	err := r.DB.QueryRow(`
+		UPDATE permissions
+		SET name = $1,
+		    resource = $2,
+		    action = $3,
+		    description = $4
+		WHERE id = $5
+		RETURNING created_at
+	`, p.Name, p.Resource, p.Action, p.Description, p.ID).Scan(&p.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("permission not found")
+		}
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Delete menghapus permission beserta relasinya ke role
+func (r *PermissionRepository) Delete(id uuid.UUID) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM role_permissions WHERE permission_id = $1`, id); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(`DELETE FROM permissions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("permission not found")
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"database/sql"
+
+	"UAS/app/model"
+
+	"github.com/google/uuid"
+)
+
+type QuarantineRepository struct {
+	DB *sql.DB
+}
+
+func NewQuarantineRepository(db *sql.DB) *QuarantineRepository {
+	return &QuarantineRepository{DB: db}
+}
+
+func (r *QuarantineRepository) Create(q *model.AttachmentQuarantine) error {
+	q.ID = uuid.New()
+	return r.DB.QueryRow(`
+		INSERT INTO attachment_quarantine
+		(id, mongo_achievement_id, storage_key, file_name, size, sha256, signature, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at
+	`, q.ID, q.AchievementID, q.StorageKey, q.FileName, q.Size, q.Checksum, q.Signature, q.UploadedBy).Scan(&q.CreatedAt)
+}
+
+// GetAll mengambil file karantina terbaru lebih dulu beserta total baris
+func (r *QuarantineRepository) GetAll(limit, offset int) ([]model.AttachmentQuarantine, int, error) {
+	var total int
+	if err := r.DB.QueryRow(`SELECT COUNT(*) FROM attachment_quarantine`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT id, mongo_achievement_id, storage_key, file_name, size, sha256, signature, uploaded_by, created_at
+		FROM attachment_quarantine
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := []model.AttachmentQuarantine{}
+	for rows.Next() {
+		var q model.AttachmentQuarantine
+		if err := rows.Scan(&q.ID, &q.AchievementID, &q.StorageKey, &q.FileName, &q.Size, &q.Checksum, &q.Signature, &q.UploadedBy, &q.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		items = append(items, q)
+	}
+
+	return items, total, rows.Err()
+}
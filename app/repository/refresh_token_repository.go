@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"UAS/app/model"
+
+	"github.com/google/uuid"
+)
+
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+type RefreshTokenRepository struct {
+	DB *sql.DB
+}
+
+func NewRefreshTokenRepository(db *sql.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{DB: db}
+}
+
+// Create menyimpan refresh token baru (family baru dibuat saat login)
+func (r *RefreshTokenRepository) Create(userID, familyID uuid.UUID, tokenHash string, expiresAt time.Time) (*model.RefreshToken, error) {
+	t := &model.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}
+
+	err := r.DB.QueryRow(`
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at)
+		VALUES ($1,$2,$3,$4,$5)
+		RETURNING created_at
+	`, t.ID, t.UserID, t.FamilyID, t.TokenHash, t.ExpiresAt).Scan(&t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// GetByHash mengambil refresh token berdasarkan hash
+func (r *RefreshTokenRepository) GetByHash(tokenHash string) (*model.RefreshToken, error) {
+	var t model.RefreshToken
+	err := r.DB.QueryRow(`
+		SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`, tokenHash).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.FamilyID,
+		&t.TokenHash,
+		&t.ExpiresAt,
+		&t.RevokedAt,
+		&t.ReplacedBy,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("refresh token not found")
+		}
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// Rotate menonaktifkan token lama dan membuat token pengganti dalam satu transaksi.
+// Jika token lama ternyata sudah dipakai (race / reuse), ErrRefreshTokenReused dikembalikan.
+func (r *RefreshTokenRepository) Rotate(old *model.RefreshToken, newHash string, expiresAt time.Time) (*model.RefreshToken, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	t := &model.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    old.UserID,
+		FamilyID:  old.FamilyID,
+		TokenHash: newHash,
+		ExpiresAt: expiresAt,
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at)
+		VALUES ($1,$2,$3,$4,$5)
+		RETURNING created_at
+	`, t.ID, t.UserID, t.FamilyID, t.TokenHash, t.ExpiresAt).Scan(&t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = NOW(),
+		    replaced_by = $1
+		WHERE id = $2 AND revoked_at IS NULL
+	`, t.ID, old.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrRefreshTokenReused
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// RevokeFamily mencabut seluruh token dalam satu family
+func (r *RefreshTokenRepository) RevokeFamily(familyID uuid.UUID) error {
+	_, err := r.DB.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`, familyID)
+	return err
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"UAS/app/model"
+)
+
+type RejectionReasonRepository struct {
+	DB *sql.DB
+}
+
+func NewRejectionReasonRepository(db *sql.DB) *RejectionReasonRepository {
+	return &RejectionReasonRepository{DB: db}
+}
+
+// GetAll mengambil daftar alasan penolakan (activeOnly = hanya yang masih bisa dipilih)
+func (r *RejectionReasonRepository) GetAll(activeOnly bool) ([]model.RejectionReason, error) {
+	rows, err := r.DB.Query(`
+		SELECT code, label, COALESCE(description, ''), is_active, created_at, updated_at
+		FROM rejection_reasons
+		WHERE is_active OR NOT $1
+		ORDER BY label ASC
+	`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reasons := []model.RejectionReason{}
+	for rows.Next() {
+		var reason model.RejectionReason
+		if err := rows.Scan(&reason.Code, &reason.Label, &reason.Description, &reason.IsActive, &reason.CreatedAt, &reason.UpdatedAt); err != nil {
+			return nil, err
+		}
+		reasons = append(reasons, reason)
+	}
+
+	return reasons, rows.Err()
+}
+
+// ActiveCodes mengembalikan kode alasan yang masih aktif (untuk validasi reject)
+func (r *RejectionReasonRepository) ActiveCodes() (map[string]bool, error) {
+	reasons, err := r.GetAll(true)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make(map[string]bool, len(reasons))
+	for _, reason := range reasons {
+		codes[reason.Code] = true
+	}
+	return codes, nil
+}
+
+func (r *RejectionReasonRepository) Create(reason *model.RejectionReason) (*model.RejectionReason, error) {
+	err := r.DB.QueryRow(`
+		INSERT INTO rejection_reasons (code, label, description, is_active)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		RETURNING created_at, updated_at
+	`, reason.Code, reason.Label, reason.Description, reason.IsActive).Scan(&reason.CreatedAt, &reason.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return reason, nil
+}
+
+// Update mengubah label / deskripsi / status aktif. Alasan tidak pernah dihapus
+// karena masih dirujuk oleh riwayat penolakan.
+func (r *RejectionReasonRepository) Update(reason *model.RejectionReason) (*model.RejectionReason, error) {
+	err := r.DB.QueryRow(`
+		UPDATE rejection_reasons
+		SET label = $1, description = NULLIF($2, ''), is_active = $3, updated_at = NOW()
+		WHERE code = $4
+		RETURNING created_at, updated_at
+	`, reason.Label, reason.Description, reason.IsActive, reason.Code).Scan(&reason.CreatedAt, &reason.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("rejection reason not found")
+		}
+		return nil, err
+	}
+
+	return reason, nil
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"UAS/app/model"
+
+	"github.com/google/uuid"
+)
+
+type RoleRepository struct {
+	DB *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{DB: db}
+}
+
+// GetAll mengambil semua role
+func (r *RoleRepository) GetAll() ([]model.Role, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, name, COALESCE(description, ''), require_2fa, created_at
+		FROM roles
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []model.Role
+	for rows.Next() {
+		var role model.Role
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.Require2FA, &role.CreatedAt); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
+// GetByID mengambil role berdasarkan UUID
+func (r *RoleRepository) GetByID(id uuid.UUID) (*model.Role, error) {
+	var role model.Role
+	err := r.DB.QueryRow(`
+		SELECT id, name, COALESCE(description, ''), require_2fa, created_at
+		FROM roles
+		WHERE id = $1
+	`, id).Scan(&role.ID, &role.Name, &role.Description, &role.Require2FA, &role.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("role not found")
+		}
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *RoleRepository) Create(name, description string) (*model.Role, error) {
+	role := model.Role{ID: uuid.New(), Name: name, Description: description}
+	err := r.DB.QueryRow(`
+		INSERT INTO roles (id, name, description)
+		VALUES ($1,$2,$3)
+		RETURNING require_2fa, created_at
+	`, role.ID, role.Name, role.Description).Scan(&role.Require2FA, &role.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *RoleRepository) Update(id uuid.UUID, name, description string) (*model.Role, error) {
+	var role model.Role
+	err := r.DB.QueryRow(`
+		UPDATE roles
+		SET name = $1,
+		    description = $2
+		WHERE id = $3
+		RETURNING id, name, COALESCE(description, ''), require_2fa, created_at
+	`, name, description, id).Scan(&role.ID, &role.Name, &role.Description, &role.Require2FA, &role.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("role not found")
+		}
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// Delete menghapus role beserta relasi permission-nya. Role yang masih
+// dipakai user tidak boleh dihapus.
+func (r *RoleRepository) Delete(id uuid.UUID) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var used bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE role_id = $1)`, id).Scan(&used); err != nil {
+		return err
+	}
+	if used {
+		return errors.New("role is still assigned to users")
+	}
+
+	if _, err := tx.Exec(`DELETE FROM role_permissions WHERE role_id = $1`, id); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(`DELETE FROM roles WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("role not found")
+	}
+
+	return tx.Commit()
+}
+
+// GetPermissions mengambil permission yang melekat pada role
+func (r *RoleRepository) GetPermissions(roleID uuid.UUID) ([]model.Permission, error) {
+	rows, err := r.DB.Query(`
+		SELECT p.id, p.name, p.resource, p.action, COALESCE(p.description, ''), p.created_at
+		FROM permissions p
+		JOIN role_permissions rp ON rp.permission_id = p.id
+		WHERE rp.role_id = $1
+		ORDER BY p.resource, p.action
+	`, roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var perms []model.Permission
+	for rows.Next() {
+		var p model.Permission
+		if err := rows.Scan(&p.ID, &p.Name, &p.Resource, &p.Action, &p.Description, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		perms = append(perms, p)
+	}
+
+	return perms, nil
+}
+
+// GetPermissionsByRoleID mengambil permission role dalam format "resource:action"
+// (dipakai oleh cache permission di middleware)
+func (r *RoleRepository) GetPermissionsByRoleID(roleID string) ([]string, error) {
+	rows, err := r.DB.Query(`
+		SELECT p.resource || ':' || p.action
+		FROM permissions p
+		JOIN role_permissions rp ON rp.permission_id = p.id
+		WHERE rp.role_id = $1
+	`, roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var perms []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		perms = append(perms, p)
+	}
+
+	return perms, nil
+}
+
+func (r *RoleRepository) AttachPermission(roleID, permissionID uuid.UUID) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO role_permissions (role_id, permission_id)
+		VALUES ($1,$2)
+		ON CONFLICT DO NOTHING
+	`, roleID, permissionID)
+	return err
+}
+
+func (r *RoleRepository) DetachPermission(roleID, permissionID uuid.UUID) error {
+	res, err := r.DB.Exec(`
+		DELETE FROM role_permissions
+		WHERE role_id = $1 AND permission_id = $2
+	`, roleID, permissionID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("permission not attached to role")
+	}
+	return nil
+}
@@ -178,4 +178,31 @@ func (r *StudentRepository) GetByAdvisorID(advisorID string) ([]model.StudentRes
 	return students, nil
 }
 
+// GetByStudentNumber mengambil mahasiswa berdasarkan NIM (students.student_id)
+func (r *StudentRepository) GetByStudentNumber(nim string) (*model.StudentResponse, error) {
+	row := r.DB.QueryRow(`
+		SELECT s.id, s.user_id, s.student_id, u.full_name, s.program_study, s.academic_year, s.advisor_id
+		FROM students s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.student_id = $1
+	`, nim)
 
+	var s model.StudentResponse
+	err := row.Scan(
+		&s.ID,
+		&s.UserID,
+		&s.StudentID,
+		&s.FullName,
+		&s.ProgramStudy,
+		&s.AcademicYear,
+		&s.AdvisorID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("student not found")
+		}
+		return nil, err
+	}
+
+	return &s, nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TokenRevocationRepository adalah RevocationStore berbasis PostgreSQL,
+// dipakai bersama oleh semua instance aplikasi
+type TokenRevocationRepository struct {
+	DB *sql.DB
+}
+
+func NewTokenRevocationRepository(db *sql.DB) *TokenRevocationRepository {
+	return &TokenRevocationRepository{DB: db}
+}
+
+func (r *TokenRevocationRepository) Revoke(jti string, expiresAt time.Time) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+	`, jti, expiresAt)
+	return err
+}
+
+func (r *TokenRevocationRepository) IsRevoked(jti string) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM revoked_tokens
+			WHERE jti = $1 AND expires_at > NOW()
+		)
+	`, jti).Scan(&exists)
+	return exists, err
+}
+
+func (r *TokenRevocationRepository) PurgeExpired() (int64, error) {
+	res, err := r.DB.Exec(`DELETE FROM revoked_tokens WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
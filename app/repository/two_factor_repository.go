@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"UAS/app/model"
+
+	"github.com/google/uuid"
+)
+
+type TwoFactorRepository struct {
+	DB *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{DB: db}
+}
+
+// GetByUserID mengambil konfigurasi TOTP user, nil jika belum pernah setup
+func (r *TwoFactorRepository) GetByUserID(userID uuid.UUID) (*model.UserTwoFactor, error) {
+	var t model.UserTwoFactor
+	err := r.DB.QueryRow(`
+		SELECT user_id, secret_enc, enabled, last_used_step, enabled_at, created_at
+		FROM user_totp
+		WHERE user_id = $1
+	`, userID).Scan(
+		&t.UserID,
+		&t.SecretEnc,
+		&t.Enabled,
+		&t.LastUsedStep,
+		&t.EnabledAt,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SavePendingSecret menyimpan secret baru yang belum aktif (menunggu verifikasi kode pertama)
+func (r *TwoFactorRepository) SavePendingSecret(userID uuid.UUID, secretEnc string) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO user_totp (user_id, secret_enc, enabled, last_used_step)
+		VALUES ($1, $2, FALSE, 0)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret_enc = EXCLUDED.secret_enc,
+		    enabled = FALSE,
+		    last_used_step = 0,
+		    enabled_at = NULL
+		WHERE user_totp.enabled = FALSE
+	`, userID, secretEnc)
+	return err
+}
+
+// Enable mengaktifkan 2FA dan mengganti seluruh recovery code
+func (r *TwoFactorRepository) Enable(userID uuid.UUID, recoveryHashes []string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE user_totp
+		SET enabled = TRUE,
+		    enabled_at = NOW()
+		WHERE user_id = $1
+	`, userID); err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Disable menghapus secret dan recovery code user
+func (r *TwoFactorRepository) Disable(userID uuid.UUID) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MarkStepUsed mencatat langkah TOTP yang dipakai. Mengembalikan false jika
+// langkah tersebut (atau yang lebih baru) sudah pernah dipakai (replay).
+func (r *TwoFactorRepository) MarkStepUsed(userID uuid.UUID, step int64) (bool, error) {
+	res, err := r.DB.Exec(`
+		UPDATE user_totp
+		SET last_used_step = $1
+		WHERE user_id = $2 AND last_used_step < $1
+	`, step, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+// UseRecoveryCode memakai satu recovery code (sekali pakai)
+func (r *TwoFactorRepository) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	res, err := r.DB.Exec(`
+		UPDATE user_recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+// ReplaceRecoveryCodes mengganti seluruh recovery code user
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(userID uuid.UUID, hashes []string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, hashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID uuid.UUID, hashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, h := range hashes {
+		if _, err := tx.Exec(`
+			INSERT INTO user_recovery_codes (id, user_id, code_hash)
+			VALUES ($1,$2,$3)
+		`, uuid.New(), userID, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsRequiredForRole mengecek apakah role mewajibkan 2FA
+func (r *TwoFactorRepository) IsRequiredForRole(roleID uuid.UUID) (bool, error) {
+	var required bool
+	err := r.DB.QueryRow(`SELECT require_2fa FROM roles WHERE id = $1`, roleID).Scan(&required)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return required, nil
+}
+
+// SetRoleRequirement mengatur kewajiban 2FA untuk sebuah role
+func (r *TwoFactorRepository) SetRoleRequirement(roleID uuid.UUID, required bool) error {
+	res, err := r.DB.Exec(`UPDATE roles SET require_2fa = $1 WHERE id = $2`, required, roleID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("role not found")
+	}
+	return nil
+}
+
+// ----------------- LOGIN CHALLENGE -----------------
+
+// CreateChallenge menyimpan challenge login (hash token) setelah password benar
+func (r *TwoFactorRepository) CreateChallenge(userID uuid.UUID, purpose, tokenHash string, expiresAt time.Time) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO login_challenges (id, user_id, purpose, token_hash, expires_at)
+		VALUES ($1,$2,$3,$4,$5)
+	`, uuid.New(), userID, purpose, tokenHash, expiresAt)
+	return err
+}
+
+// GetChallenge mengambil challenge yang masih berlaku
+func (r *TwoFactorRepository) GetChallenge(tokenHash string) (*model.LoginChallenge, error) {
+	var ch model.LoginChallenge
+	err := r.DB.QueryRow(`
+		SELECT id, user_id, purpose, expires_at, attempts, used_at
+		FROM login_challenges
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+	`, tokenHash).Scan(
+		&ch.ID,
+		&ch.UserID,
+		&ch.Purpose,
+		&ch.ExpiresAt,
+		&ch.Attempts,
+		&ch.UsedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("invalid or expired challenge")
+		}
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// FailChallenge menambah hitungan percobaan gagal; challenge hangus setelah maxAttempts
+func (r *TwoFactorRepository) FailChallenge(id uuid.UUID, maxAttempts int) error {
+	_, err := r.DB.Exec(`
+		UPDATE login_challenges
+		SET attempts = attempts + 1,
+		    used_at = CASE WHEN attempts + 1 >= $2 THEN NOW() ELSE used_at END
+		WHERE id = $1
+	`, id, maxAttempts)
+	return err
+}
+
+// ConsumeChallenge menandai challenge sudah dipakai
+func (r *TwoFactorRepository) ConsumeChallenge(id uuid.UUID) (bool, error) {
+	res, err := r.DB.Exec(`
+		UPDATE login_challenges
+		SET used_at = NOW()
+		WHERE id = $1 AND used_at IS NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
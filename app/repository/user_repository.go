@@ -20,12 +20,12 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{DB: db}
 }
 
-// Cari user berdasarkan email
+// Cari user berdasarkan email (tidak peka huruf besar/kecil, sama seperti key throttling login)
 func (r *UserRepository) GetUserByEmail(email string) (*model.User, error) {
 	row := r.DB.QueryRow(`
 		SELECT id, username, email, password, full_name, role_id, is_active
 		FROM users
-		WHERE email = $1
+		WHERE LOWER(email) = LOWER($1)
 	`, email)
 
 	var u model.User
@@ -163,6 +163,14 @@ func (r *UserRepository) CreateUser(
 		return nil, err
 	}
 
+	// ===== PASSWORD HISTORY =====
+	if _, err := tx.Exec(`
+		INSERT INTO password_history (id, user_id, password_hash)
+		VALUES ($1,$2,$3)
+	`, uuid.New(), user.ID, user.Password); err != nil {
+		return nil, err
+	}
+
 	// ===== INSERT STUDENT =====
 	if studentID != nil {
 		_, err := tx.Exec(`
@@ -215,6 +223,7 @@ func (r *UserRepository) UpdateUser(user *model.User) (*model.User, error) {
 		    full_name = $3,
 		    role_id = $4,
 		    is_active = $5,
+		    token_version = token_version + CASE WHEN role_id IS DISTINCT FROM $4 THEN 1 ELSE 0 END,
 		    updated_at = NOW()
 		WHERE id = $6
 		RETURNING id, username, email, full_name, role_id, is_active, created_at, updated_at
@@ -269,6 +278,27 @@ func (r *UserRepository) DeleteUser(id uuid.UUID) error {
 }
 
 func (r *UserRepository) UpdatePassword(userID uuid.UUID, hashedPassword string) (*model.User, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	updatedUser, err := updatePasswordTx(tx, userID, hashedPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return updatedUser, nil
+}
+
+// updatePasswordTx mengganti password dan mencatatnya di riwayat dalam transaksi tx
+// (dipakai juga oleh reset password agar token dan password berubah bersamaan)
+func updatePasswordTx(tx *sql.Tx, userID uuid.UUID, hashedPassword string) (*model.User, error) {
 	query := `
 		UPDATE users
 		SET password = $1,
@@ -278,7 +308,7 @@ func (r *UserRepository) UpdatePassword(userID uuid.UUID, hashedPassword string)
 	`
 
 	updatedUser := &model.User{}
-	err := r.DB.QueryRow(query, hashedPassword, userID).Scan(
+	err := tx.QueryRow(query, hashedPassword, userID).Scan(
 		&updatedUser.ID,
 		&updatedUser.Username,
 		&updatedUser.Email,
@@ -295,9 +325,53 @@ func (r *UserRepository) UpdatePassword(userID uuid.UUID, hashedPassword string)
 		return nil, err
 	}
 
+	// Simpan hash ke riwayat untuk mencegah pemakaian ulang password
+	if _, err := tx.Exec(`
+		INSERT INTO password_history (id, user_id, password_hash)
+		VALUES ($1,$2,$3)
+	`, uuid.New(), userID, hashedPassword); err != nil {
+		return nil, err
+	}
+
 	return updatedUser, nil
 }
 
+// UpdatePasswordHash mengganti hash password tanpa mengubah password
+// (dipakai saat re-hash dengan cost bcrypt yang lebih tinggi)
+func (r *UserRepository) UpdatePasswordHash(userID uuid.UUID, hashedPassword string) error {
+	_, err := r.DB.Exec(`
+		UPDATE users SET password = $1 WHERE id = $2
+	`, hashedPassword, userID)
+	return err
+}
+
+// GetRecentPasswordHashes mengambil hash password saat ini dan n hash terakhir dari riwayat
+func (r *UserRepository) GetRecentPasswordHashes(userID uuid.UUID, n int) ([]string, error) {
+	rows, err := r.DB.Query(`
+		(SELECT password FROM users WHERE id = $1)
+		UNION ALL
+		(SELECT password_hash FROM password_history
+		 WHERE user_id = $1
+		 ORDER BY created_at DESC
+		 LIMIT $2)
+	`, userID, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+
+	return hashes, nil
+}
+
 func (r *UserRepository) GetRoleIDByName(name string) (uuid.UUID, error) {
 	var id uuid.UUID
 	err := r.DB.QueryRow(`
@@ -311,3 +385,58 @@ func (r *UserRepository) GetRoleIDByName(name string) (uuid.UUID, error) {
 
 
 
+
+
+// GetTokenVersion mengambil versi token user saat ini
+func (r *UserRepository) GetTokenVersion(userID string) (int, error) {
+	var version int
+	err := r.DB.QueryRow(`
+		SELECT token_version FROM users WHERE id = $1
+	`, userID).Scan(&version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("user not found")
+		}
+		return 0, err
+	}
+	return version, nil
+}
+
+// RevokeAllSessions menaikkan token_version (semua access token lama tidak berlaku)
+// dan mencabut semua refresh token milik user
+func (r *UserRepository) RevokeAllSessions(userID uuid.UUID) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE users
+		SET token_version = token_version + 1,
+		    updated_at = NOW()
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	_, err = tx.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
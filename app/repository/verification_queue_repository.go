@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"UAS/app/model"
+
+	"github.com/google/uuid"
+)
+
+// VerificationQueueRepository membaca antrean prestasi submitted milik mahasiswa
+// bimbingan seorang dosen wali. Judul & tipe diambil dari snapshot putaran yang
+// sedang berjalan (achievement_rounds), jadi tidak perlu query ke MongoDB.
+type VerificationQueueRepository struct {
+	DB *sql.DB
+}
+
+func NewVerificationQueueRepository(db *sql.DB) *VerificationQueueRepository {
+	return &VerificationQueueRepository{DB: db}
+}
+
+const pendingFrom = `
+	FROM achievement_references ar
+	JOIN students s ON s.id = ar.student_id
+	JOIN users u ON u.id = s.user_id
+	LEFT JOIN achievement_rounds rd
+		ON rd.mongo_achievement_id = ar.mongo_achievement_id AND rd.round = ar.submission_round
+	WHERE s.advisor_id = $1
+	  AND ar.status = 'submitted'
+	  AND ar.deleted_at IS NULL
+`
+
+// Pending mengambil satu halaman antrean beserta total baris yang cocok dengan filter
+func (r *VerificationQueueRepository) Pending(f model.VerificationQueueFilter) ([]model.PendingVerification, int, error) {
+	where := pendingFrom + `
+	  AND ($2 = '' OR rd.snapshot->>'achievementType' = $2)
+	  AND ($3 = '' OR s.program_study = $3)
+	`
+
+	var total int
+	if err := r.DB.QueryRow(`SELECT COUNT(*) `+where, f.AdvisorID, f.AchievementType, f.ProgramStudy).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order := "ASC"
+	if f.NewestFirst {
+		order = "DESC"
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT ar.mongo_achievement_id,
+		       COALESCE(rd.snapshot->>'title', ''),
+		       COALESCE(rd.snapshot->>'achievementType', ''),
+		       ar.submission_round,
+		       s.id, s.student_id, u.full_name, COALESCE(s.program_study, ''),
+		       COALESCE(ar.submitted_at, rd.submitted_at, ar.updated_at) AS submitted_at
+		`+where+`
+		ORDER BY submitted_at `+order+`, ar.mongo_achievement_id ASC
+		LIMIT $4 OFFSET $5
+	`, f.AdvisorID, f.AchievementType, f.ProgramStudy, f.Limit, f.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	items := []model.PendingVerification{}
+	for rows.Next() {
+		var p model.PendingVerification
+		if err := rows.Scan(
+			&p.AchievementID,
+			&p.Title,
+			&p.AchievementType,
+			&p.Round,
+			&p.StudentUUID,
+			&p.StudentID,
+			&p.StudentName,
+			&p.ProgramStudy,
+			&p.SubmittedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		p.WaitingDays = int(now.Sub(p.SubmittedAt).Hours() / 24)
+		items = append(items, p)
+	}
+
+	return items, total, rows.Err()
+}
+
+// Counts menghitung seluruh antrean dosen wali (tanpa filter) untuk badge
+func (r *VerificationQueueRepository) Counts(advisorID uuid.UUID) (*model.VerificationQueueCounts, error) {
+	rows, err := r.DB.Query(`
+		SELECT COALESCE(rd.snapshot->>'achievementType', ''),
+		       COALESCE(s.program_study, ''),
+		       COUNT(*),
+		       COUNT(*) FILTER (
+		           WHERE COALESCE(ar.submitted_at, rd.submitted_at, ar.updated_at) < NOW() - make_interval(days => $2)
+		       )
+		`+pendingFrom+`
+		GROUP BY 1, 2
+	`, advisorID, model.OverdueAfterDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := &model.VerificationQueueCounts{
+		ByType:         map[string]int{},
+		ByProgramStudy: map[string]int{},
+	}
+	for rows.Next() {
+		var achievementType, programStudy string
+		var n, overdue int
+		if err := rows.Scan(&achievementType, &programStudy, &n, &overdue); err != nil {
+			return nil, err
+		}
+		counts.Total += n
+		counts.Overdue += overdue
+		if achievementType != "" {
+			counts.ByType[achievementType] += n
+		}
+		if programStudy != "" {
+			counts.ByProgramStudy[programStudy] += n
+		}
+	}
+
+	return counts, rows.Err()
+}
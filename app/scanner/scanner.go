@@ -0,0 +1,24 @@
+package scanner
+
+import (
+	"context"
+	"io"
+)
+
+// Result adalah hasil pemindaian satu file
+type Result struct {
+	Clean     bool
+	Signature string // nama malware jika Clean = false
+}
+
+// Scanner memindai isi file sebelum disimpan sebagai lampiran
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
+
+// NoopScanner menganggap semua file bersih (development / tanpa antivirus)
+type NoopScanner struct{}
+
+func (NoopScanner) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	return Result{Clean: true}, nil
+}
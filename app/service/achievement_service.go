@@ -1,23 +1,22 @@
 package service
 
 import (
+	"errors"
 	"net/http"
 	"time"
-	"fmt"
-	"os"
-	"strings"
+	"log"
 
 	"UAS/app/model"
-	"path/filepath"
+	"UAS/app/policy"
 	"UAS/app/repository"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 // ListAchievements godoc
 // @Summary List achievements
-// @Description Mahasiswa: melihat prestasi milik sendiri, Dosen/Admin: melihat prestasi yang sudah diverifikasi
+// @Description Mahasiswa: melihat prestasi milik sendiri, Dosen Wali: prestasi mahasiswa bimbingan (selain draft), Admin: prestasi yang sudah diverifikasi
 // @Tags Achievements
 // @Security BearerAuth
 // @Produce json
@@ -28,42 +27,27 @@ import (
 func ListAchievements(
 	c *fiber.Ctx,
 	achievementRepo *repository.AchievementRepository,
-	studentRepo *repository.StudentRepository,
+	pol *policy.Policy,
 ) error {
 
-	userIDAny := c.Locals("userID")
-	permsAny := c.Locals("permissions")
-
-	if userIDAny == nil || permsAny == nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "unauthorized",
-		})
-	}
-
-	userID := userIDAny.(string)
-	perms := permsAny.([]string)
-
-	isStudent := false
-	for _, p := range perms {
-		if p == "achievement:create" {
-			isStudent = true
-			break
-		}
+	actor, err := pol.Actor(c)
+	if err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	// ===== MAHASISWA =====
-	if isStudent {
-		students, err := studentRepo.GetByUserID(userID)
-		if err != nil || len(students) == 0 {
-			return c.JSON(fiber.Map{"data": []interface{}{}})
+	if actor.IsStudent() {
+		achievements, err := achievementRepo.GetByStudentID(actor.StudentNumbers())
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		var studentIDs []string
-		for _, s := range students {
-			studentIDs = append(studentIDs, s.StudentID)
-		}
+		return c.JSON(fiber.Map{"data": achievements})
+	}
 
-		achievements, err := achievementRepo.GetByStudentID(studentIDs)
+	// ===== ADMIN =====
+	if actor.IsAdmin() {
+		achievements, err := achievementRepo.GetVerifiedOnly()
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
@@ -71,13 +55,39 @@ func ListAchievements(
 		return c.JSON(fiber.Map{"data": achievements})
 	}
 
-	// ===== DOSEN / ADMIN =====
-	achievements, err := achievementRepo.GetVerifiedOnly()
+	// ===== DOSEN WALI =====
+	if !actor.IsLecturer() {
+		return c.JSON(fiber.Map{"data": []interface{}{}})
+	}
+
+	advisees, err := pol.Students.GetByAdvisorID(actor.Lecturer.ID.String())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(advisees) == 0 {
+		return c.JSON(fiber.Map{"data": []interface{}{}})
+	}
+
+	var nims []string
+	for _, s := range advisees {
+		nims = append(nims, s.StudentID)
+	}
+
+	achievements, err := achievementRepo.GetByStudentID(nims)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.JSON(fiber.Map{"data": achievements})
+	// draft & deleted hanya terlihat oleh mahasiswa pemilik
+	visible := []model.Achievement{}
+	for _, a := range achievements {
+		if a.Status == string(model.StatusDraft) || a.Status == string(model.StatusDeleted) {
+			continue
+		}
+		visible = append(visible, a)
+	}
+
+	return c.JSON(fiber.Map{"data": visible})
 }
 
 // GetAchievementDetail godoc
@@ -94,6 +104,7 @@ func ListAchievements(
 func GetAchievementDetail(
 	c *fiber.Ctx,
 	achievementRepo *repository.AchievementRepository,
+	pol *policy.Policy,
 ) error {
 
 	id := c.Params("id")
@@ -109,6 +120,17 @@ func GetAchievementDetail(
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "achievement not found"})
 	}
 
+	if _, err := pol.AuthorizeStudentNumber(c, achievement.StudentID, policy.View); err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// draft hanya boleh dilihat pemiliknya
+	if achievement.Status == string(model.StatusDraft) {
+		if _, err := pol.AuthorizeStudentNumber(c, achievement.StudentID, policy.Modify); err != nil {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "achievement not found"})
+		}
+	}
+
 	return c.JSON(fiber.Map{
 		"status":      "success",
 		"achievement": achievement,
@@ -117,12 +139,12 @@ func GetAchievementDetail(
 
 // CreateAchievement godoc
 // @Summary Create achievement
-// @Description Mahasiswa membuat prestasi baru (status draft)
+// @Description Mahasiswa membuat prestasi baru (status draft). Field yang diterima: achievementType, title, description, details, tags; details divalidasi sesuai schema achievementType.
 // @Tags Achievements
 // @Security BearerAuth
 // @Accept json
 // @Produce json
-// @Param body body model.Achievement true "Achievement payload"
+// @Param body body model.AchievementInput true "Achievement payload"
 // @Success 201 {object} model.AchievementResponse
 // @Failure 400 {object} map[string]string
 // @Router /achievements [post]
@@ -130,28 +152,50 @@ func CreateAchievement(
 	c *fiber.Ctx,
 	achievementRepo *repository.AchievementRepository,
 	refRepo *repository.AchievementReferenceRepository,
-	studentRepo *repository.StudentRepository,
+	versionRepo *repository.AchievementVersionRepository,
+	workflow *AchievementWorkflow,
 ) error {
 
-	userID := c.Locals("userID").(string)
-
-	students, err := studentRepo.GetByUserID(userID)
-	if err != nil || len(students) == 0 {
+	actor, err := workflow.Policy.Actor(c)
+	if err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !actor.IsStudent() {
 		return c.Status(400).JSON(fiber.Map{"error": "student profile not found"})
 	}
 
-	student := students[0]
+	student := actor.Students[0]
 
-	var input model.Achievement
-	if err := c.BodyParser(&input); err != nil {
+	var body map[string]interface{}
+	if err := c.BodyParser(&body); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	input.StudentID = student.StudentID
-	input.Status = string(model.StatusDraft)
-	input.Points = 0
-	input.CreatedAt = time.Now()
-	input.UpdatedAt = time.Now()
+	fields, fieldErrs := model.ValidateAchievementCreate(body)
+	if len(fieldErrs) == 0 {
+		fieldErrs = checkTypeActive(workflow.Points.Catalog, fields)
+	}
+	if len(fieldErrs) > 0 {
+		return validationError(c, fieldErrs)
+	}
+
+	input := model.Achievement{
+		StudentID:       student.StudentID,
+		AchievementType: fields["achievementType"].(string),
+		Title:           fields["title"].(string),
+		Details:         fields["details"].(bson.M),
+		Tags:            []string{},
+		Status:          string(model.StatusDraft),
+		Points:          0,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	if description, ok := fields["description"].(string); ok {
+		input.Description = description
+	}
+	if tags, ok := fields["tags"].([]string); ok {
+		input.Tags = tags
+	}
 
 	created, err := achievementRepo.Create(&input)
 	if err != nil {
@@ -160,11 +204,15 @@ func CreateAchievement(
 
 	mongoID := created.ID.Hex()
 
-	ref, err := refRepo.CreateReference(student.ID, mongoID)
+	ref, err := refRepo.CreateReference(student.ID, mongoID, actor.UserID)
 	if err != nil {
+		// kompensasi: hapus dokumen Mongo agar tidak yatim
+		compensateCreate(achievementRepo, workflow.Dispatcher, mongoID)
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	recordVersion(versionRepo, created, model.VersionCreate, actor.UserID, nil)
+
 	return c.Status(201).JSON(fiber.Map{
 		"status":      "success",
 		"achievement": created,
@@ -172,32 +220,71 @@ func CreateAchievement(
 	})
 }
 
+// compensateCreate menghapus dokumen Mongo yang reference-nya gagal dibuat. Jika
+// penghapusan langsung gagal, dijadwalkan lewat outbox agar diulang worker.
+func compensateCreate(
+	achievementRepo *repository.AchievementRepository,
+	dispatcher *OutboxDispatcher,
+	mongoID string,
+) {
+	err := achievementRepo.HardDelete(mongoID)
+	if err == nil {
+		return
+	}
+	log.Println("❌ Failed to delete orphan achievement, scheduling retry:", err)
+
+	if _, err := dispatcher.Outbox.Enqueue(mongoID, model.OutboxAchievementDelete, struct{}{}); err != nil {
+		log.Println("❌ Failed to schedule orphan achievement cleanup:", mongoID, err)
+	}
+}
+
+// syncAchievement menerapkan event outbox achievement langsung setelah commit lalu
+// mengambil dokumen terbaru. Jika MongoDB gagal, worker outbox akan mengulang dan
+// response tetap memakai status yang sudah tersimpan di PostgreSQL.
+func syncAchievement(
+	achievementRepo *repository.AchievementRepository,
+	dispatcher *OutboxDispatcher,
+	current *model.Achievement,
+	status model.AchievementStatus,
+) *model.Achievement {
+	id := current.ID.Hex()
+	if err := dispatcher.DispatchAggregate(id); err != nil {
+		log.Println("❌ Failed to dispatch outbox for achievement", id, err)
+	}
+
+	updated, err := achievementRepo.GetByID(id)
+	if err != nil || updated == nil || updated.Status != string(status) {
+		fallback := *current
+		fallback.Status = string(status)
+		return &fallback
+	}
+	return updated
+}
+
 // UpdateAchievement godoc
 // @Summary Update achievement
-// @Description Update prestasi (hanya status draft & milik sendiri)
+// @Description Update prestasi (hanya status draft & milik sendiri). Hanya achievementType, title, description, details dan tags yang boleh diubah. Setiap edit disimpan sebagai versi baru.
 // @Tags Achievements
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path string true "Achievement ID"
-// @Param body body object true "Update payload"
+// @Param body body model.AchievementInput true "Update payload (partial)"
 // @Success 200 {object} model.AchievementResponse
+// @Failure 400 {object} map[string]interface{}
 // @Failure 403 {object} map[string]string
 // @Router /achievements/{id} [put]
 func UpdateAchievement(
 	c *fiber.Ctx,
 	achievementRepo *repository.AchievementRepository,
-	studentRepo *repository.StudentRepository,
+	refRepo *repository.AchievementReferenceRepository,
+	versionRepo *repository.AchievementVersionRepository,
+	catalog *repository.AchievementTypeRepository,
+	pol *policy.Policy,
 ) error {
 
-	userID := c.Locals("userID").(string)
 	id := c.Params("id")
 
-	students, err := studentRepo.GetByUserID(userID)
-	if err != nil || len(students) == 0 {
-		return c.Status(400).JSON(fiber.Map{"error": "student profile not found"})
-	}
-
 	var input map[string]interface{}
 	if err := c.BodyParser(&input); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
@@ -208,22 +295,89 @@ func UpdateAchievement(
 		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
 	}
 
-	if achievement.StudentID != students[0].StudentID {
-		return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
+	if _, err := pol.AuthorizeStudentNumber(c, achievement.StudentID, policy.Modify); err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	if achievement.Status != string(model.StatusDraft) {
-		return c.Status(400).JSON(fiber.Map{"error": "only draft can be updated"})
+	if ok, err := requireDraft(c, refRepo, id, "only draft can be updated"); !ok {
+		return err
+	}
+
+	actor, err := pol.Actor(c)
+	if err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	fields, fieldErrs := model.ValidateAchievementUpdate(input, achievement)
+	if len(fieldErrs) == 0 {
+		fieldErrs = checkTypeActive(catalog, fields)
+	}
+	if len(fieldErrs) > 0 {
+		return validationError(c, fieldErrs)
+	}
+	if len(fields) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "no fields to update"})
 	}
 
-	updated, err := achievementRepo.Update(id, input)
+	ensureBaselineVersion(versionRepo, achievement)
+
+	updated, err := achievementRepo.Update(id, fields)
+	if errors.Is(err, repository.ErrNotDraft) {
+		return c.Status(400).JSON(fiber.Map{"error": "only draft can be updated"})
+	}
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
+	if updated == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
+	}
+
+	recordVersion(versionRepo, updated, model.VersionUpdate, actor.UserID, nil)
 
 	return c.JSON(fiber.Map{"status": "success", "achievement": updated})
 }
 
+// checkTypeActive menolak achievementType yang dinonaktifkan admin di katalog
+func checkTypeActive(catalog *repository.AchievementTypeRepository, fields bson.M) []model.FieldError {
+	code, ok := fields["achievementType"].(string)
+	if !ok {
+		return nil
+	}
+
+	t, err := catalog.GetType(code)
+	if err == nil && t.IsActive {
+		return nil
+	}
+	if err != nil && err.Error() != "achievement type not found" {
+		log.Println("❌ Failed to read achievement type catalog:", err)
+		return nil
+	}
+
+	return []model.FieldError{{Field: "achievementType", Message: "is not accepted anymore"}}
+}
+
+// validationError mengembalikan kesalahan validasi per field
+func validationError(c *fiber.Ctx, errs []model.FieldError) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error":   "validation failed",
+		"details": errs,
+	})
+}
+
+// runTransition menjalankan event workflow untuk prestasi :id atas nama user yang login
+func runTransition(
+	c *fiber.Ctx,
+	workflow *AchievementWorkflow,
+	event model.AchievementEvent,
+	payload model.TransitionPayload,
+) (*model.Achievement, error) {
+	actor, err := workflow.Policy.Actor(c)
+	if err != nil {
+		return nil, err
+	}
+	return workflow.Transition(c.Context(), c.Params("id"), actor, event, payload)
+}
+
 // DeleteAchievement godoc
 // @Summary Delete achievement
 // @Description Hapus prestasi (soft delete, draft only)
@@ -232,37 +386,17 @@ func UpdateAchievement(
 // @Produce json
 // @Param id path string true "Achievement ID"
 // @Success 200 {object} model.AchievementResponse
+// @Failure 400 {object} map[string]string
 // @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /achievements/{id} [delete]
 func DeleteAchievement(
 	c *fiber.Ctx,
-	achievementRepo *repository.AchievementRepository,
-	studentRepo *repository.StudentRepository,
+	workflow *AchievementWorkflow,
 ) error {
 
-	userID := c.Locals("userID").(string)
-	id := c.Params("id")
-
-	students, err := studentRepo.GetByUserID(userID)
-	if err != nil || len(students) == 0 {
-		return c.Status(400).JSON(fiber.Map{"error": "student profile not found"})
-	}
-
-	achievement, err := achievementRepo.GetByID(id)
-	if err != nil || achievement == nil {
-		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
-	}
-
-	if achievement.StudentID != students[0].StudentID {
-		return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
-	}
-
-	if achievement.Status != string(model.StatusDraft) {
-		return c.Status(400).JSON(fiber.Map{"error": "only draft can be deleted"})
-	}
-
-	if err := achievementRepo.Delete(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	if _, err := runTransition(c, workflow, model.EventDelete, model.TransitionPayload{}); err != nil {
+		return transitionError(c, err)
 	}
 
 	return c.JSON(fiber.Map{"status": "success"})
@@ -277,42 +411,16 @@ func DeleteAchievement(
 // @Param id path string true "Achievement ID"
 // @Success 200 {object} model.AchievementResponse
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /achievements/{id}/submit [post]
 func SubmitAchievement(
 	c *fiber.Ctx,
-	achievementRepo *repository.AchievementRepository,
-	refRepo *repository.AchievementReferenceRepository,
-	studentRepo *repository.StudentRepository,
+	workflow *AchievementWorkflow,
 ) error {
 
-	userID := c.Locals("userID").(string)
-	id := c.Params("id")
-
-	students, err := studentRepo.GetByUserID(userID)
-	if err != nil || len(students) == 0 {
-		return c.Status(400).JSON(fiber.Map{"error": "student profile not found"})
-	}
-
-	achievement, err := achievementRepo.GetByID(id)
-	if err != nil || achievement == nil {
-		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
-	}
-
-	if achievement.StudentID != students[0].StudentID {
-		return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
-	}
-
-	if achievement.Status != string(model.StatusDraft) {
-		return c.Status(400).JSON(fiber.Map{"error": "only draft can be submitted"})
-	}
-
-	updated, err := achievementRepo.UpdateStatus(id, string(model.StatusSubmitted))
+	updated, err := runTransition(c, workflow, model.EventSubmit, model.TransitionPayload{})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-	}
-
-	if err := refRepo.Submit(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return transitionError(c, err)
 	}
 
 	return c.JSON(fiber.Map{"status": "success", "achievement": updated})
@@ -320,180 +428,304 @@ func SubmitAchievement(
 
 // VerifyAchievement godoc
 // @Summary Verify achievement
-// @Description Dosen memverifikasi prestasi mahasiswa
+// @Description Dosen wali memverifikasi prestasi mahasiswa bimbingannya (submitted -> verified). Poin dihitung dari katalog; dosen wali boleh menyesuaikan poin (points) dengan justification.
 // @Tags Achievements
 // @Security BearerAuth
+// @Accept json
 // @Produce json
 // @Param id path string true "Achievement ID"
+// @Param body body model.TransitionPayload false "Optional points override + justification"
 // @Success 200 {object} model.AchievementResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /achievements/{id}/verify [post]
 func VerifyAchievement(
 	c *fiber.Ctx,
-	refRepo *repository.AchievementReferenceRepository,
+	workflow *AchievementWorkflow,
 ) error {
 
-	mongoID := c.Params("id")
-	lecturerID := c.Locals("userID").(string)
-
-	uid, err := uuid.Parse(lecturerID)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "invalid user id"})
+	// body opsional: verifikasi tanpa override boleh tanpa body
+	var body model.TransitionPayload
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
 	}
 
-	if err := refRepo.Verify(mongoID, uid); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	updated, err := runTransition(c, workflow, model.EventVerify, body)
+	if err != nil {
+		return transitionError(c, err)
 	}
 
-	return c.JSON(fiber.Map{"message": "achievement verified"})
+	return c.JSON(fiber.Map{"message": "achievement verified", "achievement": updated})
 }
+
 // RejectAchievement godoc
 // @Summary Reject achievement
-// @Description Dosen menolak prestasi mahasiswa
+// @Description Dosen wali menolak prestasi mahasiswa bimbingannya (submitted -> rejected). Wajib memilih minimal satu alasan (reasons) dari daftar rejection reason; fieldComments menunjuk field yang harus diperbaiki.
 // @Tags Achievements
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path string true "Achievement ID"
-// @Param body body object true "Reject note"
+// @Param body body model.TransitionPayload true "Reasons, field comments and note"
 // @Success 200 {object} model.AchievementResponse
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /achievements/{id}/reject [post]
 func RejectAchievement(
 	c *fiber.Ctx,
-	achievementRepo *repository.AchievementRepository,
-	refRepo *repository.AchievementReferenceRepository,
+	workflow *AchievementWorkflow,
 ) error {
 
-	id := c.Params("id")
-
-	var body struct {
-		Note string `json:"note"`
-	}
+	var body model.TransitionPayload
 	if err := c.BodyParser(&body); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	updated, err := runTransition(c, workflow, model.EventReject, body)
+	if err != nil {
+		return transitionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "achievement": updated})
+}
+
+// ReviseAchievement godoc
+// @Summary Revise rejected achievement
+// @Description Mahasiswa mengembalikan prestasi yang ditolak ke draft untuk diperbaiki (rejected -> draft). Catatan penolakan tetap disimpan; submit berikutnya membuka putaran baru.
+// @Tags Achievements
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Success 200 {object} model.AchievementResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /achievements/{id}/revise [post]
+func ReviseAchievement(
+	c *fiber.Ctx,
+	workflow *AchievementWorkflow,
+) error {
+
+	updated, err := runTransition(c, workflow, model.EventRevise, model.TransitionPayload{})
+	if err != nil {
+		return transitionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "achievement": updated})
+}
+
+// GetAchievementRounds godoc
+// @Summary Get achievement submission rounds
+// @Description Seluruh putaran pengajuan prestasi beserta isi yang diajukan dan hasil review (verified/rejected)
+// @Tags Achievements
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Success 200 {array} model.AchievementRound
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievements/{id}/rounds [get]
+func GetAchievementRounds(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	refRepo *repository.AchievementReferenceRepository,
+	pol *policy.Policy,
+) error {
+
+	id := c.Params("id")
+
 	achievement, err := achievementRepo.GetByID(id)
 	if err != nil || achievement == nil {
 		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
 	}
 
-	if achievement.Status != string(model.StatusSubmitted) {
-		return c.Status(400).JSON(fiber.Map{"error": "only submitted can be rejected"})
+	if _, err := pol.AuthorizeStudentNumber(c, achievement.StudentID, policy.View); err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	updated, err := achievementRepo.UpdateStatus(id, string(model.StatusRejected))
+	rounds, err := refRepo.GetRounds(id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	if err := refRepo.Reject(id, body.Note); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-	}
-
-	return c.JSON(fiber.Map{"status": "success", "achievement": updated})
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   rounds,
+	})
 }
 
-
-// UploadAchievementAttachment godoc
-// @Summary Upload achievement attachment
-// @Description Upload file pendukung prestasi (PDF/JPG/PNG)
+// GetAchievementRevisionDiff godoc
+// @Summary Diff since last rejection
+// @Description Perubahan isi prestasi dibandingkan putaran terakhir yang ditolak, untuk dosen wali saat meninjau pengajuan ulang
 // @Tags Achievements
 // @Security BearerAuth
-// @Accept multipart/form-data
 // @Produce json
 // @Param id path string true "Achievement ID"
-// @Param file formData file true "Attachment file"
-// @Success 200 {object} model.AchievementResponse
-// @Router /achievements/{id}/attachments [post]
-func GetAchievementHistory(
+// @Success 200 {object} model.RevisionDiff
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievements/{id}/diff [get]
+func GetAchievementRevisionDiff(
 	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
 	refRepo *repository.AchievementReferenceRepository,
+	pol *policy.Policy,
 ) error {
 
 	id := c.Params("id")
 
-	history, err := refRepo.StatusHistory(id)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	achievement, err := achievementRepo.GetByID(id)
+	if err != nil || achievement == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
+	}
+
+	if _, err := pol.AuthorizeStudentNumber(c, achievement.StudentID, policy.View); err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	if history == nil {
-		return c.Status(404).JSON(fiber.Map{"error": "history not found"})
+	rejected, err := refRepo.GetLastRejectedRound(id)
+	if err != nil {
+		if err.Error() == "rejected round not found" {
+			return c.Status(404).JSON(fiber.Map{"error": "achievement has never been rejected"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{
 		"status": "success",
-		"data":   []model.AchievementStatusHistory{*history},
+		"data": model.RevisionDiff{
+			RejectedRound: rejected.Round,
+			RejectionNote: rejected.RejectionNote,
+			Reasons:       rejected.RejectionReasons,
+			FieldComments: rejected.FieldComments,
+			RejectedAt:    rejected.ReviewedAt,
+			CurrentStatus: achievement.Status,
+			Changes:       model.DiffSnapshots(rejected.Snapshot, model.SnapshotOf(achievement)),
+		},
 	})
 }
 
-// UploadAchievementAttachment godoc
-// @Summary Upload achievement attachment
-// @Description Upload file pendukung prestasi (PDF/JPG/PNG)
+// GetAchievementFeedback godoc
+// @Summary Get rejection feedback
+// @Description Umpan balik penolakan terakhir untuk mahasiswa: alasan yang dipilih dosen wali, komentar per field, dan catatan
 // @Tags Achievements
 // @Security BearerAuth
-// @Accept multipart/form-data
 // @Produce json
 // @Param id path string true "Achievement ID"
-// @Param file formData file true "Attachment file"
-// @Success 200 {object} map[string]interface{}
-// @Router /achievements/{id}/attachments [post]
-func UploadAchievementAttachment(
+// @Success 200 {object} model.AchievementFeedback
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievements/{id}/feedback [get]
+func GetAchievementFeedback(
 	c *fiber.Ctx,
 	achievementRepo *repository.AchievementRepository,
+	refRepo *repository.AchievementReferenceRepository,
+	reasonRepo *repository.RejectionReasonRepository,
+	pol *policy.Policy,
 ) error {
 
-	achievementID := c.Params("id")
+	id := c.Params("id")
 
-	// Ambil file
-	file, err := c.FormFile("file")
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "file is required")
+	achievement, err := achievementRepo.GetByID(id)
+	if err != nil || achievement == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
 	}
 
-	// Validasi ekstensi
-	allowedExt := map[string]bool{
-		".png": true,
-		".jpg": true,
-		".jpeg": true,
-		".pdf": true,
+	if _, err := pol.AuthorizeStudentNumber(c, achievement.StudentID, policy.View); err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if !allowedExt[ext] {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid file type")
+	rejected, err := refRepo.GetLastRejectedRound(id)
+	if err != nil {
+		if err.Error() == "rejected round not found" {
+			return c.Status(404).JSON(fiber.Map{"error": "achievement has never been rejected"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Folder upload
-	uploadDir := fmt.Sprintf("./uploads/achievements/%s", achievementID)
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	// alasan yang sudah dinonaktifkan tetap ditampilkan dengan labelnya
+	all, err := reasonRepo.GetAll(false)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	byCode := make(map[string]model.RejectionReason, len(all))
+	for _, reason := range all {
+		byCode[reason.Code] = reason
 	}
 
-	// Nama file unik
-	fileName := fmt.Sprintf("%d%s", time.Now().UnixNano(), ext)
-	filePath := filepath.Join(uploadDir, fileName)
+	feedback := model.AchievementFeedback{
+		Round:         rejected.Round,
+		Reasons:       []model.RejectionReason{},
+		FieldComments: rejected.FieldComments,
+		Note:          rejected.RejectionNote,
+		ReviewedAt:    rejected.ReviewedAt,
+	}
+	if feedback.FieldComments == nil {
+		feedback.FieldComments = []model.FieldComment{}
+	}
+	if rejected.ReviewedBy != nil {
+		reviewer := rejected.ReviewedBy.String()
+		feedback.ReviewedBy = &reviewer
+	}
+	for _, code := range rejected.RejectionReasons {
+		reason, ok := byCode[code]
+		if !ok {
+			reason = model.RejectionReason{Code: code, Label: code}
+		}
+		feedback.Reasons = append(feedback.Reasons, reason)
+	}
 
-	// Simpan file
-	if err := c.SaveFile(file, filePath); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   feedback,
+	})
+}
+
+// GetAchievementHistory godoc
+// @Summary Get achievement status history
+// @Description Riwayat lengkap perpindahan status prestasi (kronologis, dengan pagination)
+// @Tags Achievements
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param page query int false "Halaman (default 1)"
+// @Param limit query int false "Jumlah per halaman (default 20, maks 100)"
+// @Success 200 {array} model.AchievementStatusHistory
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievements/{id}/history [get]
+func GetAchievementHistory(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	refRepo *repository.AchievementReferenceRepository,
+	pol *policy.Policy,
+) error {
+
+	id := c.Params("id")
+
+	achievement, err := achievementRepo.GetByID(id)
+	if err != nil || achievement == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
 	}
 
-	attachment := model.Attachment{
-		FileName: file.Filename,
-		FilePath: filePath,
-		FileType: ext,
-		UploadedAt: time.Now(),
+	if _, err := pol.AuthorizeStudentNumber(c, achievement.StudentID, policy.View); err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Push attachment ke achievement
-	if err := achievementRepo.AddAttachment(achievementID, attachment); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	page, limit, offset := parsePagination(c)
+
+	history, total, err := refRepo.History(id, limit, offset)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "attachment uploaded successfully",
-		"data": attachment,
+		"status":     "success",
+		"data":       history,
+		"pagination": model.NewPagination(page, limit, total),
 	})
 }
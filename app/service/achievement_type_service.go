@@ -0,0 +1,388 @@
+package service
+
+import (
+	"strings"
+
+	"UAS/app/model"
+	"UAS/app/policy"
+	"UAS/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetAchievementTypes godoc
+// @Summary Get achievement type catalog
+// @Description Katalog jenis prestasi beserta aturan poinnya
+// @Tags Achievement Types
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} model.AchievementType
+// @Failure 401 {object} map[string]string
+// @Router /achievement-types [get]
+func GetAchievementTypes(c *fiber.Ctx, catalog *repository.AchievementTypeRepository) error {
+	types, err := catalog.GetTypes()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   types,
+	})
+}
+
+// GetAchievementType godoc
+// @Summary Get achievement type
+// @Description Satu jenis prestasi beserta aturan poinnya
+// @Tags Achievement Types
+// @Security BearerAuth
+// @Produce json
+// @Param code path string true "Type code"
+// @Success 200 {object} model.AchievementType
+// @Failure 404 {object} map[string]string
+// @Router /achievement-types/{code} [get]
+func GetAchievementType(c *fiber.Ctx, catalog *repository.AchievementTypeRepository) error {
+	t, err := catalog.GetType(c.Params("code"))
+	if err != nil {
+		if err.Error() == "achievement type not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   t,
+	})
+}
+
+// parseAchievementTypeInput memvalidasi payload katalog (nama wajib)
+func parseAchievementTypeInput(c *fiber.Ctx) (*model.AchievementType, string) {
+	var input model.AchievementTypeRequest
+	if err := c.BodyParser(&input); err != nil {
+		return nil, "invalid request body"
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, "name is required"
+	}
+
+	active := true
+	if input.IsActive != nil {
+		active = *input.IsActive
+	}
+
+	return &model.AchievementType{
+		Code:        strings.TrimSpace(input.Code),
+		Name:        name,
+		Description: strings.TrimSpace(input.Description),
+		IsActive:    active,
+	}, ""
+}
+
+// CreateAchievementType godoc
+// @Summary Create achievement type
+// @Description Menambahkan jenis prestasi ke katalog. Kode harus salah satu tipe yang memiliki schema details (competition, publication, certification, organization).
+// @Tags Achievement Types
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body model.AchievementTypeRequest true "Type payload"
+// @Success 201 {object} model.AchievementType
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /achievement-types [post]
+func CreateAchievementType(c *fiber.Ctx, catalog *repository.AchievementTypeRepository) error {
+	t, msg := parseAchievementTypeInput(c)
+	if t == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+
+	known := false
+	for _, code := range model.AchievementTypes() {
+		if code == t.Code {
+			known = true
+		}
+	}
+	if !known {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "code must be one of: " + strings.Join(model.AchievementTypes(), ", "),
+		})
+	}
+
+	if _, err := catalog.GetType(t.Code); err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "achievement type already exists"})
+	}
+
+	created, err := catalog.CreateType(t)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"data":   created,
+	})
+}
+
+// UpdateAchievementType godoc
+// @Summary Update achievement type
+// @Description Mengubah nama / deskripsi / status aktif jenis prestasi. Tipe nonaktif tidak bisa dipakai untuk prestasi baru.
+// @Tags Achievement Types
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param code path string true "Type code"
+// @Param body body model.AchievementTypeRequest true "Type payload"
+// @Success 200 {object} model.AchievementType
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievement-types/{code} [put]
+func UpdateAchievementType(c *fiber.Ctx, catalog *repository.AchievementTypeRepository) error {
+	t, msg := parseAchievementTypeInput(c)
+	if t == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	t.Code = c.Params("code")
+
+	updated, err := catalog.UpdateType(t)
+	if err != nil {
+		if err.Error() == "achievement type not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   updated,
+	})
+}
+
+// parsePointRuleInput memvalidasi payload aturan poin
+func parsePointRuleInput(c *fiber.Ctx) (*model.PointRule, string) {
+	var input model.PointRuleRequest
+	if err := c.BodyParser(&input); err != nil {
+		return nil, "invalid request body"
+	}
+
+	label := strings.TrimSpace(input.Label)
+	if label == "" {
+		return nil, "label is required"
+	}
+	if input.Points < 0 {
+		return nil, "points must not be negative"
+	}
+	if (input.RankFrom != nil && *input.RankFrom < 1) || (input.RankTo != nil && *input.RankTo < 1) {
+		return nil, "rankFrom and rankTo must be positive"
+	}
+	if input.RankFrom != nil && input.RankTo != nil && *input.RankTo < *input.RankFrom {
+		return nil, "rankTo must not be less than rankFrom"
+	}
+
+	var level *string
+	if input.Level != nil && strings.TrimSpace(*input.Level) != "" {
+		l := strings.ToLower(strings.TrimSpace(*input.Level))
+		level = &l
+	}
+
+	return &model.PointRule{
+		Level:    level,
+		RankFrom: input.RankFrom,
+		RankTo:   input.RankTo,
+		Label:    label,
+		Points:   input.Points,
+	}, ""
+}
+
+// recalculateAfterRuleChange menjadwalkan hitung ulang poin setelah aturan tipe code berubah
+func recalculateAfterRuleChange(c *fiber.Ctx, points *PointCalculator, code string) string {
+	actorID, _ := c.Locals("userID").(string)
+	points.Schedule(code, model.PointReasonRuleChange, actorID)
+	return "scheduled"
+}
+
+// CreatePointRule godoc
+// @Summary Create point rule
+// @Description Menambahkan aturan poin lalu menjadwalkan hitung ulang poin prestasi verified dengan tipe ini
+// @Tags Achievement Types
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param code path string true "Type code"
+// @Param body body model.PointRuleRequest true "Rule payload"
+// @Success 201 {object} model.PointRule
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievement-types/{code}/rules [post]
+func CreatePointRule(c *fiber.Ctx, catalog *repository.AchievementTypeRepository, points *PointCalculator) error {
+	code := c.Params("code")
+	if _, err := catalog.GetType(code); err != nil {
+		if err.Error() == "achievement type not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rule, msg := parsePointRuleInput(c)
+	if rule == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	rule.TypeCode = code
+
+	created, err := catalog.CreateRule(rule)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":        "success",
+		"data":          created,
+		"recalculation": recalculateAfterRuleChange(c, points, code),
+	})
+}
+
+// UpdatePointRule godoc
+// @Summary Update point rule
+// @Description Mengubah aturan poin lalu menjadwalkan hitung ulang poin prestasi verified dengan tipe ini
+// @Tags Achievement Types
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param code path string true "Type code"
+// @Param ruleId path string true "Rule ID (UUID)"
+// @Param body body model.PointRuleRequest true "Rule payload"
+// @Success 200 {object} model.PointRule
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievement-types/{code}/rules/{ruleId} [put]
+func UpdatePointRule(c *fiber.Ctx, catalog *repository.AchievementTypeRepository, points *PointCalculator) error {
+	ruleID, err := uuid.Parse(c.Params("ruleId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid rule ID"})
+	}
+
+	rule, msg := parsePointRuleInput(c)
+	if rule == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	rule.ID = ruleID
+	rule.TypeCode = c.Params("code")
+
+	updated, err := catalog.UpdateRule(rule)
+	if err != nil {
+		if err.Error() == "point rule not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":        "success",
+		"data":          updated,
+		"recalculation": recalculateAfterRuleChange(c, points, rule.TypeCode),
+	})
+}
+
+// DeletePointRule godoc
+// @Summary Delete point rule
+// @Description Menghapus aturan poin lalu menjadwalkan hitung ulang poin prestasi verified dengan tipe ini
+// @Tags Achievement Types
+// @Security BearerAuth
+// @Produce json
+// @Param code path string true "Type code"
+// @Param ruleId path string true "Rule ID (UUID)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievement-types/{code}/rules/{ruleId} [delete]
+func DeletePointRule(c *fiber.Ctx, catalog *repository.AchievementTypeRepository, points *PointCalculator) error {
+	ruleID, err := uuid.Parse(c.Params("ruleId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid rule ID"})
+	}
+
+	code := c.Params("code")
+	if err := catalog.DeleteRule(code, ruleID); err != nil {
+		if err.Error() == "point rule not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":        "success",
+		"recalculation": recalculateAfterRuleChange(c, points, code),
+	})
+}
+
+// RecalculateAchievementPoints godoc
+// @Summary Recalculate points
+// @Description Menjadwalkan hitung ulang poin seluruh prestasi verified dengan tipe ini di background (mis. setelah hitung ulang otomatis gagal)
+// @Tags Achievement Types
+// @Security BearerAuth
+// @Produce json
+// @Param code path string true "Type code"
+// @Success 202 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievement-types/{code}/recalculate [post]
+func RecalculateAchievementPoints(c *fiber.Ctx, catalog *repository.AchievementTypeRepository, points *PointCalculator) error {
+	code := c.Params("code")
+	if _, err := catalog.GetType(code); err != nil {
+		if err.Error() == "achievement type not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	actorID, _ := c.Locals("userID").(string)
+	points.Schedule(code, model.PointReasonManual, actorID)
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":  "success",
+		"message": "point recalculation scheduled",
+	})
+}
+
+// GetAchievementPointAudit godoc
+// @Summary Get achievement point audit
+// @Description Jejak perubahan poin prestasi (verifikasi dan hitung ulang karena aturan berubah)
+// @Tags Achievements
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Success 200 {array} model.AchievementPointAudit
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievements/{id}/points [get]
+func GetAchievementPointAudit(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	catalog *repository.AchievementTypeRepository,
+	pol *policy.Policy,
+) error {
+
+	id := c.Params("id")
+
+	achievement, err := achievementRepo.GetByID(id)
+	if err != nil || achievement == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
+	}
+
+	if _, err := pol.AuthorizeStudentNumber(c, achievement.StudentID, policy.View); err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	audit, err := catalog.GetPointAudit(id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"points": achievement.Points,
+		"data":   audit,
+	})
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/policy"
+	"UAS/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// recordVersion menyimpan snapshot isi prestasi setelah dibuat / diedit / dipulihkan.
+// Kegagalan hanya dicatat di log karena dokumen prestasinya sudah tersimpan.
+func recordVersion(
+	versionRepo *repository.AchievementVersionRepository,
+	achievement *model.Achievement,
+	action string,
+	editedBy string,
+	restoredFrom *int,
+) *model.AchievementVersion {
+	version, err := versionRepo.Create(&model.AchievementVersion{
+		AchievementID: achievement.ID.Hex(),
+		Action:        action,
+		RestoredFrom:  restoredFrom,
+		Snapshot:      model.SnapshotOf(achievement),
+		EditedBy:      editedBy,
+		EditedAt:      time.Now(),
+	})
+	if err != nil {
+		log.Println("❌ Failed to record achievement version:", achievement.ID.Hex(), err)
+		return nil
+	}
+	return version
+}
+
+// ensureBaselineVersion: prestasi yang dibuat sebelum versioning belum punya versi,
+// jadi isi lamanya disimpan dulu sebelum ditimpa
+func ensureBaselineVersion(versionRepo *repository.AchievementVersionRepository, achievement *model.Achievement) {
+	n, err := versionRepo.Count(achievement.ID.Hex())
+	if err != nil {
+		log.Println("❌ Failed to count achievement versions:", achievement.ID.Hex(), err)
+		return
+	}
+	if n == 0 {
+		recordVersion(versionRepo, achievement, model.VersionBaseline, "", nil)
+	}
+}
+
+// loadAuthorizedAchievement mengambil prestasi :id dan memastikan user boleh melakukan action.
+// Jika nil, response error sudah ditulis dan err adalah hasil penulisannya.
+func loadAuthorizedAchievement(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	pol *policy.Policy,
+	action policy.Action,
+) (*model.Achievement, error) {
+	achievement, err := achievementRepo.GetByID(c.Params("id"))
+	if err != nil || achievement == nil {
+		return nil, c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
+	}
+
+	if _, err := pol.AuthorizeStudentNumber(c, achievement.StudentID, action); err != nil {
+		return nil, c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// sama seperti detail: draft hanya boleh dilihat pemiliknya
+	if achievement.Status == string(model.StatusDraft) && action == policy.View {
+		if _, err := pol.AuthorizeStudentNumber(c, achievement.StudentID, policy.Modify); err != nil {
+			return nil, c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
+		}
+	}
+
+	return achievement, nil
+}
+
+// requireDraft memastikan prestasi masih draft menurut PostgreSQL (sumber kebenaran status).
+// Status di MongoDB bisa tertinggal kalau sinkronisasi setelah submit gagal.
+func requireDraft(c *fiber.Ctx, refRepo *repository.AchievementReferenceRepository, id, message string) (bool, error) {
+	ref, err := refRepo.GetByMongoID(id)
+	if err != nil {
+		if err.Error() == "achievement reference not found" {
+			return false, c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
+		}
+		return false, c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if !model.IsEditable(ref.CurrentStatus()) {
+		return false, c.Status(400).JSON(fiber.Map{"error": message})
+	}
+	return true, nil
+}
+
+// GetAchievementVersions godoc
+// @Summary List achievement versions
+// @Description Seluruh versi isi prestasi (snapshot setiap create / edit / restore), dari yang paling lama
+// @Tags Achievements
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Success 200 {array} model.AchievementVersion
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievements/{id}/versions [get]
+func GetAchievementVersions(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	versionRepo *repository.AchievementVersionRepository,
+	pol *policy.Policy,
+) error {
+
+	achievement, err := loadAuthorizedAchievement(c, achievementRepo, pol, policy.View)
+	if achievement == nil {
+		return err
+	}
+
+	versions, err := versionRepo.GetByAchievementID(achievement.ID.Hex())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   versions,
+	})
+}
+
+// GetAchievementVersion godoc
+// @Summary Get achievement version
+// @Description Mengambil satu versi isi prestasi
+// @Tags Achievements
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param version path int true "Version number"
+// @Success 200 {object} model.AchievementVersion
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievements/{id}/versions/{version} [get]
+func GetAchievementVersion(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	versionRepo *repository.AchievementVersionRepository,
+	pol *policy.Policy,
+) error {
+
+	number, err := c.ParamsInt("version")
+	if err != nil || number < 1 {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid version"})
+	}
+
+	achievement, err := loadAuthorizedAchievement(c, achievementRepo, pol, policy.View)
+	if achievement == nil {
+		return err
+	}
+
+	version, err := versionRepo.GetVersion(achievement.ID.Hex(), number)
+	if err != nil {
+		if err.Error() == "version not found" {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   version,
+	})
+}
+
+// DiffAchievementVersions godoc
+// @Summary Diff two achievement versions
+// @Description Perbandingan per field antara dua versi (termasuk setiap key details dan tags)
+// @Tags Achievements
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param from query int true "Versi awal"
+// @Param to query int true "Versi akhir"
+// @Success 200 {object} model.VersionDiff
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievements/{id}/versions/diff [get]
+func DiffAchievementVersions(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	versionRepo *repository.AchievementVersionRepository,
+	pol *policy.Policy,
+) error {
+
+	from := c.QueryInt("from")
+	to := c.QueryInt("to")
+	if from < 1 || to < 1 {
+		return c.Status(400).JSON(fiber.Map{"error": "from and to versions are required"})
+	}
+
+	achievement, err := loadAuthorizedAchievement(c, achievementRepo, pol, policy.View)
+	if achievement == nil {
+		return err
+	}
+
+	id := achievement.ID.Hex()
+	older, err := versionRepo.GetVersion(id, from)
+	if err == nil {
+		var newer *model.AchievementVersion
+		newer, err = versionRepo.GetVersion(id, to)
+		if err == nil {
+			return c.JSON(fiber.Map{
+				"status": "success",
+				"data": model.VersionDiff{
+					From:    from,
+					To:      to,
+					Changes: model.DiffSnapshots(older.Snapshot, newer.Snapshot),
+				},
+			})
+		}
+	}
+
+	if err.Error() == "version not found" {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+}
+
+// RestoreAchievementVersion godoc
+// @Summary Restore achievement version
+// @Description Mengembalikan isi prestasi draft ke versi lama (lampiran tidak ikut dipulihkan). Restore dicatat sebagai versi baru.
+// @Tags Achievements
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param version path int true "Version number"
+// @Success 200 {object} model.AchievementResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievements/{id}/versions/{version}/restore [post]
+func RestoreAchievementVersion(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	refRepo *repository.AchievementReferenceRepository,
+	versionRepo *repository.AchievementVersionRepository,
+	catalog *repository.AchievementTypeRepository,
+	pol *policy.Policy,
+) error {
+
+	number, err := c.ParamsInt("version")
+	if err != nil || number < 1 {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid version"})
+	}
+
+	achievement, err := loadAuthorizedAchievement(c, achievementRepo, pol, policy.Modify)
+	if achievement == nil {
+		return err
+	}
+
+	id := achievement.ID.Hex()
+	if ok, err := requireDraft(c, refRepo, id, "only draft can be restored"); !ok {
+		return err
+	}
+
+	version, err := versionRepo.GetVersion(id, number)
+	if err != nil {
+		if err.Error() == "version not found" {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	actor, err := pol.Actor(c)
+	if err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// versi lama bisa memakai tipe yang sudah dinonaktifkan / details yang ditolak schema saat ini
+	fields, fieldErrs := model.ValidateAchievementUpdate(model.RestoreInput(version.Snapshot), achievement)
+	if len(fieldErrs) == 0 {
+		fieldErrs = checkTypeActive(catalog, fields)
+	}
+	if len(fieldErrs) > 0 {
+		return validationError(c, fieldErrs)
+	}
+
+	ensureBaselineVersion(versionRepo, achievement)
+
+	updated, err := achievementRepo.Update(id, fields)
+	if errors.Is(err, repository.ErrNotDraft) {
+		return c.Status(400).JSON(fiber.Map{"error": "only draft can be restored"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if updated == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
+	}
+
+	recordVersion(versionRepo, updated, model.VersionRestore, actor.UserID, &number)
+
+	return c.JSON(fiber.Map{"status": "success", "achievement": updated})
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/policy"
+	"UAS/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var ErrAchievementNotFound = errors.New("achievement not found")
+
+// transitionActions: siapa yang boleh memicu event (lihat policy.Actor.Can)
+var transitionActions = map[model.AchievementEvent]policy.Action{
+	model.EventSubmit: policy.Modify,
+	model.EventRevise: policy.Modify,
+	model.EventDelete: policy.Modify,
+	model.EventVerify: policy.Verify,
+	model.EventReject: policy.Verify,
+}
+
+// AchievementWorkflow adalah state machine prestasi. Semua perpindahan status
+// lewat Transition: cek aturan & hak akses, simpan ke PostgreSQL + outbox dalam
+// satu transaksi, terapkan ke MongoDB, lalu pancarkan AchievementTransition.
+type AchievementWorkflow struct {
+	Achievements *repository.AchievementRepository
+	Refs         *repository.AchievementReferenceRepository
+	Dispatcher   *OutboxDispatcher
+	Policy       *policy.Policy
+	Points       *PointCalculator
+	Reasons      *repository.RejectionReasonRepository
+
+	mu        sync.RWMutex
+	listeners []func(model.AchievementTransition)
+}
+
+func NewAchievementWorkflow(
+	achievements *repository.AchievementRepository,
+	refs *repository.AchievementReferenceRepository,
+	dispatcher *OutboxDispatcher,
+	pol *policy.Policy,
+	points *PointCalculator,
+	reasons *repository.RejectionReasonRepository,
+) *AchievementWorkflow {
+	return &AchievementWorkflow{
+		Achievements: achievements,
+		Refs:         refs,
+		Dispatcher:   dispatcher,
+		Policy:       pol,
+		Points:       points,
+		Reasons:      reasons,
+	}
+}
+
+// OnTransition mendaftarkan listener yang dipanggil setelah transisi tersimpan
+func (w *AchievementWorkflow) OnTransition(fn func(model.AchievementTransition)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.listeners = append(w.listeners, fn)
+}
+
+func (w *AchievementWorkflow) emit(t model.AchievementTransition) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, fn := range w.listeners {
+		fn(t)
+	}
+}
+
+// Transition memindahkan status prestasi id karena event yang dipicu actor
+func (w *AchievementWorkflow) Transition(
+	ctx context.Context,
+	id string,
+	actor *policy.Actor,
+	event model.AchievementEvent,
+	payload model.TransitionPayload,
+) (*model.Achievement, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	achievement, err := w.Achievements.GetByID(id)
+	if err != nil || achievement == nil {
+		return nil, ErrAchievementNotFound
+	}
+
+	// status di PostgreSQL adalah sumber kebenaran (MongoDB bisa tertinggal)
+	ref, err := w.Refs.GetByMongoID(id)
+	if err != nil {
+		if err.Error() == "achievement reference not found" {
+			return nil, ErrAchievementNotFound
+		}
+		return nil, err
+	}
+
+	action, ok := transitionActions[event]
+	if !ok {
+		return nil, &model.InvalidTransitionError{Event: event, From: ref.CurrentStatus()}
+	}
+
+	student, err := w.Policy.Students.GetByStudentNumber(achievement.StudentID)
+	if err != nil {
+		if err.Error() == "student not found" {
+			return nil, policy.ErrStudentNotFound
+		}
+		return nil, err
+	}
+	if !actor.Can(action, student) {
+		return nil, policy.ErrForbidden
+	}
+
+	from := ref.CurrentStatus()
+	to, err := model.NextStatus(from, event)
+	if err != nil {
+		return nil, err
+	}
+
+	t := model.AchievementTransition{
+		AchievementID: id,
+		StudentID:     achievement.StudentID,
+		Event:         event,
+		From:          from,
+		To:            to,
+		ActorID:       actor.UserID,
+		Note:          payload.Note,
+		At:            time.Now(),
+	}
+	if event == model.EventSubmit {
+		snapshot := model.SnapshotOf(achievement)
+		t.Snapshot = &snapshot
+	}
+	switch event {
+	case model.EventVerify:
+		if errs := model.ValidateVerifyPayload(payload); len(errs) > 0 {
+			return nil, &model.ValidationError{Errors: errs}
+		}
+		award, err := w.Points.Award(achievement, ref.Points)
+		if err != nil {
+			return nil, err
+		}
+		if payload.Points != nil {
+			award.Calculated = award.Points
+			award.Points = *payload.Points
+			award.Overridden = true
+			award.Justification = strings.TrimSpace(payload.Justification)
+		}
+		t.Award = &award
+
+	case model.EventReject:
+		active, err := w.Reasons.ActiveCodes()
+		if err != nil {
+			return nil, err
+		}
+		if errs := model.ValidateRejectPayload(payload, achievement, active); len(errs) > 0 {
+			return nil, &model.ValidationError{Errors: errs}
+		}
+		t.Reasons = payload.Reasons
+		for _, fc := range payload.FieldComments {
+			t.FieldComments = append(t.FieldComments, model.FieldComment{
+				Field:   fc.Field,
+				Comment: strings.TrimSpace(fc.Comment),
+			})
+		}
+	}
+
+	if err := w.Refs.ApplyTransition(t); err != nil {
+		return nil, err
+	}
+
+	updated := syncAchievement(w.Achievements, w.Dispatcher, achievement, to)
+	if t.Award != nil {
+		updated.Points = t.Award.Points
+	}
+	w.emit(t)
+
+	return updated, nil
+}
+
+// transitionError memetakan error Transition ke response HTTP
+func transitionError(c *fiber.Ctx, err error) error {
+	var invalid *model.InvalidTransitionError
+	var validation *model.ValidationError
+	switch {
+	case errors.As(err, &validation):
+		return validationError(c, validation.Errors)
+	case errors.Is(err, ErrAchievementNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.As(err, &invalid):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repository.ErrTransitionConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, policy.ErrForbidden), errors.Is(err, policy.ErrStudentNotFound), errors.Is(err, policy.ErrUnauthorized):
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// LogTransition adalah listener sederhana untuk jejak audit di log aplikasi
+func LogTransition(t model.AchievementTransition) {
+	log.Printf("📝 Achievement %s: %s -> %s (%s by %s)\n", t.AchievementID, t.From, t.To, t.Event, t.ActorID)
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+	"UAS/app/scanner"
+	"UAS/app/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// UploadError adalah penolakan upload yang dikembalikan ke client dengan status tertentu
+type UploadError struct {
+	Status  int
+	Message string
+}
+
+func (e *UploadError) Error() string {
+	return e.Message
+}
+
+func uploadError(status int, format string, args ...interface{}) *UploadError {
+	return &UploadError{Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+// AttachmentManager mengelola lampiran prestasi: upload, penggantian dan penghapusan.
+// Isi file divalidasi (magic bytes, ukuran, checksum, duplikat) dan dipindai sebelum
+// disimpan; file yang ditandai scanner dikarantina. Pratinjau dibuat oleh Previews
+// (boleh nil) setelah lampiran tersimpan.
+type AttachmentManager struct {
+	Achievements      *repository.AchievementRepository
+	Store             storage.Storage
+	Scanner           scanner.Scanner
+	Quarantine        *repository.QuarantineRepository
+	Previews          *PreviewWorker
+	MaxBytes          int64
+	MaxPerAchievement int
+}
+
+func NewAttachmentManager(
+	achievements *repository.AchievementRepository,
+	store storage.Storage,
+	scan scanner.Scanner,
+	quarantine *repository.QuarantineRepository,
+	previews *PreviewWorker,
+	maxBytes int64,
+	maxPerAchievement int,
+) *AttachmentManager {
+	return &AttachmentManager{
+		Achievements:      achievements,
+		Store:             store,
+		Scanner:           scan,
+		Quarantine:        quarantine,
+		Previews:          previews,
+		MaxBytes:          maxBytes,
+		MaxPerAchievement: maxPerAchievement,
+	}
+}
+
+// Upload menyimpan file sebagai lampiran baru prestasi
+func (m *AttachmentManager) Upload(ctx context.Context, achievement *model.Achievement, file *multipart.FileHeader, actorID string) (*model.Attachment, error) {
+	if len(achievement.Attachments) >= m.MaxPerAchievement {
+		return nil, uploadError(fiber.StatusConflict, "attachment limit reached (max %d per achievement)", m.MaxPerAchievement)
+	}
+
+	attachment, err := m.accept(ctx, achievement, file, actorID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Achievements.AddAttachment(achievement.ID.Hex(), *attachment, m.MaxPerAchievement); err != nil {
+		m.deleteObject(ctx, attachment.StorageKey)
+		if err.Error() == "attachment conflict" {
+			return nil, uploadError(fiber.StatusConflict, "attachment limit reached or file already attached")
+		}
+		return nil, err
+	}
+
+	m.Previews.Enqueue(achievement.ID.Hex(), *attachment)
+	return attachment, nil
+}
+
+// Replace mengganti file sebuah lampiran; ID lampiran tetap sama, file lama dihapus
+func (m *AttachmentManager) Replace(ctx context.Context, achievement *model.Achievement, attachmentID string, file *multipart.FileHeader, actorID string) (*model.Attachment, error) {
+	if _, ok := achievement.FindAttachment(attachmentID); !ok {
+		return nil, uploadError(fiber.StatusNotFound, "attachment not found")
+	}
+
+	attachment, err := m.accept(ctx, achievement, file, actorID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := m.Achievements.ReplaceAttachment(achievement.ID.Hex(), *attachment)
+	if err != nil {
+		m.deleteObject(ctx, attachment.StorageKey)
+		if err.Error() == "attachment not found" {
+			return nil, uploadError(fiber.StatusNotFound, "attachment not found")
+		}
+		return nil, err
+	}
+
+	m.Previews.Enqueue(achievement.ID.Hex(), *attachment)
+	m.cleanup(ctx, old)
+	return attachment, nil
+}
+
+// Remove menghapus lampiran beserta file-nya di storage
+func (m *AttachmentManager) Remove(ctx context.Context, achievement *model.Achievement, attachmentID string) (*model.Attachment, error) {
+	removed, err := m.Achievements.RemoveAttachment(achievement.ID.Hex(), attachmentID)
+	if err != nil {
+		if err.Error() == "attachment not found" {
+			return nil, uploadError(fiber.StatusNotFound, "attachment not found")
+		}
+		return nil, err
+	}
+
+	m.cleanup(ctx, removed)
+	return removed, nil
+}
+
+// cleanup menghapus file lampiran yang sudah tidak dirujuk beserta thumbnail-nya
+func (m *AttachmentManager) cleanup(ctx context.Context, attachment *model.Attachment) {
+	if attachment == nil {
+		return
+	}
+	m.deleteObject(ctx, attachment.Key())
+	for _, key := range attachment.PreviewKeys() {
+		m.deleteObject(ctx, key)
+	}
+}
+
+// deleteObject: kegagalan hanya dicatat karena data di MongoDB sudah benar dan
+// file yatim tidak bisa diakses lewat API
+func (m *AttachmentManager) deleteObject(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	if err := m.Store.Delete(ctx, key); err != nil {
+		log.Printf("❌ Failed to delete stored attachment %s: %v\n", key, err)
+	}
+}
+
+// accept memvalidasi, memindai dan menyimpan file ke storage, lalu mengembalikan
+// lampiran yang siap disimpan ke MongoDB. replacing = ID lampiran yang diganti
+// (tidak dihitung sebagai duplikat dan ID-nya dipakai ulang).
+func (m *AttachmentManager) accept(ctx context.Context, achievement *model.Achievement, file *multipart.FileHeader, actorID, replacing string) (*model.Attachment, error) {
+	achievementID := achievement.ID.Hex()
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if file.Size > m.MaxBytes {
+		return nil, uploadError(fiber.StatusRequestEntityTooLarge, "file exceeds the %d byte limit", m.MaxBytes)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, uploadError(fiber.StatusBadRequest, "%s", err.Error())
+	}
+	defer src.Close()
+
+	// Magic bytes harus cocok dengan ekstensi
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, uploadError(fiber.StatusBadRequest, "file is empty")
+	}
+	contentType, err := model.SniffAttachment(ext, head[:n])
+	if err != nil {
+		if errors.Is(err, model.ErrUnsupportedFileType) {
+			return nil, uploadError(fiber.StatusBadRequest, "invalid file type, allowed: %s", strings.Join(model.AttachmentExtensions(), ", "))
+		}
+		return nil, uploadError(fiber.StatusBadRequest, "%s", err.Error())
+	}
+
+	// Checksum (sekaligus memastikan ukuran sebenarnya, bukan yang diklaim header)
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, io.LimitReader(src, m.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > m.MaxBytes {
+		return nil, uploadError(fiber.StatusRequestEntityTooLarge, "file exceeds the %d byte limit", m.MaxBytes)
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+
+	for _, existing := range achievement.Attachments {
+		if (replacing == "" || existing.ID != replacing) && existing.Checksum == checksum {
+			return nil, uploadError(fiber.StatusConflict, "this file is already attached as %q", existing.FileName)
+		}
+	}
+
+	// Malware scan; scanner yang tidak bisa dihubungi = upload ditolak (fail closed)
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	result, err := m.Scanner.Scan(ctx, src)
+	if err != nil {
+		log.Println("❌ Attachment scan failed:", err)
+		return nil, uploadError(fiber.StatusServiceUnavailable, "malware scan is unavailable, please try again later")
+	}
+
+	// nama file di storage selalu baru, supaya file lama tidak tertimpa saat diganti
+	name := uuid.NewString() + ext
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if !result.Clean {
+		m.quarantine(ctx, achievementID, name, file.Filename, src, size, checksum, result.Signature, actorID)
+		return nil, uploadError(fiber.StatusUnprocessableEntity, "file was flagged by the malware scanner and has been quarantined")
+	}
+
+	key := storage.AttachmentKey(achievementID, name)
+	if err := m.Store.Put(ctx, key, src, size, contentType); err != nil {
+		return nil, err
+	}
+
+	id := replacing
+	if id == "" {
+		id = uuid.NewString()
+	}
+
+	attachment := &model.Attachment{
+		ID:          id,
+		FileName:    path.Base(file.Filename),
+		StorageKey:  key,
+		FileType:    ext,
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    checksum,
+		UploadedAt:  time.Now(),
+	}
+	if m.Previews != nil && attachment.NeedsPreview() {
+		attachment.Preview = &model.AttachmentPreview{Status: model.PreviewPending}
+	}
+
+	return attachment, nil
+}
+
+// quarantine menyimpan file yang ditandai di prefix quarantine/ dan mencatatnya untuk ditinjau admin
+func (m *AttachmentManager) quarantine(ctx context.Context, achievementID, name, fileName string, r io.Reader, size int64, checksum, signature, actorID string) {
+	key := path.Join("quarantine", achievementID, name)
+	if err := m.Store.Put(ctx, key, r, size, "application/octet-stream"); err != nil {
+		log.Println("❌ Failed to store quarantined file:", err)
+		return
+	}
+
+	record := &model.AttachmentQuarantine{
+		AchievementID: achievementID,
+		StorageKey:    key,
+		FileName:      fileName,
+		Size:          size,
+		Checksum:      checksum,
+		Signature:     signature,
+	}
+	if uid, err := uuid.Parse(actorID); err == nil {
+		record.UploadedBy = &uid
+	}
+	if err := m.Quarantine.Create(record); err != nil {
+		log.Println("❌ Failed to record quarantined file:", err)
+		return
+	}
+
+	log.Printf("⚠️ Quarantined upload for achievement %s: %s (%s)\n", achievementID, signature, key)
+}
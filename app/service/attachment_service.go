@@ -0,0 +1,324 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/policy"
+	"UAS/app/repository"
+	"UAS/app/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AttachmentLinkTTL adalah masa berlaku link sementara lampiran (diset dari config)
+var AttachmentLinkTTL = 15 * time.Minute
+
+// loadDraftForAttachments: lampiran hanya boleh diubah pemilik prestasi, dan hanya saat draft.
+// Jika nil, response error sudah ditulis dan err adalah hasil penulisannya.
+func loadDraftForAttachments(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	refRepo *repository.AchievementReferenceRepository,
+	pol *policy.Policy,
+) (*model.Achievement, string, error) {
+	achievement, err := loadAuthorizedAchievement(c, achievementRepo, pol, policy.Modify)
+	if achievement == nil {
+		return nil, "", err
+	}
+
+	if ok, err := requireDraft(c, refRepo, achievement.ID.Hex(), errAttachmentsDraftOnly); !ok {
+		return nil, "", err
+	}
+
+	actor, err := pol.Actor(c)
+	if err != nil {
+		return nil, "", c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return achievement, actor.UserID, nil
+}
+
+const errAttachmentsDraftOnly = "attachments can only be changed while the achievement is a draft"
+
+// attachmentError memetakan error AttachmentManager ke response HTTP
+func attachmentError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, repository.ErrNotDraft) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errAttachmentsDraftOnly})
+	}
+	var rejected *UploadError
+	if errors.As(err, &rejected) {
+		return c.Status(rejected.Status).JSON(fiber.Map{"error": rejected.Message})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// UploadAchievementAttachment godoc
+// @Summary Upload achievement attachment
+// @Description Pemilik menambah file pendukung prestasi draft (PDF/JPG/PNG). Isi file dicek dengan magic bytes, dibatasi ukuran & jumlahnya, diberi checksum SHA-256 (file yang sama ditolak), lalu dipindai malware.
+// @Tags Achievements
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param file formData file true "Attachment file"
+// @Success 200 {object} model.Attachment
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /achievements/{id}/attachments [post]
+func UploadAchievementAttachment(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	refRepo *repository.AchievementReferenceRepository,
+	attachments *AttachmentManager,
+	pol *policy.Policy,
+) error {
+
+	achievement, actorID, err := loadDraftForAttachments(c, achievementRepo, refRepo, pol)
+	if achievement == nil {
+		return err
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+	}
+
+	attachment, err := attachments.Upload(c.UserContext(), achievement, file, actorID)
+	if err != nil {
+		return attachmentError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "attachment uploaded successfully",
+		"data":    attachment,
+	})
+}
+
+// ReplaceAchievementAttachment godoc
+// @Summary Replace achievement attachment
+// @Description Mengganti file sebuah lampiran (prestasi draft, pemilik saja). ID lampiran tetap; file lama dihapus dari storage. Validasi sama dengan upload.
+// @Tags Achievements
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Param file formData file true "Attachment file"
+// @Success 200 {object} model.Attachment
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /achievements/{id}/attachments/{attachmentId} [put]
+func ReplaceAchievementAttachment(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	refRepo *repository.AchievementReferenceRepository,
+	attachments *AttachmentManager,
+	pol *policy.Policy,
+) error {
+
+	achievement, actorID, err := loadDraftForAttachments(c, achievementRepo, refRepo, pol)
+	if achievement == nil {
+		return err
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+	}
+
+	attachment, err := attachments.Replace(c.UserContext(), achievement, c.Params("attachmentId"), file, actorID)
+	if err != nil {
+		return attachmentError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "attachment replaced successfully",
+		"data":    attachment,
+	})
+}
+
+// DeleteAchievementAttachment godoc
+// @Summary Delete achievement attachment
+// @Description Menghapus lampiran prestasi draft beserta file-nya di storage (pemilik saja)
+// @Tags Achievements
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievements/{id}/attachments/{attachmentId} [delete]
+func DeleteAchievementAttachment(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	refRepo *repository.AchievementReferenceRepository,
+	attachments *AttachmentManager,
+	pol *policy.Policy,
+) error {
+
+	achievement, _, err := loadDraftForAttachments(c, achievementRepo, refRepo, pol)
+	if achievement == nil {
+		return err
+	}
+
+	if _, err := attachments.Remove(c.UserContext(), achievement, c.Params("attachmentId")); err != nil {
+		return attachmentError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "attachment deleted successfully"})
+}
+
+// DownloadAchievementAttachment godoc
+// @Summary Download achievement attachment
+// @Description Mengunduh lampiran prestasi (mendukung header Range). Akses sama dengan detail prestasi: pemilik, dosen wali, atau admin; draft hanya pemilik. ?inline=true untuk ditampilkan di browser.
+// @Tags Achievements
+// @Security BearerAuth
+// @Produce octet-stream
+// @Param id path string true "Achievement ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Param inline query bool false "Content-Disposition inline"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 416 {object} map[string]string
+// @Router /achievements/{id}/attachments/{attachmentId} [get]
+func DownloadAchievementAttachment(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	store storage.Storage,
+	pol *policy.Policy,
+) error {
+
+	achievement, err := loadAuthorizedAchievement(c, achievementRepo, pol, policy.View)
+	if achievement == nil {
+		return err
+	}
+
+	attachment, ok := achievement.FindAttachment(c.Params("attachmentId"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "attachment not found"})
+	}
+
+	return serveObject(c, store, attachment.Key(), attachment.FileName, attachment.ContentType, c.QueryBool("inline", false))
+}
+
+// GetAchievementAttachmentThumbnail godoc
+// @Summary Get attachment thumbnail
+// @Description Thumbnail JPEG lampiran gambar (small / medium) yang dibuat di background setelah upload; URL-nya ada di attachments[].preview.thumbnails. Akses sama dengan download lampiran. 404 selama pratinjau belum siap.
+// @Tags Achievements
+// @Security BearerAuth
+// @Produce jpeg
+// @Param id path string true "Achievement ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Param size path string true "Ukuran thumbnail" Enums(small, medium)
+// @Success 200 {file} file
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievements/{id}/attachments/{attachmentId}/thumbnails/{size} [get]
+func GetAchievementAttachmentThumbnail(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	store storage.Storage,
+	pol *policy.Policy,
+) error {
+
+	achievement, err := loadAuthorizedAchievement(c, achievementRepo, pol, policy.View)
+	if achievement == nil {
+		return err
+	}
+
+	attachment, ok := achievement.FindAttachment(c.Params("attachmentId"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "attachment not found"})
+	}
+
+	thumb, ok := attachment.FindThumbnail(c.Params("size"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "thumbnail not available"})
+	}
+
+	name := strings.TrimSuffix(attachment.FileName, filepath.Ext(attachment.FileName)) + "-" + thumb.Size + ".jpg"
+	return serveObject(c, store, thumb.StorageKey, name, "image/jpeg", true)
+}
+
+// GetAchievementAttachmentLink godoc
+// @Summary Get short-lived attachment link
+// @Description Link sementara (tanpa token login) untuk menampilkan lampiran di frontend, mis. <img> atau viewer PDF. Berlaku sesuai STORAGE_URL_TTL.
+// @Tags Achievements
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /achievements/{id}/attachments/{attachmentId}/link [get]
+func GetAchievementAttachmentLink(
+	c *fiber.Ctx,
+	achievementRepo *repository.AchievementRepository,
+	store storage.Storage,
+	pol *policy.Policy,
+) error {
+
+	achievement, err := loadAuthorizedAchievement(c, achievementRepo, pol, policy.View)
+	if achievement == nil {
+		return err
+	}
+
+	attachment, ok := achievement.FindAttachment(c.Params("attachmentId"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "attachment not found"})
+	}
+
+	url, err := store.PresignedURL(c.UserContext(), attachment.Key(), AttachmentLinkTTL)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"url":       url,
+			"expiresAt": time.Now().Add(AttachmentLinkTTL),
+		},
+	})
+}
+
+// GetQuarantinedAttachments godoc
+// @Summary List quarantined attachments
+// @Description Upload yang ditandai malware scanner (terbaru lebih dulu). File disimpan di prefix quarantine/ dan tidak pernah dilampirkan.
+// @Tags Attachments
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Halaman (default 1)"
+// @Param limit query int false "Jumlah per halaman (default 20, maks 100)"
+// @Success 200 {array} model.AttachmentQuarantine
+// @Failure 403 {object} map[string]string
+// @Router /attachments/quarantine [get]
+func GetQuarantinedAttachments(c *fiber.Ctx, quarantine *repository.QuarantineRepository) error {
+	page, limit, offset := parsePagination(c)
+
+	items, total, err := quarantine.GetAll(limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"data":       items,
+		"pagination": model.NewPagination(page, limit, total),
+	})
+}
@@ -1,18 +1,26 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
 	"UAS/app/model"
 	"UAS/app/repository"
 	"UAS/app/utils"
 
 	"github.com/gofiber/fiber/v2"
-
-	"golang.org/x/crypto/bcrypt"
+	"github.com/google/uuid"
 )
 
 // Login godoc
 // @Summary Login user
-// @Description Login menggunakan email dan password untuk mendapatkan JWT token
+// @Description Login menggunakan email dan password untuk mendapatkan access token dan refresh token. Jika 2FA aktif / diwajibkan role, response berisi status "2fa_required" dan challengeToken untuk /auth/login/2fa.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -21,15 +29,35 @@ import (
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]interface{}
 // @Router /auth/login [post]
-func Login(c *fiber.Ctx, repo *repository.UserRepository) error {
+func Login(
+	c *fiber.Ctx,
+	repo *repository.UserRepository,
+	refreshRepo *repository.RefreshTokenRepository,
+	attemptRepo *repository.LoginAttemptRepository,
+	tfRepo *repository.TwoFactorRepository,
+) error {
 	var req model.LoginRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
 	}
 
-	user, err := repo.GetUserByEmail(req.Email)
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	ip := c.IP()
+
+	// Tolak lebih awal jika akun / IP sedang back-off atau terkunci
+	wait, err := loginRetryAfter(attemptRepo, email, ip)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "gagal memeriksa percobaan login"})
+	}
+	if wait > 0 {
+		return tooManyLoginAttempts(c, wait)
+	}
+
+	user, err := repo.GetUserByEmail(email)
 	if err != nil {
+		recordLoginFailure(attemptRepo, email, ip)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "email atau password salah"})
 	}
 
@@ -37,74 +65,228 @@ func Login(c *fiber.Ctx, repo *repository.UserRepository) error {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "akun tidak aktif"})
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+	ok, newHash := utils.CheckPasswordAndRehash(user.Password, req.Password)
+	if !ok {
+		recordLoginFailure(attemptRepo, email, ip)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "email atau password salah"})
 	}
 
-	perms, err := repo.GetPermissionsByUserID(user.ID.String())
+	// Cost bcrypt di config naik -> simpan hash baru secara transparan
+	if newHash != "" {
+		if err := repo.UpdatePasswordHash(user.ID, newHash); err != nil {
+			log.Println("❌ Failed to upgrade password hash:", err)
+		}
+	}
+
+	// Two-factor authentication: password benar tapi sesi baru dibuat setelah kode TOTP.
+	// Hitungan gagal akun belum di-reset di sini; kode 2FA yang salah ikut dihitung,
+	// dan akun yang terkunci sudah ditolak di atas sebelum challenge baru dibuat.
+	purpose, err := twoFactorChallengePurpose(tfRepo, user)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "gagal mengambil permissions"})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "gagal memeriksa 2FA"})
+	}
+	if purpose != "" {
+		challengeToken, hash, err := utils.GenerateOpaqueToken()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "gagal generate challenge"})
+		}
+		if err := tfRepo.CreateChallenge(user.ID, purpose, hash, time.Now().Add(loginChallengeTTL)); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "gagal menyimpan challenge"})
+		}
+
+		return c.JSON(fiber.Map{
+			"status": "2fa_required",
+			"data": fiber.Map{
+				"challengeToken": challengeToken,
+				"method":         purpose,
+				"expiresIn":      int(loginChallengeTTL.Seconds()),
+			},
+		})
 	}
 
-	token, err := utils.GenerateJWT(user.ID.String(), user.RoleID.String(), perms)
+	// Login berhasil -> hitungan gagal akun di-reset (hitungan IP tetap)
+	_ = attemptRepo.Reset(model.LoginKeyAccount, email)
+
+	token, refreshToken, err := issueSession(repo, refreshRepo, user)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "gagal generate token"})
 	}
 
 	return c.JSON(fiber.Map{
 		"status": "success",
-		"data": fiber.Map{
-			"token": token,
-			"user": fiber.Map{
-				"id":       user.ID,
-				"username": user.Username,
-				"fullName": user.FullName,
-				"roleId":   user.RoleID,
-			},
+		"data":   sessionData(token, refreshToken, user),
+	})
+}
+
+// sessionData adalah isi response login yang berhasil
+func sessionData(token, refreshToken string, user *model.User) fiber.Map {
+	return fiber.Map{
+		"token":        token,
+		"refreshToken": refreshToken,
+		"expiresIn":    int(utils.AccessTokenTTL.Seconds()),
+		"user": fiber.Map{
+			"id":       user.ID,
+			"username": user.Username,
+			"fullName": user.FullName,
+			"roleId":   user.RoleID,
 		},
+	}
+}
+
+// issueSession membuat access token dan refresh token (family baru) untuk user
+func issueSession(repo *repository.UserRepository, refreshRepo *repository.RefreshTokenRepository, user *model.User) (token string, refreshToken string, err error) {
+	perms, err := repo.GetPermissionsByUserID(user.ID.String())
+	if err != nil {
+		return "", "", err
+	}
+
+	tokenVersion, err := repo.GetTokenVersion(user.ID.String())
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err = utils.GenerateJWT(user.ID.String(), user.RoleID.String(), perms, tokenVersion)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := refreshRepo.Create(user.ID, uuid.New(), hash, time.Now().Add(utils.RefreshTokenTTL)); err != nil {
+		return "", "", err
+	}
+
+	return token, refreshToken, nil
+}
+
+// tooManyLoginAttempts adalah response 429 dengan header Retry-After
+func tooManyLoginAttempts(c *fiber.Ctx, wait time.Duration) error {
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":      "terlalu banyak percobaan login, coba lagi nanti",
+		"retryAfter": retryAfter,
 	})
 }
 
+// loginRetryAfter mengambil waktu tunggu terlama antara key akun dan key IP
+func loginRetryAfter(attemptRepo *repository.LoginAttemptRepository, email, ip string) (time.Duration, error) {
+	accountWait, err := attemptRepo.RetryAfter(model.LoginKeyAccount, email)
+	if err != nil {
+		return 0, err
+	}
+	ipWait, err := attemptRepo.RetryAfter(model.LoginKeyIP, ip)
+	if err != nil {
+		return 0, err
+	}
+	if ipWait > accountWait {
+		return ipWait, nil
+	}
+	return accountWait, nil
+}
+
+// recordLoginFailure mencatat login gagal untuk akun dan IP
+func recordLoginFailure(attemptRepo *repository.LoginAttemptRepository, email, ip string) {
+	if _, err := attemptRepo.RecordFailure(model.LoginKeyAccount, email); err != nil {
+		log.Println("❌ Failed to record login failure:", err)
+	}
+	if _, err := attemptRepo.RecordFailure(model.LoginKeyIP, ip); err != nil {
+		log.Println("❌ Failed to record login failure:", err)
+	}
+}
+
 // RefreshToken godoc
 // @Summary Refresh JWT token
-// @Description Generate access token baru menggunakan refresh token
+// @Description Tukar refresh token dengan access token baru. Refresh token selalu dirotasi; token lama yang dipakai ulang akan mencabut seluruh sesi (family) tersebut.
 // @Tags Auth
 // @Accept json
 // @Produce json
-// @Param body body object true "Refresh token payload"
+// @Param body body model.RefreshRequest true "Refresh token payload"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /auth/refresh [post]
-func RefreshToken(c *fiber.Ctx, repo *repository.UserRepository) error {
-	// Ambil refresh token dari body
-	var body struct {
-		Token string `json:"refreshToken"`
-	}
-	if err := c.BodyParser(&body); err != nil || body.Token == "" {
+func RefreshToken(c *fiber.Ctx, repo *repository.UserRepository, refreshRepo *repository.RefreshTokenRepository) error {
+	var body model.RefreshRequest
+	if err := c.BodyParser(&body); err != nil || body.RefreshToken == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "refresh token required",
 		})
 	}
 
-	// Validasi token
-	claims, err := utils.ValidateJWT(body.Token)
+	current, err := refreshRepo.GetByHash(utils.HashOpaqueToken(body.RefreshToken))
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "invalid or expired refresh token",
 		})
 	}
 
-	// Ambil user dari DB
-	user, err := repo.GetUserByID(claims.UserID)
+	// Token yang sudah dirotasi dipakai lagi -> kemungkinan dicuri, cabut seluruh family
+	if current.RevokedAt != nil {
+		_ = refreshRepo.RevokeFamily(current.FamilyID)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "refresh token reused, session revoked",
+		})
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "invalid or expired refresh token",
+		})
+	}
+
+	user, err := repo.GetUserByID(current.UserID.String())
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "user not found",
 		})
 	}
 
-	// Generate token baru
-	token, err := utils.GenerateJWT(user.ID.String(), user.RoleID.String(), claims.Permissions)
+	if !user.IsActive {
+		_ = refreshRepo.RevokeFamily(current.FamilyID)
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "akun tidak aktif",
+		})
+	}
+
+	// Permissions selalu diambil ulang supaya perubahan role langsung berlaku
+	perms, err := repo.GetPermissionsByUserID(user.ID.String())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load permissions",
+		})
+	}
+
+	newRefreshToken, newHash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to generate refresh token",
+		})
+	}
+
+	if _, err := refreshRepo.Rotate(current, newHash, time.Now().Add(utils.RefreshTokenTTL)); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenReused) {
+			_ = refreshRepo.RevokeFamily(current.FamilyID)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "refresh token reused, session revoked",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to rotate refresh token",
+		})
+	}
+
+	tokenVersion, err := repo.GetTokenVersion(user.ID.String())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load user session",
+		})
+	}
+
+	token, err := utils.GenerateJWT(user.ID.String(), user.RoleID.String(), perms, tokenVersion)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to generate token",
@@ -114,22 +296,26 @@ func RefreshToken(c *fiber.Ctx, repo *repository.UserRepository) error {
 	return c.JSON(fiber.Map{
 		"status": "success",
 		"data": fiber.Map{
-			"token": token,
+			"token":        token,
+			"refreshToken": newRefreshToken,
+			"expiresIn":    int(utils.AccessTokenTTL.Seconds()),
 		},
 	})
 }
 
 // Logout godoc
 // @Summary Logout user
-// @Description Logout dan blacklist JWT token
+// @Description Logout, blacklist JWT token dan cabut refresh token (jika dikirim)
 // @Tags Auth
 // @Security BearerAuth
+// @Accept json
 // @Produce json
+// @Param body body model.RefreshRequest false "Refresh token yang ikut dicabut"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /auth/logout [post]
-func Logout(c *fiber.Ctx) error {
+func Logout(c *fiber.Ctx, refreshRepo *repository.RefreshTokenRepository) error {
 	authHeader := c.Get("Authorization")
 	token, err := utils.ExtractTokenFromHeader(authHeader)
 	if err != nil {
@@ -141,8 +327,19 @@ func Logout(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Masukkan token ke blacklist
-	utils.AddToBlacklist(token, claims.ExpiresAt.Time)
+	// Cabut access token (berdasarkan jti) sampai expired
+	if err := utils.RevokeToken(claims); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke token"})
+	}
+
+	// Refresh token milik user ini ikut dicabut beserta family-nya
+	var body model.RefreshRequest
+	if err := c.BodyParser(&body); err == nil && body.RefreshToken != "" {
+		rt, err := refreshRepo.GetByHash(utils.HashOpaqueToken(body.RefreshToken))
+		if err == nil && rt.UserID.String() == claims.UserID {
+			_ = refreshRepo.RevokeFamily(rt.FamilyID)
+		}
+	}
 
 	return c.JSON(fiber.Map{
 		"status":  "success",
@@ -150,4 +347,198 @@ func Logout(c *fiber.Ctx) error {
 	})
 }
 
+// LogoutAll godoc
+// @Summary Logout from all devices
+// @Description Mencabut semua access token dan refresh token milik user yang sedang login
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/logout-all [post]
+func LogoutAll(c *fiber.Ctx, repo *repository.UserRepository) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user"})
+	}
+
+	if err := repo.RevokeAllSessions(userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke sessions"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "logout dari semua perangkat berhasil",
+	})
+}
+
+// ChangePassword godoc
+// @Summary Change own password
+// @Description User yang sedang login mengganti password sendiri (wajib menyertakan password lama). Semua sesi lain dicabut dan token baru dikembalikan.
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body model.ChangePasswordRequest true "Change password payload"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/password [put]
+func ChangePassword(c *fiber.Ctx, repo *repository.UserRepository, refreshRepo *repository.RefreshTokenRepository) error {
+	var body model.ChangePasswordRequest
+	if err := c.BodyParser(&body); err != nil || body.CurrentPassword == "" || body.NewPassword == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "currentPassword and newPassword are required"})
+	}
+
+	user, err := repo.GetUserByID(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user"})
+	}
+
+	if !utils.CheckPassword(user.Password, body.CurrentPassword) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "password lama salah"})
+	}
+
+	violations, err := validatePasswordPolicy(repo, user.ID, body.NewPassword)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to check password policy"})
+	}
+	if len(violations) > 0 {
+		return passwordPolicyError(c, violations)
+	}
+
+	hashedPassword, err := utils.HashPassword(body.NewPassword)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to hash password"})
+	}
+
+	if _, err := repo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update password"})
+	}
+
+	// Semua sesi lama dicabut, lalu sesi baru dibuat untuk client ini
+	if err := repo.RevokeAllSessions(user.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke sessions"})
+	}
+
+	token, refreshToken, err := issueSession(repo, refreshRepo, user)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate token"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "password berhasil diubah",
+		"data": fiber.Map{
+			"token":        token,
+			"refreshToken": refreshToken,
+			"expiresIn":    int(utils.AccessTokenTTL.Seconds()),
+		},
+	})
+}
+
+// ForgotPassword godoc
+// @Summary Request password reset
+// @Description Mengirim link reset password (token sekali pakai) ke email user. Response selalu sama agar email terdaftar tidak bisa ditebak.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.ForgotPasswordRequest true "Forgot password payload"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /auth/forgot-password [post]
+func ForgotPassword(c *fiber.Ctx, repo *repository.UserRepository, resetRepo *repository.PasswordResetRepository) error {
+	var body model.ForgotPasswordRequest
+	if err := c.BodyParser(&body); err != nil || body.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "email is required"})
+	}
+
+	response := fiber.Map{
+		"status":  "success",
+		"message": "jika email terdaftar, link reset password akan dikirim",
+	}
+
+	user, err := repo.GetUserByEmail(body.Email)
+	if err != nil || !user.IsActive {
+		return c.JSON(response)
+	}
+
+	token, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate reset token"})
+	}
+
+	if err := resetRepo.Create(user.ID, hash, time.Now().Add(utils.PasswordResetTTL)); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save reset token"})
+	}
+
+	message := fmt.Sprintf(
+		"Halo %s,\n\nGunakan link berikut untuk reset password (berlaku %s):\n%s?token=%s\n\nAbaikan pesan ini jika kamu tidak meminta reset password.",
+		user.FullName,
+		utils.PasswordResetTTL,
+		utils.PasswordResetURL,
+		url.QueryEscape(token),
+	)
+	if err := utils.Notify(user.Email, "Reset Password", message); err != nil {
+		log.Println("❌ Failed to send reset password notification:", err)
+	}
+
+	return c.JSON(response)
+}
+
+// ResetPassword godoc
+// @Summary Reset password with token
+// @Description Mengganti password menggunakan token reset (sekali pakai). Semua sesi user dicabut.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.ResetPasswordRequest true "Reset password payload"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/reset-password [post]
+func ResetPassword(c *fiber.Ctx, repo *repository.UserRepository, resetRepo *repository.PasswordResetRepository) error {
+	var body model.ResetPasswordRequest
+	if err := c.BodyParser(&body); err != nil || body.Token == "" || body.NewPassword == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "token and newPassword are required"})
+	}
+
+	tokenHash := utils.HashOpaqueToken(body.Token)
+
+	// Policy dicek sebelum token dipakai supaya token tidak hangus jika password ditolak
+	userID, err := resetRepo.GetUserID(tokenHash)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid or expired reset token"})
+	}
+
+	violations, err := validatePasswordPolicy(repo, userID, body.NewPassword)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to check password policy"})
+	}
+	if len(violations) > 0 {
+		return passwordPolicyError(c, violations)
+	}
 
+	hashedPassword, err := utils.HashPassword(body.NewPassword)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to hash password"})
+	}
+
+	if _, err := resetRepo.ResetPassword(tokenHash, hashedPassword); err != nil {
+		if err.Error() == "invalid or expired reset token" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update password"})
+	}
+
+	if err := repo.RevokeAllSessions(userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke sessions"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "password berhasil direset, silakan login kembali",
+	})
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"errors"
+	"mime"
+	"path"
+	"strconv"
+
+	"UAS/app/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// serveObject mengirim objek storage dengan Content-Type, Content-Disposition dan
+// dukungan HTTP range (satu rentang). inline=false memaksa browser mengunduh.
+func serveObject(c *fiber.Ctx, store storage.Storage, key, fileName, contentType string, inline bool) error {
+	ctx := c.UserContext()
+
+	info, err := store.Stat(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "file not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if contentType == "" {
+		contentType = info.ContentType
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+	if fileName == "" {
+		fileName = path.Base(key)
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType(disposition, map[string]string{"filename": fileName}))
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "private, max-age=60")
+	if !info.LastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, info.LastModified.UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT"))
+	}
+
+	byteRange, err := storage.ParseRange(c.Get(fiber.HeaderRange), info.Size)
+	if err != nil {
+		c.Set(fiber.HeaderContentRange, "bytes */"+strconv.FormatInt(info.Size, 10))
+		return c.Status(fiber.StatusRequestedRangeNotSatisfiable).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if byteRange == nil {
+		body, _, err := store.Get(ctx, key)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.SendStream(body, int(info.Size))
+	}
+
+	body, err := store.GetRange(ctx, key, byteRange.Start, byteRange.Length)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.Set(fiber.HeaderContentRange, byteRange.ContentRange(info.Size))
+	c.Status(fiber.StatusPartialContent)
+	return c.SendStream(body, int(byteRange.Length))
+}
+
+// ServeSignedFile melayani link sementara dari LocalStorage.PresignedURL.
+// Tidak butuh token login; akses dijamin oleh tanda tangan dan masa berlaku link.
+func ServeSignedFile(c *fiber.Ctx, local *storage.LocalStorage) error {
+	key := c.Params("*")
+
+	if err := local.VerifySignature(key, c.Query("expires"), c.Query("signature")); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return serveObject(c, local, key, "", "", c.Query("download") != "1")
+}
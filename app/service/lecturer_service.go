@@ -1,6 +1,7 @@
 package service
 
 import (
+	"UAS/app/policy"
 	"UAS/app/repository"
 
 	"github.com/gofiber/fiber/v2"
@@ -33,7 +34,7 @@ func GetLecturers(c *fiber.Ctx, repo *repository.LecturerRepository) error {
 
 // GetLecturerAdvisees godoc
 // @Summary Get lecturer advisees
-// @Description Mengambil daftar mahasiswa bimbingan dosen
+// @Description Mengambil daftar mahasiswa bimbingan dosen (dosen ybs atau admin)
 // @Tags Lecturers
 // @Security BearerAuth
 // @Produce json
@@ -41,18 +42,26 @@ func GetLecturers(c *fiber.Ctx, repo *repository.LecturerRepository) error {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /lecturers/{id}/advisees [get]
-func GetLecturerAdvisees(c *fiber.Ctx, repo *repository.LecturerRepository) error {
+func GetLecturerAdvisees(c *fiber.Ctx, repo *repository.LecturerRepository, pol *policy.Policy) error {
 	lecturerID := c.Params("id")
 
 	// validasi UUID
-	if _, err := uuid.Parse(lecturerID); err != nil {
+	lid, err := uuid.Parse(lecturerID)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "invalid lecturer id",
 		})
 	}
 
+	if err := pol.AuthorizeLecturer(c, lid); err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	advisees, err := repo.GetAdvisees(lecturerID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+)
+
+const (
+	outboxBatchSize  = 100
+	outboxMaxBackoff = 10 * time.Minute
+)
+
+// OutboxDispatcher menerapkan event outbox PostgreSQL ke MongoDB. Setiap event
+// idempotent, jadi aman diterapkan lebih dari sekali (mis. oleh dua instance).
+type OutboxDispatcher struct {
+	Outbox       *repository.OutboxRepository
+	Achievements *repository.AchievementRepository
+	MaxAttempts  int
+}
+
+func NewOutboxDispatcher(outbox *repository.OutboxRepository, achievements *repository.AchievementRepository, maxAttempts int) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		Outbox:       outbox,
+		Achievements: achievements,
+		MaxAttempts:  maxAttempts,
+	}
+}
+
+func (d *OutboxDispatcher) apply(ev model.OutboxEvent) error {
+	switch ev.EventType {
+	case model.OutboxAchievementStatus:
+		var p model.AchievementStatusPayload
+		if err := json.Unmarshal(ev.Payload, &p); err != nil {
+			return err
+		}
+		// dokumen hilang / sudah lebih baru tidak diulang; selisih seperti ini
+		// ditangani oleh job rekonsiliasi
+		_, err := d.Achievements.ApplyStatus(ev.AggregateID, p.Status, p.At, ev.ID, p.Points)
+		return err
+
+	case model.OutboxAchievementPoints:
+		var p model.AchievementPointsPayload
+		if err := json.Unmarshal(ev.Payload, &p); err != nil {
+			return err
+		}
+		_, err := d.Achievements.ApplyPoints(ev.AggregateID, p.Points, p.At, ev.ID)
+		return err
+
+	case model.OutboxAchievementDelete:
+		return d.Achievements.HardDelete(ev.AggregateID)
+	}
+
+	return fmt.Errorf("unknown outbox event type %q", ev.EventType)
+}
+
+// retryIn: exponential back-off per percobaan, dibatasi outboxMaxBackoff
+func retryIn(attempts int) time.Duration {
+	if attempts > 10 {
+		return outboxMaxBackoff
+	}
+	d := time.Second << uint(attempts)
+	if d > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return d
+}
+
+func (d *OutboxDispatcher) process(events []model.OutboxEvent) (int, error) {
+	processed := 0
+	for _, ev := range events {
+		if err := d.apply(ev); err != nil {
+			log.Printf("❌ Outbox event %d (%s %s) failed: %v\n", ev.ID, ev.EventType, ev.AggregateID, err)
+			if mErr := d.Outbox.MarkFailed(ev.ID, err, retryIn(ev.Attempts), d.MaxAttempts); mErr != nil {
+				return processed, mErr
+			}
+			continue
+		}
+		if err := d.Outbox.MarkProcessed(ev.ID); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// DispatchAggregate menerapkan event pending milik satu achievement, dipanggil
+// langsung setelah transaksi commit agar perubahan segera terlihat di MongoDB
+func (d *OutboxDispatcher) DispatchAggregate(aggregateID string) error {
+	events, err := d.Outbox.GetPendingByAggregate(aggregateID)
+	if err != nil {
+		return err
+	}
+	_, err = d.process(events)
+	return err
+}
+
+// DispatchDue menerapkan satu batch event pending yang sudah jatuh tempo
+func (d *OutboxDispatcher) DispatchDue() (int, error) {
+	events, err := d.Outbox.GetDue(outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return d.process(events)
+}
+
+// Start menjalankan worker yang mengulang event gagal secara berkala sampai ctx dibatalkan
+func (d *OutboxDispatcher) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := d.DispatchDue()
+				if err != nil {
+					log.Println("❌ Outbox dispatch failed:", err)
+					continue
+				}
+				if n > 0 {
+					log.Printf("📤 Outbox applied %d events\n", n)
+				}
+			}
+		}
+	}()
+}
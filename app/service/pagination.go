@@ -0,0 +1,26 @@
+package service
+
+import "github.com/gofiber/fiber/v2"
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// parsePagination membaca query ?page=&limit= (page mulai dari 1)
+func parsePagination(c *fiber.Ctx) (page, limit, offset int) {
+	page = c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+
+	limit = c.QueryInt("limit", defaultPageLimit)
+	if limit < 1 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	return page, limit, (page - 1) * limit
+}
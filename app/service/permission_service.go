@@ -0,0 +1,163 @@
+package service
+
+import (
+	"strings"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+	"UAS/app/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetPermissions godoc
+// @Summary Get all permissions
+// @Description Mengambil seluruh permission
+// @Tags Permissions
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /permissions [get]
+func GetPermissions(c *fiber.Ctx, permRepo *repository.PermissionRepository) error {
+	perms, err := permRepo.GetAll()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "gagal mengambil data permission",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   perms,
+	})
+}
+
+// parsePermissionInput memvalidasi payload permission (resource & action wajib)
+func parsePermissionInput(c *fiber.Ctx) (*model.Permission, string) {
+	var input model.PermissionRequest
+	if err := c.BodyParser(&input); err != nil {
+		return nil, "invalid request body"
+	}
+
+	resource := strings.TrimSpace(input.Resource)
+	action := strings.TrimSpace(input.Action)
+	if resource == "" || action == "" || strings.Contains(resource, ":") || strings.Contains(action, ":") {
+		return nil, "resource and action are required and must not contain ':'"
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		name = resource + ":" + action
+	}
+
+	return &model.Permission{
+		Name:        name,
+		Resource:    resource,
+		Action:      action,
+		Description: input.Description,
+	}, ""
+}
+
+// CreatePermission godoc
+// @Summary Create permission
+// @Description Membuat permission baru (format resource:action)
+// @Tags Permissions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body model.PermissionRequest true "Permission payload"
+// @Success 201 {object} model.Permission
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /permissions [post]
+func CreatePermission(c *fiber.Ctx, permRepo *repository.PermissionRepository) error {
+	p, msg := parsePermissionInput(c)
+	if p == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+
+	created, err := permRepo.Create(p)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"data":   created,
+	})
+}
+
+// UpdatePermission godoc
+// @Summary Update permission
+// @Description Mengubah permission (berlaku tanpa user login ulang)
+// @Tags Permissions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Permission ID (UUID)"
+// @Param body body model.PermissionRequest true "Permission payload"
+// @Success 200 {object} model.Permission
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /permissions/{id} [put]
+func UpdatePermission(c *fiber.Ctx, permRepo *repository.PermissionRepository) error {
+	permID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid permission ID"})
+	}
+
+	p, msg := parsePermissionInput(c)
+	if p == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	p.ID = permID
+
+	updated, err := permRepo.Update(p)
+	if err != nil {
+		if err.Error() == "permission not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "permission not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	utils.InvalidatePermissions()
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   updated,
+	})
+}
+
+// DeletePermission godoc
+// @Summary Delete permission
+// @Description Menghapus permission dan melepasnya dari semua role
+// @Tags Permissions
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Permission ID (UUID)"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /permissions/{id} [delete]
+func DeletePermission(c *fiber.Ctx, permRepo *repository.PermissionRepository) error {
+	permID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid permission ID"})
+	}
+
+	if err := permRepo.Delete(permID); err != nil {
+		if err.Error() == "permission not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "permission not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	utils.InvalidatePermissions()
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+	})
+}
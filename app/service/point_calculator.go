@@ -0,0 +1,151 @@
+package service
+
+import (
+	"log"
+	"sync"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+)
+
+// PointCalculator menghitung poin prestasi dari katalog aturan poin
+type PointCalculator struct {
+	Catalog      *repository.AchievementTypeRepository
+	Refs         *repository.AchievementReferenceRepository
+	Achievements *repository.AchievementRepository
+	Dispatcher   *OutboxDispatcher
+
+	mu sync.Mutex
+	// tipe yang sedang dihitung ulang -> permintaan berikutnya (nil jika tidak ada)
+	running map[string]*recalculation
+}
+
+type recalculation struct {
+	reason  string
+	actorID string
+}
+
+func NewPointCalculator(
+	catalog *repository.AchievementTypeRepository,
+	refs *repository.AchievementReferenceRepository,
+	achievements *repository.AchievementRepository,
+	dispatcher *OutboxDispatcher,
+) *PointCalculator {
+	return &PointCalculator{
+		Catalog:      catalog,
+		Refs:         refs,
+		Achievements: achievements,
+		Dispatcher:   dispatcher,
+	}
+}
+
+// Award menghitung poin untuk prestasi yang akan diverifikasi
+func (p *PointCalculator) Award(achievement *model.Achievement, previous int) (model.PointAward, error) {
+	rules, err := p.Catalog.GetRules(achievement.AchievementType)
+	if err != nil {
+		return model.PointAward{}, err
+	}
+
+	award := model.CalculatePoints(rules, achievement.AchievementType, achievement.Details)
+	award.Previous = previous
+	return award, nil
+}
+
+// recalculateBatch adalah jumlah reference yang diproses per halaman saat hitung ulang
+const recalculateBatch = 200
+
+// Recalculate menghitung ulang poin seluruh prestasi verified dengan tipe code
+// setelah aturannya berubah. Kandidat dipilih dari PostgreSQL (sumber status) per
+// halaman; MongoDB hanya dibaca untuk detail prestasi yang terpilih.
+func (p *PointCalculator) Recalculate(code, reason, actorID string) (*model.RecalculationResult, error) {
+	result := &model.RecalculationResult{TypeCode: code}
+
+	rules, err := p.Catalog.GetRules(code)
+	if err != nil {
+		return nil, err
+	}
+
+	after := ""
+	for {
+		ids, err := p.Refs.GetVerifiedIDsForRecalculation(code, after, recalculateBatch)
+		if err != nil {
+			return result, err
+		}
+		if len(ids) == 0 {
+			return result, nil
+		}
+		after = ids[len(ids)-1]
+
+		achievements, err := p.Achievements.GetByIDs(ids)
+		if err != nil {
+			return result, err
+		}
+
+		for _, a := range achievements {
+			// reference lama tanpa snapshot: tipe dipastikan dari dokumen
+			if a.AchievementType != code {
+				continue
+			}
+			result.Checked++
+			id := a.ID.Hex()
+
+			award := model.CalculatePoints(rules, a.AchievementType, a.Details)
+			changed, err := p.Refs.RecalculatePoints(id, award, reason, actorID)
+			if err != nil {
+				log.Println("❌ Failed to recalculate points for achievement", id, err)
+				result.Failed = append(result.Failed, id)
+				continue
+			}
+			if !changed {
+				continue
+			}
+			result.Changed++
+
+			if err := p.Dispatcher.DispatchAggregate(id); err != nil {
+				log.Println("❌ Failed to dispatch outbox for achievement", id, err)
+			}
+		}
+	}
+}
+
+// Schedule menjalankan Recalculate di background supaya request admin tidak menunggu.
+// Hanya satu hitung ulang per tipe yang berjalan; permintaan yang datang selama itu
+// digabung menjadi satu putaran ulang agar aturan terbaru tetap terpakai.
+func (p *PointCalculator) Schedule(code, reason, actorID string) {
+	req := recalculation{reason: reason, actorID: actorID}
+
+	p.mu.Lock()
+	if p.running == nil {
+		p.running = map[string]*recalculation{}
+	}
+	if _, busy := p.running[code]; busy {
+		p.running[code] = &req
+		p.mu.Unlock()
+		return
+	}
+	p.running[code] = nil
+	p.mu.Unlock()
+
+	go func() {
+		for {
+			result, err := p.Recalculate(code, req.reason, req.actorID)
+			if err != nil {
+				log.Printf("❌ Point recalculation for %s failed: %v\n", code, err)
+			} else {
+				log.Printf("✅ Point recalculation for %s: %d checked, %d changed, %d failed\n",
+					code, result.Checked, result.Changed, len(result.Failed))
+			}
+
+			p.mu.Lock()
+			next := p.running[code]
+			if next == nil {
+				delete(p.running, code)
+				p.mu.Unlock()
+				return
+			}
+			p.running[code] = nil
+			p.mu.Unlock()
+			req = *next
+		}
+	}()
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/preview"
+	"UAS/app/repository"
+	"UAS/app/storage"
+)
+
+const previewSweepBatch = 50
+
+// PreviewJob adalah satu lampiran yang perlu dibuatkan pratinjau
+type PreviewJob struct {
+	AchievementID string
+	Attachment    model.Attachment
+}
+
+// PreviewWorker membuat thumbnail gambar dan membaca metadata PDF di background,
+// supaya upload tidak menunggu proses decode / resize. Lampiran yang masih pending
+// (antrean penuh, server restart, storage sempat gagal) diambil lagi oleh sweep berkala.
+type PreviewWorker struct {
+	Achievements *repository.AchievementRepository
+	Store        storage.Storage
+	Sizes        []preview.Size
+
+	jobs     chan PreviewJob
+	mu       sync.Mutex
+	inflight map[string]bool
+}
+
+func NewPreviewWorker(achievements *repository.AchievementRepository, store storage.Storage, queueSize int) *PreviewWorker {
+	return &PreviewWorker{
+		Achievements: achievements,
+		Store:        store,
+		Sizes:        preview.DefaultSizes,
+		jobs:         make(chan PreviewJob, queueSize),
+		inflight:     map[string]bool{},
+	}
+}
+
+// Enqueue tidak pernah memblokir request upload; kalau antrean penuh, lampiran tetap
+// pending dan akan diambil sweep berikutnya
+func (w *PreviewWorker) Enqueue(achievementID string, attachment model.Attachment) {
+	if w == nil || !attachment.NeedsPreview() {
+		return
+	}
+
+	id := achievementID + "/" + attachment.StorageKey
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.inflight[id] {
+		return
+	}
+
+	select {
+	case w.jobs <- PreviewJob{AchievementID: achievementID, Attachment: attachment}:
+		w.inflight[id] = true
+	default:
+	}
+}
+
+func (w *PreviewWorker) done(job PreviewJob) {
+	w.mu.Lock()
+	delete(w.inflight, job.AchievementID+"/"+job.Attachment.StorageKey)
+	w.mu.Unlock()
+}
+
+// Start menjalankan sejumlah worker dan sweep berkala sampai ctx dibatalkan
+func (w *PreviewWorker) Start(ctx context.Context, workers int, interval time.Duration) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-w.jobs:
+					if err := w.Generate(ctx, job.AchievementID, job.Attachment); err != nil {
+						log.Printf("❌ Preview for attachment %s failed: %v\n", job.Attachment.ID, err)
+					}
+					w.done(job)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		w.sweep()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.sweep()
+			}
+		}
+	}()
+}
+
+// sweep mengantrekan ulang lampiran yang masih pending di MongoDB
+func (w *PreviewWorker) sweep() {
+	achievements, err := w.Achievements.GetPendingPreviews(previewSweepBatch)
+	if err != nil {
+		log.Println("❌ Failed to load pending previews:", err)
+		return
+	}
+	for _, a := range achievements {
+		for _, att := range a.Attachments {
+			if att.Preview != nil && att.Preview.Status == model.PreviewPending {
+				w.Enqueue(a.ID.Hex(), att)
+			}
+		}
+	}
+}
+
+// Generate membuat pratinjau satu lampiran dan menyimpannya. Error yang dikembalikan
+// bersifat sementara (storage / database) dan lampiran tetap pending; file yang tidak
+// bisa diproses dicatat sebagai failed.
+func (w *PreviewWorker) Generate(ctx context.Context, achievementID string, attachment model.Attachment) error {
+	result, thumbnails, err := w.safeBuild(ctx, achievementID, attachment)
+
+	saved := false
+	if err == nil {
+		saved, err = w.Achievements.SetAttachmentPreview(achievementID, attachment.ID, attachment.StorageKey, *result)
+	}
+	if !saved {
+		// gagal, atau lampiran sudah diganti / dihapus: thumbnail jangan jadi yatim
+		for _, key := range thumbnails {
+			if dErr := w.Store.Delete(ctx, key); dErr != nil && !errors.Is(dErr, storage.ErrNotFound) {
+				log.Printf("❌ Failed to delete thumbnail %s: %v\n", key, dErr)
+			}
+		}
+	}
+	return err
+}
+
+// safeBuild menjalankan build; panic dari decoder (file rusak / sengaja dibuat) tidak
+// boleh mematikan proses API, jadi lampiran dicatat sebagai failed
+func (w *PreviewWorker) safeBuild(ctx context.Context, achievementID string, attachment model.Attachment) (result *model.AttachmentPreview, keys []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ Preview for attachment %s panicked: %v\n", attachment.ID, r)
+			result, err = previewFailed("file could not be processed"), nil
+		}
+	}()
+	return w.build(ctx, achievementID, attachment)
+}
+
+// build membaca file lalu menghasilkan pratinjau beserta key thumbnail yang sudah disimpan
+func (w *PreviewWorker) build(ctx context.Context, achievementID string, attachment model.Attachment) (*model.AttachmentPreview, []string, error) {
+	rc, _, err := w.Store.Get(ctx, attachment.Key())
+	if errors.Is(err, storage.ErrNotFound) {
+		return previewFailed("stored file not found"), nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	result := &model.AttachmentPreview{Status: model.PreviewReady, GeneratedAt: &now}
+
+	switch attachment.ContentType {
+	case "application/pdf":
+		info, err := preview.ReadPDFInfo(data)
+		if err != nil {
+			return previewFailed(err.Error()), nil, nil
+		}
+		result.PageCount = info.PageCount
+		result.Title = info.Title
+		result.PageWidth = info.PageWidth
+		result.PageHeight = info.PageHeight
+		return result, nil, nil
+
+	case "image/png", "image/jpeg":
+		thumbs, err := preview.Thumbnails(data, w.Sizes)
+		if err != nil {
+			return previewFailed(err.Error()), nil, nil
+		}
+
+		keys := []string{}
+		for _, t := range thumbs {
+			key := thumbnailKey(achievementID, attachment.StorageKey, t.Size.Name)
+			if err := w.Store.Put(ctx, key, bytes.NewReader(t.Data), int64(len(t.Data)), "image/jpeg"); err != nil {
+				return nil, keys, err
+			}
+			keys = append(keys, key)
+			result.Thumbnails = append(result.Thumbnails, model.AttachmentThumbnail{
+				Size:       t.Size.Name,
+				Width:      t.Width,
+				Height:     t.Height,
+				URL:        model.ThumbnailURL(achievementID, attachment.ID, t.Size.Name),
+				StorageKey: key,
+			})
+		}
+		return result, keys, nil
+	}
+
+	return previewFailed(fmt.Sprintf("no preview for %q", attachment.ContentType)), nil, nil
+}
+
+func previewFailed(reason string) *model.AttachmentPreview {
+	now := time.Now()
+	return &model.AttachmentPreview{Status: model.PreviewFailed, Error: reason, GeneratedAt: &now}
+}
+
+// thumbnailKey diturunkan dari nama file di storage (selalu baru setiap upload / ganti),
+// jadi thumbnail file lama dan baru tidak pernah bertabrakan
+func thumbnailKey(achievementID, storageKey, size string) string {
+	name := strings.TrimSuffix(path.Base(storageKey), path.Ext(storageKey))
+	return path.Join("previews", achievementID, name+"-"+size+".jpg")
+}
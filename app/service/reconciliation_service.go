@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// orphanGracePeriod: dokumen baru mungkin sedang menunggu reference-nya dibuat,
+// jadi baru dianggap yatim setelah lewat dari periode ini
+const orphanGracePeriod = 10 * time.Minute
+
+// Reconciler membandingkan achievement_references (PostgreSQL) dengan koleksi
+// achievements (MongoDB). PostgreSQL adalah sumber kebenaran untuk status.
+type Reconciler struct {
+	Refs         *repository.AchievementReferenceRepository
+	Achievements *repository.AchievementRepository
+	Dispatcher   *OutboxDispatcher
+}
+
+func NewReconciler(
+	refs *repository.AchievementReferenceRepository,
+	achievements *repository.AchievementRepository,
+	dispatcher *OutboxDispatcher,
+) *Reconciler {
+	return &Reconciler{Refs: refs, Achievements: achievements, Dispatcher: dispatcher}
+}
+
+// Run menghasilkan laporan selisih; jika opts.Apply, selisih sekaligus diperbaiki:
+//   - missing reference -> hanya dilaporkan. PostgreSQL adalah sumber kebenaran, jadi
+//     reference tidak pernah dihapus karena dokumen MongoDB hilang (bisa saja MongoDB
+//     sedang bermasalah). Pengecualian: dengan opts.Force, reference draft yang belum
+//     pernah diajukan dihapus.
+//   - orphan document   -> dokumen dihapus (kompensasi create yang gagal)
+//   - status mismatch   -> status & poin PostgreSQL diterapkan ulang lewat outbox
+func (r *Reconciler) Run(ctx context.Context, opts model.ReconcileOptions) (*model.ReconciliationReport, error) {
+	refs, err := r.Refs.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	docs, err := r.Achievements.GetSyncStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.Reconcile(refs, docs, opts), nil
+}
+
+// Reconcile membandingkan reference dan dokumen yang sudah dimuat
+func (r *Reconciler) Reconcile(refs []model.AchievementReference, docs []model.AchievementSyncState, opts model.ReconcileOptions) *model.ReconciliationReport {
+	report := &model.ReconciliationReport{
+		Mode:              "dry-run",
+		MissingReferences: []model.ReconciliationItem{},
+		OrphanDocuments:   []model.ReconciliationItem{},
+		StatusMismatches:  []model.ReconciliationItem{},
+		StartedAt:         time.Now(),
+	}
+	if opts.Apply {
+		report.Mode = "apply"
+	}
+	apply := opts.Apply
+
+	report.CheckedReferences = len(refs)
+	report.CheckedDocuments = len(docs)
+
+	docByID := make(map[string]model.AchievementSyncState, len(docs))
+	for _, d := range docs {
+		docByID[d.ID] = d
+	}
+	refByMongoID := make(map[string]bool, len(refs))
+
+	for _, ref := range refs {
+		refByMongoID[ref.MongoAchievementID] = true
+		refID := ref.ID
+
+		doc, ok := docByID[ref.MongoAchievementID]
+		if !ok {
+			item := model.ReconciliationItem{
+				Kind:           model.DriftMissingReference,
+				MongoID:        ref.MongoAchievementID,
+				ReferenceID:    &refID,
+				PostgresStatus: string(ref.CurrentStatus()),
+				Action:         "none (restore the MongoDB document)",
+			}
+			if ref.CurrentStatus() == model.StatusDraft && ref.SubmissionRound == 0 {
+				item.Action = "delete draft reference (requires force)"
+				if apply && opts.Force {
+					mongoID := ref.MongoAchievementID
+					r.repair(&item, report, func() error {
+						return r.Refs.DeleteDraftByMongoID(mongoID)
+					})
+				}
+			}
+			report.MissingReferences = append(report.MissingReferences, item)
+			continue
+		}
+
+		pgStatus := ref.CurrentStatus()
+		if string(pgStatus) == doc.Status && ref.Points == doc.Points {
+			continue
+		}
+
+		item := model.ReconciliationItem{
+			Kind:           model.DriftStatusMismatch,
+			MongoID:        doc.ID,
+			ReferenceID:    &refID,
+			StudentID:      doc.StudentID,
+			PostgresStatus: string(pgStatus),
+			MongoStatus:    doc.Status,
+			Action:         "set mongo status to " + string(pgStatus),
+		}
+		if ref.Points != doc.Points {
+			pgPoints, mongoPoints := ref.Points, doc.Points
+			item.PostgresPoints, item.MongoPoints = &pgPoints, &mongoPoints
+			item.Action += fmt.Sprintf(", points to %d", pgPoints)
+		}
+		if apply {
+			// ref bisa basi jika transisi commit di antara dua pembacaan di atas;
+			// payload outbox dibangun dari baris terkini, bukan dari ref
+			mongoID := ref.MongoAchievementID
+			r.repair(&item, report, func() error {
+				if err := r.Refs.EnqueueStatusSync(mongoID); err != nil {
+					return err
+				}
+				return r.Dispatcher.DispatchAggregate(mongoID)
+			})
+		}
+		report.StatusMismatches = append(report.StatusMismatches, item)
+	}
+
+	cutoff := time.Now().Add(-orphanGracePeriod)
+	for _, doc := range docs {
+		if refByMongoID[doc.ID] || doc.CreatedAt.After(cutoff) {
+			continue
+		}
+
+		item := model.ReconciliationItem{
+			Kind:        model.DriftOrphanDocument,
+			MongoID:     doc.ID,
+			StudentID:   doc.StudentID,
+			MongoStatus: doc.Status,
+			Action:      "delete document",
+		}
+		if apply {
+			id := doc.ID
+			r.repair(&item, report, func() error {
+				return r.Achievements.HardDelete(id)
+			})
+		}
+		report.OrphanDocuments = append(report.OrphanDocuments, item)
+	}
+
+	report.FinishedAt = time.Now()
+	return report
+}
+
+func (r *Reconciler) repair(item *model.ReconciliationItem, report *model.ReconciliationReport, fix func() error) {
+	if err := fix(); err != nil {
+		item.Error = err.Error()
+		return
+	}
+	item.Repaired = true
+	report.Repaired++
+}
+
+// Start menjalankan rekonsiliasi berkala sampai ctx dibatalkan dan mencatat hasilnya di log.
+// Job berkala tidak pernah memakai force: reference tidak dihapus tanpa admin.
+func (r *Reconciler) Start(ctx context.Context, interval time.Duration, apply bool) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				report, err := r.Run(ctx, model.ReconcileOptions{Apply: apply})
+				if err != nil {
+					log.Println("❌ Reconciliation failed:", err)
+					continue
+				}
+				drift := len(report.MissingReferences) + len(report.OrphanDocuments) + len(report.StatusMismatches)
+				if drift > 0 {
+					log.Printf("🔍 Reconciliation (%s): %d missing references, %d orphan documents, %d status mismatches, %d repaired\n",
+						report.Mode,
+						len(report.MissingReferences),
+						len(report.OrphanDocuments),
+						len(report.StatusMismatches),
+						report.Repaired,
+					)
+				}
+			}
+		}
+	}()
+}
+
+// GetReconciliationReport godoc
+// @Summary Reconciliation report (dry-run)
+// @Description Admin melihat selisih achievement_references (PostgreSQL) dan achievements (MongoDB) tanpa mengubah data
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} model.ReconciliationReport
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/reconciliation [get]
+func GetReconciliationReport(c *fiber.Ctx, reconciler *Reconciler) error {
+	report, err := reconciler.Run(c.Context(), model.ReconcileOptions{})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   report,
+	})
+}
+
+// ApplyReconciliation godoc
+// @Summary Repair Mongo/Postgres drift
+// @Description Admin memperbaiki selisih: dokumen yatim dihapus, status MongoDB disamakan dengan PostgreSQL. Reference tanpa dokumen hanya dilaporkan; dengan ?force=true, reference draft yang belum pernah diajukan ikut dihapus (reference yang sudah diajukan / diverifikasi tidak pernah dihapus).
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param force query bool false "Hapus reference draft yang dokumennya hilang"
+// @Success 200 {object} model.ReconciliationReport
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/reconciliation [post]
+func ApplyReconciliation(c *fiber.Ctx, reconciler *Reconciler) error {
+	report, err := reconciler.Run(c.Context(), model.ReconcileOptions{Apply: true, Force: c.QueryBool("force", false)})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   report,
+	})
+}
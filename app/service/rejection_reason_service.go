@@ -0,0 +1,136 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var reasonCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// GetRejectionReasons godoc
+// @Summary Get rejection reasons
+// @Description Daftar alasan penolakan yang bisa dipilih dosen wali (?all=true untuk menyertakan yang nonaktif)
+// @Tags Rejection Reasons
+// @Security BearerAuth
+// @Produce json
+// @Param all query bool false "Sertakan alasan nonaktif"
+// @Success 200 {array} model.RejectionReason
+// @Failure 401 {object} map[string]string
+// @Router /rejection-reasons [get]
+func GetRejectionReasons(c *fiber.Ctx, reasons *repository.RejectionReasonRepository) error {
+	list, err := reasons.GetAll(c.Query("all") != "true")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   list,
+	})
+}
+
+// parseRejectionReasonInput memvalidasi payload alasan penolakan (label wajib)
+func parseRejectionReasonInput(c *fiber.Ctx) (*model.RejectionReason, string) {
+	var input model.RejectionReasonRequest
+	if err := c.BodyParser(&input); err != nil {
+		return nil, "invalid request body"
+	}
+
+	label := strings.TrimSpace(input.Label)
+	if label == "" {
+		return nil, "label is required"
+	}
+
+	active := true
+	if input.IsActive != nil {
+		active = *input.IsActive
+	}
+
+	return &model.RejectionReason{
+		Code:        strings.TrimSpace(input.Code),
+		Label:       label,
+		Description: strings.TrimSpace(input.Description),
+		IsActive:    active,
+	}, ""
+}
+
+// CreateRejectionReason godoc
+// @Summary Create rejection reason
+// @Description Menambahkan alasan penolakan. Kode huruf kecil/angka/underscore dan tidak bisa diubah.
+// @Tags Rejection Reasons
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body model.RejectionReasonRequest true "Reason payload"
+// @Success 201 {object} model.RejectionReason
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /rejection-reasons [post]
+func CreateRejectionReason(c *fiber.Ctx, reasons *repository.RejectionReasonRepository) error {
+	reason, msg := parseRejectionReasonInput(c)
+	if reason == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	if !reasonCodePattern.MatchString(reason.Code) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "code must be lowercase letters, digits or underscore"})
+	}
+
+	existing, err := reasons.GetAll(false)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	for _, r := range existing {
+		if r.Code == reason.Code {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "rejection reason already exists"})
+		}
+	}
+
+	created, err := reasons.Create(reason)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"data":   created,
+	})
+}
+
+// UpdateRejectionReason godoc
+// @Summary Update rejection reason
+// @Description Mengubah label / deskripsi / status aktif. Alasan tidak dihapus, cukup dinonaktifkan, agar riwayat penolakan tetap terbaca.
+// @Tags Rejection Reasons
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param code path string true "Reason code"
+// @Param body body model.RejectionReasonRequest true "Reason payload"
+// @Success 200 {object} model.RejectionReason
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /rejection-reasons/{code} [put]
+func UpdateRejectionReason(c *fiber.Ctx, reasons *repository.RejectionReasonRepository) error {
+	reason, msg := parseRejectionReasonInput(c)
+	if reason == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	reason.Code = c.Params("code")
+
+	updated, err := reasons.Update(reason)
+	if err != nil {
+		if err.Error() == "rejection reason not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   updated,
+	})
+}
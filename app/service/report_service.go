@@ -2,6 +2,7 @@ package service
 
 import (
 	"UAS/app/model"
+	"UAS/app/policy"
 	"UAS/app/repository"
 	"context"
 
@@ -21,11 +22,19 @@ import (
 func GetReportStatistics(
 	reportRepo *repository.ReportRepository,
 	achievementRepo *repository.AchievementRepository,
+	pol *policy.Policy,
 ) fiber.Handler {
 
 	return func(c *fiber.Ctx) error {
 		ctx := context.Background()
 
+		// statistik keseluruhan hanya untuk admin
+		if err := pol.RequireAdmin(c); err != nil {
+			return c.Status(policy.Status(err)).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
 		// 1️⃣ Total mahasiswa (PostgreSQL)
 		totalStudents, err := reportRepo.GetStudentStatistics()
 		if err != nil {
@@ -76,10 +85,16 @@ func GetReportStatistics(
 func GetStudentReport(
 	reportRepo *repository.ReportRepository,
 	achievementRepo *repository.AchievementRepository,
+	pol *policy.Policy,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		nim := c.Params("id")
 
+		// Mahasiswa: data sendiri, Dosen Wali: mahasiswa bimbingan, Admin: semua
+		if _, err := pol.AuthorizeStudentNumber(c, nim, policy.View); err != nil {
+			return fiber.NewError(policy.Status(err), err.Error())
+		}
+
 		// 1️⃣ Ambil data dasar mahasiswa (Postgres)
 		report, err := reportRepo.GetStudentBase(nim)
 		if err != nil {
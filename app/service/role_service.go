@@ -0,0 +1,294 @@
+package service
+
+import (
+	"strings"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+	"UAS/app/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetRoles godoc
+// @Summary Get all roles
+// @Description Mengambil seluruh role
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /roles [get]
+func GetRoles(c *fiber.Ctx, roleRepo *repository.RoleRepository) error {
+	roles, err := roleRepo.GetAll()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "gagal mengambil data role",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   roles,
+	})
+}
+
+// GetRoleByID godoc
+// @Summary Get role by ID
+// @Description Mengambil role beserta permission-nya
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Role ID (UUID)"
+// @Success 200 {object} model.RoleDetail
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /roles/{id} [get]
+func GetRoleByID(c *fiber.Ctx, roleRepo *repository.RoleRepository) error {
+	roleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid role ID"})
+	}
+
+	role, err := roleRepo.GetByID(roleID)
+	if err != nil {
+		if err.Error() == "role not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "role not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	perms, err := roleRepo.GetPermissions(roleID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": model.RoleDetail{
+			Role:        *role,
+			Permissions: perms,
+		},
+	})
+}
+
+// CreateRole godoc
+// @Summary Create role
+// @Description Membuat role baru
+// @Tags Roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body model.RoleRequest true "Role payload"
+// @Success 201 {object} model.Role
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /roles [post]
+func CreateRole(c *fiber.Ctx, roleRepo *repository.RoleRepository) error {
+	var input model.RoleRequest
+	if err := c.BodyParser(&input); err != nil || strings.TrimSpace(input.Name) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "role name is required"})
+	}
+
+	role, err := roleRepo.Create(strings.TrimSpace(input.Name), input.Description)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"data":   role,
+	})
+}
+
+// UpdateRole godoc
+// @Summary Update role
+// @Description Mengubah nama / deskripsi role. Nama role bawaan tidak boleh diubah.
+// @Tags Roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID (UUID)"
+// @Param body body model.RoleRequest true "Role payload"
+// @Success 200 {object} model.Role
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /roles/{id} [put]
+func UpdateRole(c *fiber.Ctx, roleRepo *repository.RoleRepository) error {
+	roleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid role ID"})
+	}
+
+	var input model.RoleRequest
+	if err := c.BodyParser(&input); err != nil || strings.TrimSpace(input.Name) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "role name is required"})
+	}
+
+	existing, err := roleRepo.GetByID(roleID)
+	if err != nil {
+		if err.Error() == "role not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "role not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if model.IsBuiltinRole(existing.Name) && name != existing.Name {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "nama role bawaan tidak boleh diubah"})
+	}
+
+	role, err := roleRepo.Update(roleID, name, input.Description)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   role,
+	})
+}
+
+// DeleteRole godoc
+// @Summary Delete role
+// @Description Menghapus role. Role bawaan (Admin, Mahasiswa, Dosen Wali) dan role yang masih dipakai user tidak boleh dihapus.
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Role ID (UUID)"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /roles/{id} [delete]
+func DeleteRole(c *fiber.Ctx, roleRepo *repository.RoleRepository) error {
+	roleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid role ID"})
+	}
+
+	role, err := roleRepo.GetByID(roleID)
+	if err != nil {
+		if err.Error() == "role not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "role not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if model.IsBuiltinRole(role.Name) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "role bawaan tidak boleh dihapus"})
+	}
+
+	if err := roleRepo.Delete(roleID); err != nil {
+		if err.Error() == "role is still assigned to users" {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	utils.InvalidatePermissions()
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+	})
+}
+
+// AttachRolePermission godoc
+// @Summary Attach permission to role
+// @Description Menambahkan permission ke role (berlaku tanpa user login ulang)
+// @Tags Roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID (UUID)"
+// @Param body body object true "{\"permissionId\": \"uuid\"}"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /roles/{id}/permissions [post]
+func AttachRolePermission(c *fiber.Ctx, roleRepo *repository.RoleRepository, permRepo *repository.PermissionRepository) error {
+	roleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid role ID"})
+	}
+
+	var body struct {
+		PermissionID string `json:"permissionId"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	permID, err := uuid.Parse(body.PermissionID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid permissionId"})
+	}
+
+	if _, err := roleRepo.GetByID(roleID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "role not found"})
+	}
+	if _, err := permRepo.GetByID(permID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "permission not found"})
+	}
+
+	if err := roleRepo.AttachPermission(roleID, permID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	utils.InvalidatePermissions()
+
+	perms, err := roleRepo.GetPermissions(roleID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   perms,
+	})
+}
+
+// DetachRolePermission godoc
+// @Summary Detach permission from role
+// @Description Mencabut permission dari role (berlaku tanpa user login ulang)
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Role ID (UUID)"
+// @Param permissionId path string true "Permission ID (UUID)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /roles/{id}/permissions/{permissionId} [delete]
+func DetachRolePermission(c *fiber.Ctx, roleRepo *repository.RoleRepository) error {
+	roleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid role ID"})
+	}
+	permID, err := uuid.Parse(c.Params("permissionId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid permission ID"})
+	}
+
+	if err := roleRepo.DetachPermission(roleID, permID); err != nil {
+		if err.Error() == "permission not attached to role" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	utils.InvalidatePermissions()
+
+	perms, err := roleRepo.GetPermissions(roleID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   perms,
+	})
+}
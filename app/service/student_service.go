@@ -1,6 +1,8 @@
 package service
 
 import (
+	"UAS/app/model"
+	"UAS/app/policy"
 	"UAS/app/repository"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -8,7 +10,7 @@ import (
 
 // GetStudents godoc
 // @Summary Get all students
-// @Description Mengambil daftar seluruh mahasiswa
+// @Description Admin: seluruh mahasiswa, Dosen Wali: mahasiswa bimbingan, Mahasiswa: data sendiri
 // @Tags Students
 // @Security BearerAuth
 // @Produce json
@@ -16,8 +18,21 @@ import (
 // @Failure 401 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /students [get]
-func GetStudents(c *fiber.Ctx, repo *repository.StudentRepository) error {
-	students, err := repo.GetAll()
+func GetStudents(c *fiber.Ctx, repo *repository.StudentRepository, pol *policy.Policy) error {
+	actor, err := pol.Actor(c)
+	if err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var students []model.StudentResponse
+	switch {
+	case actor.IsAdmin():
+		students, err = repo.GetAll()
+	case actor.IsLecturer():
+		students, err = repo.GetByAdvisorID(actor.Lecturer.ID.String())
+	default:
+		students = actor.Students
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
@@ -38,20 +53,16 @@ func GetStudents(c *fiber.Ctx, repo *repository.StudentRepository) error {
 // @Param id path string true "Student ID (UUID)"
 // @Success 200 {object} map[string]interface{}
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /students/{id} [get]
-func GetStudentByID(c *fiber.Ctx, repo *repository.StudentRepository) error {
+func GetStudentByID(c *fiber.Ctx, pol *policy.Policy) error {
 	studentID := c.Params("id")
 
-	student, err := repo.GetByID(studentID)
+	student, err := pol.AuthorizeStudent(c, studentID, policy.View)
 	if err != nil {
-		if err.Error() == "student not found" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "student not found",
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		return c.Status(policy.Status(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
@@ -76,9 +87,13 @@ func GetStudentByID(c *fiber.Ctx, repo *repository.StudentRepository) error {
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /students/{id}/advisor [put]
-func UpdateStudentAdvisor(c *fiber.Ctx, repo *repository.StudentRepository) error {
+func UpdateStudentAdvisor(c *fiber.Ctx, repo *repository.StudentRepository, pol *policy.Policy) error {
 	studentID := c.Params("id")
 
+	if err := pol.RequireAdmin(c); err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	var input struct {
 		AdvisorID string `json:"advisor_id"`
 	}
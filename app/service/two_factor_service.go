@@ -0,0 +1,489 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+	"UAS/app/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	totpIssuer             = "Prestasi Mahasiswa"
+	loginChallengeTTL      = 5 * time.Minute
+	loginChallengeAttempts = 5
+	recoveryCodeCount      = 10
+)
+
+// twoFactorChallengePurpose menentukan apakah login butuh langkah 2FA:
+// "totp" jika user sudah enroll, "enroll" jika role mewajibkan tapi belum enroll
+func twoFactorChallengePurpose(tfRepo *repository.TwoFactorRepository, user *model.User) (string, error) {
+	tf, err := tfRepo.GetByUserID(user.ID)
+	if err != nil {
+		return "", err
+	}
+	if tf != nil && tf.Enabled {
+		return model.ChallengeTOTP, nil
+	}
+
+	required, err := tfRepo.IsRequiredForRole(user.RoleID)
+	if err != nil {
+		return "", err
+	}
+	if required {
+		return model.ChallengeEnroll, nil
+	}
+	return "", nil
+}
+
+// verifyTOTP mengecek kode terhadap secret user dan mencegah kode yang sama dipakai dua kali
+func verifyTOTP(tfRepo *repository.TwoFactorRepository, tf *model.UserTwoFactor, code string) (bool, error) {
+	secret, err := utils.DecryptSecret(tf.SecretEnc)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return tfRepo.MarkStepUsed(tf.UserID, step)
+}
+
+// newRecoveryCodes membuat recovery code baru beserta hash-nya
+func newRecoveryCodes() (codes []string, hashes []string, err error) {
+	codes, err = utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, code := range codes {
+		hashes = append(hashes, utils.HashOpaqueToken(utils.NormalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+// startTOTPSetup membuat secret baru (belum aktif) dan mengembalikan data untuk QR code
+func startTOTPSetup(tfRepo *repository.TwoFactorRepository, user *model.User) (fiber.Map, error) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	enc, err := utils.EncryptSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := tfRepo.SavePendingSecret(user.ID, enc); err != nil {
+		return nil, err
+	}
+
+	return fiber.Map{
+		"secret":     secret,
+		"otpauthUri": utils.TOTPProvisioningURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// SetupTwoFactor godoc
+// @Summary Start 2FA enrollment
+// @Description Membuat secret TOTP baru (belum aktif). Scan otpauthUri dengan aplikasi authenticator lalu konfirmasi lewat /auth/2fa/enable.
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/2fa/setup [post]
+func SetupTwoFactor(c *fiber.Ctx, repo *repository.UserRepository, tfRepo *repository.TwoFactorRepository) error {
+	user, err := repo.GetUserByID(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user"})
+	}
+
+	tf, err := tfRepo.GetByUserID(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load 2FA"})
+	}
+	if tf != nil && tf.Enabled {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "2FA sudah aktif"})
+	}
+
+	data, err := startTOTPSetup(tfRepo, user)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to setup 2FA"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   data,
+	})
+}
+
+// EnableTwoFactor godoc
+// @Summary Confirm 2FA enrollment
+// @Description Mengaktifkan 2FA dengan kode TOTP pertama dan mengembalikan recovery code (hanya ditampilkan sekali)
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body model.TwoFactorCodeRequest true "Kode TOTP"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/2fa/enable [post]
+func EnableTwoFactor(c *fiber.Ctx, tfRepo *repository.TwoFactorRepository) error {
+	var body model.TwoFactorCodeRequest
+	if err := c.BodyParser(&body); err != nil || body.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "code is required"})
+	}
+
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user"})
+	}
+
+	tf, err := tfRepo.GetByUserID(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load 2FA"})
+	}
+	if tf == nil || tf.Enabled {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "tidak ada setup 2FA yang menunggu konfirmasi"})
+	}
+
+	ok, err := verifyTOTP(tfRepo, tf, body.Code)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to verify code"})
+	}
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "kode 2FA salah"})
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate recovery codes"})
+	}
+	if err := tfRepo.Enable(userID, hashes); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to enable 2FA"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"recoveryCodes": codes,
+		},
+	})
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable 2FA
+// @Description Menonaktifkan 2FA (wajib password dan kode TOTP). Ditolak jika role user mewajibkan 2FA.
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body model.DisableTwoFactorRequest true "Password dan kode TOTP"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /auth/2fa/disable [post]
+func DisableTwoFactor(c *fiber.Ctx, repo *repository.UserRepository, tfRepo *repository.TwoFactorRepository) error {
+	var body model.DisableTwoFactorRequest
+	if err := c.BodyParser(&body); err != nil || body.Password == "" || body.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "password and code are required"})
+	}
+
+	user, err := repo.GetUserByID(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user"})
+	}
+
+	required, err := tfRepo.IsRequiredForRole(user.RoleID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load 2FA policy"})
+	}
+	if required {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "2FA wajib untuk role ini"})
+	}
+
+	tf, err := tfRepo.GetByUserID(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load 2FA"})
+	}
+	if tf == nil || !tf.Enabled {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "2FA belum aktif"})
+	}
+
+	if !utils.CheckPassword(user.Password, body.Password) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "password salah"})
+	}
+
+	ok, err := verifyTOTP(tfRepo, tf, body.Code)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to verify code"})
+	}
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "kode 2FA salah"})
+	}
+
+	if err := tfRepo.Disable(user.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to disable 2FA"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "2FA dinonaktifkan",
+	})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate 2FA recovery codes
+// @Description Mengganti seluruh recovery code (kode lama tidak berlaku)
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body model.TwoFactorCodeRequest true "Kode TOTP"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *fiber.Ctx, tfRepo *repository.TwoFactorRepository) error {
+	var body model.TwoFactorCodeRequest
+	if err := c.BodyParser(&body); err != nil || body.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "code is required"})
+	}
+
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user"})
+	}
+
+	tf, err := tfRepo.GetByUserID(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load 2FA"})
+	}
+	if tf == nil || !tf.Enabled {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "2FA belum aktif"})
+	}
+
+	ok, err := verifyTOTP(tfRepo, tf, body.Code)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to verify code"})
+	}
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "kode 2FA salah"})
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate recovery codes"})
+	}
+	if err := tfRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save recovery codes"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"recoveryCodes": codes,
+		},
+	})
+}
+
+// SetupLoginTwoFactor godoc
+// @Summary Start mandatory 2FA enrollment during login
+// @Description Untuk challenge login dengan method "enroll": membuat secret TOTP sebelum user memiliki sesi
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.TwoFactorLoginRequest true "Challenge token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/login/2fa/setup [post]
+func SetupLoginTwoFactor(c *fiber.Ctx, repo *repository.UserRepository, tfRepo *repository.TwoFactorRepository) error {
+	var body model.TwoFactorLoginRequest
+	if err := c.BodyParser(&body); err != nil || body.ChallengeToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "challengeToken is required"})
+	}
+
+	ch, err := tfRepo.GetChallenge(utils.HashOpaqueToken(body.ChallengeToken))
+	if err != nil || ch.Purpose != model.ChallengeEnroll {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid or expired challenge"})
+	}
+
+	user, err := repo.GetUserByID(ch.UserID.String())
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user"})
+	}
+
+	data, err := startTOTPSetup(tfRepo, user)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to setup 2FA"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   data,
+	})
+}
+
+// VerifyLoginTwoFactor godoc
+// @Summary Complete login with 2FA
+// @Description Menyelesaikan login dengan kode TOTP atau recovery code. Untuk method "enroll", kode pertama sekaligus mengaktifkan 2FA dan recovery code dikembalikan. Kode yang salah dihitung sebagai login gagal (akun + IP) dan bisa mengunci akun.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.TwoFactorLoginRequest true "Challenge token dan kode"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]interface{}
+// @Router /auth/login/2fa [post]
+func VerifyLoginTwoFactor(
+	c *fiber.Ctx,
+	repo *repository.UserRepository,
+	refreshRepo *repository.RefreshTokenRepository,
+	tfRepo *repository.TwoFactorRepository,
+	attemptRepo *repository.LoginAttemptRepository,
+) error {
+	var body model.TwoFactorLoginRequest
+	if err := c.BodyParser(&body); err != nil || body.ChallengeToken == "" || (body.Code == "" && body.RecoveryCode == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "challengeToken and code or recoveryCode are required"})
+	}
+
+	ch, err := tfRepo.GetChallenge(utils.HashOpaqueToken(body.ChallengeToken))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid or expired challenge"})
+	}
+
+	user, err := repo.GetUserByID(ch.UserID.String())
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user"})
+	}
+	if !user.IsActive {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "akun tidak aktif"})
+	}
+
+	// Kode 2FA memakai hitungan gagal yang sama dengan password (akun + IP),
+	// jadi membuat challenge baru berulang kali tidak membuka jalan brute-force
+	email := strings.ToLower(strings.TrimSpace(user.Email))
+	ip := c.IP()
+	wait, err := loginRetryAfter(attemptRepo, email, ip)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "gagal memeriksa percobaan login"})
+	}
+	if wait > 0 {
+		return tooManyLoginAttempts(c, wait)
+	}
+
+	tf, err := tfRepo.GetByUserID(ch.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load 2FA"})
+	}
+	if tf == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "2FA belum di-setup"})
+	}
+
+	var ok bool
+	switch {
+	case ch.Purpose == model.ChallengeTOTP && tf.Enabled && body.Code != "":
+		ok, err = verifyTOTP(tfRepo, tf, body.Code)
+	case ch.Purpose == model.ChallengeTOTP && tf.Enabled:
+		ok, err = tfRepo.UseRecoveryCode(ch.UserID, utils.HashOpaqueToken(utils.NormalizeRecoveryCode(body.RecoveryCode)))
+	case ch.Purpose == model.ChallengeEnroll && !tf.Enabled && body.Code != "":
+		ok, err = verifyTOTP(tfRepo, tf, body.Code)
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "challenge tidak sesuai dengan status 2FA"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to verify code"})
+	}
+	if !ok {
+		_ = tfRepo.FailChallenge(ch.ID, loginChallengeAttempts)
+		recordLoginFailure(attemptRepo, email, ip)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "kode 2FA salah"})
+	}
+
+	consumed, err := tfRepo.ConsumeChallenge(ch.ID)
+	if err != nil || !consumed {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid or expired challenge"})
+	}
+
+	// Login baru benar-benar berhasil setelah 2FA -> hitungan gagal akun di-reset
+	_ = attemptRepo.Reset(model.LoginKeyAccount, email)
+
+	data := fiber.Map{}
+
+	// Enrollment wajib: kode pertama valid -> aktifkan 2FA
+	if ch.Purpose == model.ChallengeEnroll {
+		codes, hashes, err := newRecoveryCodes()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate recovery codes"})
+		}
+		if err := tfRepo.Enable(user.ID, hashes); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to enable 2FA"})
+		}
+		data["recoveryCodes"] = codes
+	}
+
+	token, refreshToken, err := issueSession(repo, refreshRepo, user)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "gagal generate token"})
+	}
+
+	for k, v := range sessionData(token, refreshToken, user) {
+		data[k] = v
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   data,
+	})
+}
+
+// SetRoleTwoFactorRequirement godoc
+// @Summary Set mandatory 2FA for a role
+// @Description Admin mengatur apakah user dengan role tertentu wajib memakai 2FA
+// @Tags Roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID (UUID)"
+// @Param body body object true "{\"required\": true}"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /roles/{id}/require-2fa [put]
+func SetRoleTwoFactorRequirement(c *fiber.Ctx, tfRepo *repository.TwoFactorRepository) error {
+	roleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid role ID"})
+	}
+
+	var body struct {
+		Required bool `json:"required"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if err := tfRepo.SetRoleRequirement(roleID, body.Required); err != nil {
+		if err.Error() == "role not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "role not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update role"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"roleId":     roleID,
+			"require2fa": body.Required,
+		},
+	})
+}
@@ -2,6 +2,9 @@
 package service
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 	
 	"UAS/app/repository"
@@ -118,6 +121,10 @@ func CreateUser(c *fiber.Ctx, repo *repository.UserRepository) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
 	}
 
+	if violations := utils.Policy.Validate(input.Password); len(violations) > 0 {
+		return passwordPolicyError(c, violations)
+	}
+
 	hashedPassword, err := utils.HashPassword(input.Password)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "hash failed"})
@@ -243,6 +250,17 @@ func UpdateUser(c *fiber.Ctx, repo *repository.UserRepository) error {
 		})
 	}
 
+	// Role berubah -> UpdateUser menaikkan token_version: access token lama (berisi role
+	// lama) ditolak dan client cukup refresh untuk mendapat role baru.
+	// User dinonaktifkan -> semua sesi yang masih aktif dicabut
+	if !updatedUser.IsActive {
+		if err := repo.RevokeAllSessions(updatedUser.ID); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to revoke user sessions",
+			})
+		}
+	}
+
 	return c.JSON(fiber.Map{
 		"status": "success",
 		"data": map[string]interface{}{
@@ -332,6 +350,16 @@ func UpdatePassword(c *fiber.Ctx, repo *repository.UserRepository) error {
 		})
 	}
 
+	violations, err := validatePasswordPolicy(repo, userID, body.Password)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to check password policy",
+		})
+	}
+	if len(violations) > 0 {
+		return passwordPolicyError(c, violations)
+	}
+
 	// Hash password
 	hashedPassword, err := utils.HashPassword(body.Password)
 	if err != nil {
@@ -353,6 +381,13 @@ func UpdatePassword(c *fiber.Ctx, repo *repository.UserRepository) error {
 		})
 	}
 
+	// Password berubah -> semua sesi lama harus login ulang
+	if err := repo.RevokeAllSessions(userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to revoke user sessions",
+		})
+	}
+
 	// Response tanpa mengirim password
 	return c.JSON(fiber.Map{
 		"status": "success",
@@ -367,6 +402,153 @@ func UpdatePassword(c *fiber.Ctx, repo *repository.UserRepository) error {
 	})
 }
 
+// validatePasswordPolicy mengecek aturan password dan larangan memakai ulang
+// password lama milik user
+func validatePasswordPolicy(repo *repository.UserRepository, userID uuid.UUID, password string) ([]string, error) {
+	violations := utils.Policy.Validate(password)
+
+	if utils.Policy.HistorySize > 0 {
+		hashes, err := repo.GetRecentPasswordHashes(userID, utils.Policy.HistorySize)
+		if err != nil {
+			return nil, err
+		}
+		if utils.Policy.IsReused(password, hashes) {
+			violations = append(violations, fmt.Sprintf("password tidak boleh sama dengan %d password terakhir", utils.Policy.HistorySize))
+		}
+	}
+
+	return violations, nil
+}
+
+func passwordPolicyError(c *fiber.Ctx, violations []string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error":   "password tidak memenuhi policy",
+		"details": violations,
+	})
+}
+
+// RevokeUserSessions godoc
+// @Summary Revoke all sessions of a user
+// @Description Admin mencabut semua access token dan refresh token milik user lain
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID (UUID)"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/revoke-sessions [post]
+func RevokeUserSessions(c *fiber.Ctx, repo *repository.UserRepository) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user ID",
+		})
+	}
+
+	if err := repo.RevokeAllSessions(userID); err != nil {
+		if err.Error() == "user not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "user not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to revoke user sessions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+	})
+}
+
+// GetLoginLockouts godoc
+// @Summary Get login lockouts
+// @Description Admin melihat akun / IP yang sedang terkunci beserta riwayat penguncian
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/lockouts [get]
+func GetLoginLockouts(c *fiber.Ctx, attemptRepo *repository.LoginAttemptRepository) error {
+	active, err := attemptRepo.GetActiveLockouts()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "gagal mengambil data lockout",
+		})
+	}
+
+	history, err := attemptRepo.GetLockoutHistory(100)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "gagal mengambil riwayat lockout",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"active":  active,
+			"history": history,
+		},
+	})
+}
+
+// ClearLoginLockout godoc
+// @Summary Clear login lockout
+// @Description Admin membuka kunci login untuk akun (email) atau IP
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body object true "Lockout payload (type: account/ip, key: email atau IP)"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/lockouts [delete]
+func ClearLoginLockout(c *fiber.Ctx, attemptRepo *repository.LoginAttemptRepository) error {
+	var body struct {
+		Type string `json:"type"`
+		Key  string `json:"key"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.Key == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	key := body.Key
+	switch body.Type {
+	case model.LoginKeyAccount:
+		key = strings.ToLower(strings.TrimSpace(key))
+	case model.LoginKeyIP:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "type must be account or ip",
+		})
+	}
+
+	adminID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user"})
+	}
+
+	if err := attemptRepo.ClearLockout(body.Type, key, adminID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "gagal membuka lockout",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+	})
+}
+
 // Profile godoc
 // @Summary Get current user profile
 // @Description Mengambil profil user yang sedang login
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"strings"
+
+	"UAS/app/model"
+	"UAS/app/policy"
+	"UAS/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetPendingVerifications godoc
+// @Summary Advisor verification queue
+// @Description Prestasi mahasiswa bimbingan yang menunggu verifikasi dosen wali. Default urut dari yang paling lama menunggu; counts berisi jumlah seluruh antrean (untuk badge), tidak terpengaruh filter.
+// @Tags Verifications
+// @Security BearerAuth
+// @Produce json
+// @Param sort query string false "oldest (default) atau newest"
+// @Param type query string false "Filter achievementType"
+// @Param programStudy query string false "Filter program studi mahasiswa"
+// @Param page query int false "Halaman (default 1)"
+// @Param limit query int false "Jumlah per halaman (default 20, maks 100)"
+// @Success 200 {array} model.PendingVerification
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /verifications/pending [get]
+func GetPendingVerifications(
+	c *fiber.Ctx,
+	queue *repository.VerificationQueueRepository,
+	achievementRepo *repository.AchievementRepository,
+	pol *policy.Policy,
+) error {
+
+	actor, err := pol.Actor(c)
+	if err != nil {
+		return c.Status(policy.Status(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !actor.IsLecturer() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "only advisors have a verification queue"})
+	}
+
+	sort := strings.ToLower(c.Query("sort", "oldest"))
+	if sort != "oldest" && sort != "newest" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "sort must be oldest or newest"})
+	}
+
+	page, limit, offset := parsePagination(c)
+	filter := model.VerificationQueueFilter{
+		AdvisorID:       actor.Lecturer.ID,
+		AchievementType: strings.TrimSpace(c.Query("type")),
+		ProgramStudy:    strings.TrimSpace(c.Query("programStudy")),
+		NewestFirst:     sort == "newest",
+		Limit:           limit,
+		Offset:          offset,
+	}
+
+	items, total, err := queue.Pending(filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// pengajuan lama (sebelum putaran dicatat) belum punya snapshot: ambil dari MongoDB
+	for i := range items {
+		if items[i].Title != "" {
+			continue
+		}
+		if a, err := achievementRepo.GetByID(items[i].AchievementID); err == nil && a != nil {
+			items[i].Title = a.Title
+			items[i].AchievementType = a.AchievementType
+		}
+	}
+
+	counts, err := queue.Counts(actor.Lecturer.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"data":       items,
+		"counts":     counts,
+		"pagination": model.NewPagination(page, limit, total),
+	})
+}
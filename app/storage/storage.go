@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound            = errors.New("object not found")
+	ErrInvalidKey          = errors.New("invalid storage key")
+	ErrInvalidSignature    = errors.New("invalid or expired signature")
+	ErrRangeNotSatisfiable = errors.New("requested range not satisfiable")
+)
+
+// ObjectInfo adalah metadata objek yang tersimpan
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// Storage menyimpan file lampiran. Key selalu berupa path relatif dengan "/"
+// (mis. "achievements/<id>/<file>.pdf") sehingga bisa dipindah antar driver.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// GetRange membaca length byte mulai dari offset (untuk HTTP range request)
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	// PresignedURL membuat link sementara yang bisa dibuka tanpa token login
+	PresignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// CleanKey menolak key absolut atau yang keluar dari root ("..")
+func CleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") || cleaned != key {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// AttachmentKey adalah key untuk lampiran prestasi
+func AttachmentKey(achievementID, fileName string) string {
+	return path.Join("achievements", achievementID, fileName)
+}
+
+// ByteRange adalah satu rentang byte hasil parsing header Range
+type ByteRange struct {
+	Start  int64
+	Length int64
+}
+
+// ContentRange mengembalikan nilai header Content-Range untuk objek berukuran size
+func (r ByteRange) ContentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.Start, r.Start+r.Length-1, size)
+}
+
+// ParseRange mem-parsing header Range ("bytes=0-99", "bytes=100-", "bytes=-500").
+// Hasil nil berarti kirim seluruh file: header kosong, bukan satuan bytes, atau
+// multi-range (boleh diabaikan menurut RFC 9110).
+func ParseRange(header string, size int64) (*ByteRange, error) {
+	if header == "" || !strings.HasPrefix(header, "bytes=") {
+		return nil, nil
+	}
+	spec := strings.TrimSpace(strings.TrimPrefix(header, "bytes="))
+	if strings.Contains(spec, ",") {
+		return nil, nil
+	}
+
+	first, last, ok := strings.Cut(spec, "-")
+	if !ok {
+		return nil, ErrRangeNotSatisfiable
+	}
+	first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+	// suffix range: N byte terakhir
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 || size == 0 {
+			return nil, ErrRangeNotSatisfiable
+		}
+		if n > size {
+			n = size
+		}
+		return &ByteRange{Start: size - n, Length: n}, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return nil, ErrRangeNotSatisfiable
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return nil, ErrRangeNotSatisfiable
+		}
+		if end > size-1 {
+			end = size - 1
+		}
+	}
+
+	return &ByteRange{Start: start, Length: end - start + 1}, nil
+}
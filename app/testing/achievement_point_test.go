@@ -0,0 +1,173 @@
+package repository_test
+
+import (
+	"testing"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func intPtr(n int) *int       { return &n }
+func strPtr(s string) *string { return &s }
+
+func competitionRules() []model.PointRule {
+	return []model.PointRule{
+		{ID: uuid.New(), TypeCode: "competition", Label: "Kompetisi (umum)", Points: 5},
+		{ID: uuid.New(), TypeCode: "competition", Level: strPtr("national"), RankFrom: intPtr(1), RankTo: intPtr(1), Label: "Nasional - Juara 1", Points: 60},
+		{ID: uuid.New(), TypeCode: "competition", Level: strPtr("national"), RankFrom: intPtr(4), Label: "Nasional - Finalis", Points: 25},
+		{ID: uuid.New(), TypeCode: "publication", Label: "Publikasi", Points: 50},
+	}
+}
+
+func TestCalculatePoints_MostSpecificRuleWins(t *testing.T) {
+	rules := competitionRules()
+
+	award := model.CalculatePoints(rules, "competition", bson.M{"level": "national", "rank": int32(1)})
+	assert.Equal(t, 60, award.Points)
+	assert.Equal(t, rules[1].ID, *award.RuleID)
+
+	// finalis: rank 4 ke atas
+	award = model.CalculatePoints(rules, "competition", bson.M{"level": "national", "rank": float64(7)})
+	assert.Equal(t, 25, award.Points)
+
+	// level tanpa aturan khusus jatuh ke aturan umum
+	award = model.CalculatePoints(rules, "competition", bson.M{"level": "local", "rank": 2})
+	assert.Equal(t, 5, award.Points)
+}
+
+func TestCalculatePoints_NoMatchingRule(t *testing.T) {
+	award := model.CalculatePoints(competitionRules(), "certification", bson.M{"issuer": "Cisco"})
+
+	assert.Equal(t, 0, award.Points)
+	assert.Nil(t, award.RuleID)
+}
+
+func TestRecalculatePoints_UpdatesAuditsAndEnqueues(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+	ruleID := uuid.New()
+	adminID := "8f14e45f-ceea-4e7a-9b1e-2b9a6c1d3e4f"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT points").
+		WithArgs("665f1c2e9b1e8a3d4c5b6a79").
+		WillReturnRows(sqlmock.NewRows([]string{"points"}).AddRow(40))
+	mock.ExpectExec("UPDATE achievement_references SET points").
+		WithArgs(60, sqlmock.AnyArg(), "665f1c2e9b1e8a3d4c5b6a79").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO achievement_point_audit").
+		WithArgs(sqlmock.AnyArg(), "665f1c2e9b1e8a3d4c5b6a79", 40, 60, &ruleID, model.PointReasonRuleChange, "", adminID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO outbox_events").
+		WithArgs("665f1c2e9b1e8a3d4c5b6a79", model.OutboxAchievementPoints, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(9)))
+	mock.ExpectCommit()
+
+	changed, err := repo.RecalculatePoints("665f1c2e9b1e8a3d4c5b6a79", model.PointAward{Points: 60, RuleID: &ruleID}, model.PointReasonRuleChange, adminID)
+
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecalculatePoints_UnchangedIsNoop(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT points").
+		WillReturnRows(sqlmock.NewRows([]string{"points"}).AddRow(60))
+	mock.ExpectRollback()
+
+	changed, err := repo.RecalculatePoints("665f1c2e9b1e8a3d4c5b6a79", model.PointAward{Points: 60}, model.PointReasonRuleChange, "")
+
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyTransition_VerifyStoresPoints(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+	tr := newTransition(model.EventVerify, model.StatusSubmitted, model.StatusVerified)
+	tr.Award = &model.PointAward{Points: 60}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE achievement_references").
+		WithArgs(sqlmock.AnyArg(), model.StatusVerified, tr.ActorID, 60, false, tr.AchievementID, model.StatusSubmitted).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO achievement_status_history").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE achievement_rounds").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO achievement_point_audit").
+		WithArgs(sqlmock.AnyArg(), tr.AchievementID, 0, 60, nil, model.PointReasonVerify, "", tr.ActorID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO outbox_events").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(4)))
+	mock.ExpectCommit()
+
+	err := repo.ApplyTransition(tr)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyTransition_VerifyOverrideAuditsJustification(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+	tr := newTransition(model.EventVerify, model.StatusSubmitted, model.StatusVerified)
+	tr.Award = &model.PointAward{Points: 45, Calculated: 60, Overridden: true, Justification: "tingkat lomba regional"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE achievement_references").
+		WithArgs(sqlmock.AnyArg(), model.StatusVerified, tr.ActorID, 45, true, tr.AchievementID, model.StatusSubmitted).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO achievement_status_history").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE achievement_rounds").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO achievement_point_audit").
+		WithArgs(sqlmock.AnyArg(), tr.AchievementID, 0, 45, nil, model.PointReasonOverride, "calculated 60: tingkat lomba regional", tr.ActorID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO outbox_events").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(5)))
+	mock.ExpectCommit()
+
+	err := repo.ApplyTransition(tr)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetVerifiedIDsForRecalculation_SelectsFromPostgres(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+
+	mock.ExpectQuery(`SELECT r.mongo_achievement_id FROM achievement_references r LEFT JOIN achievement_rounds ar .* WHERE r.status = 'verified' AND r.deleted_at IS NULL AND r.points_overridden = FALSE AND \(ar.id IS NULL OR ar.snapshot->>'achievementType' = \$1\) AND r.mongo_achievement_id > \$2 ORDER BY r.mongo_achievement_id LIMIT \$3`).
+		WithArgs("competition", "665f1c2e9b1e8a3d4c5b6a70", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id"}).
+			AddRow("665f1c2e9b1e8a3d4c5b6a79").
+			AddRow("665f1c2e9b1e8a3d4c5b6a80"))
+
+	ids, err := repo.GetVerifiedIDsForRecalculation("competition", "665f1c2e9b1e8a3d4c5b6a70", 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"665f1c2e9b1e8a3d4c5b6a79", "665f1c2e9b1e8a3d4c5b6a80"}, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,285 @@
+package repository_test
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTransition(event model.AchievementEvent, from, to model.AchievementStatus) model.AchievementTransition {
+	return model.AchievementTransition{
+		AchievementID: "665f1c2e9b1e8a3d4c5b6a79",
+		Event:         event,
+		From:          from,
+		To:            to,
+		ActorID:       "8f14e45f-ceea-4e7a-9b1e-2b9a6c1d3e4f",
+		At:            time.Now(),
+	}
+}
+
+func TestApplyTransition_WritesOutboxInSameTransaction(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+	tr := newTransition(model.EventSubmit, model.StatusDraft, model.StatusSubmitted)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE achievement_references").
+		WithArgs(sqlmock.AnyArg(), "submitted", tr.AchievementID, "draft").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO achievement_status_history").
+		WithArgs(sqlmock.AnyArg(), tr.AchievementID, model.EventSubmit, "draft", model.StatusSubmitted, tr.ActorID, "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO achievement_rounds").
+		WithArgs(sqlmock.AnyArg(), tr.AchievementID, sqlmock.AnyArg(), tr.ActorID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO outbox_events").
+		WithArgs(tr.AchievementID, "achievement.status", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(1)))
+	mock.ExpectCommit()
+
+	err := repo.ApplyTransition(tr)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyTransition_OutboxFailureRollsBack(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE achievement_references").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO achievement_status_history").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO achievement_rounds").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO outbox_events").
+		WillReturnError(errors.New("db down"))
+	mock.ExpectRollback()
+
+	err := repo.ApplyTransition(newTransition(model.EventSubmit, model.StatusDraft, model.StatusSubmitted))
+
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyTransition_StatusChangedConcurrently(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+	tr := newTransition(model.EventReject, model.StatusSubmitted, model.StatusRejected)
+	tr.Note = "bukti kurang"
+
+	// status sudah verified -> WHERE status = 'submitted' tidak cocok
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE achievement_references").
+		WithArgs(sqlmock.AnyArg(), "rejected", tr.ActorID, tr.Note, tr.AchievementID, "submitted").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.ApplyTransition(tr)
+
+	assert.ErrorIs(t, err, repository.ErrTransitionConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyTransition_RejectClosesRound(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+	tr := newTransition(model.EventReject, model.StatusSubmitted, model.StatusRejected)
+	tr.Note = "sertifikat tidak terbaca"
+	tr.Reasons = []string{"unreadable_evidence"}
+	tr.FieldComments = []model.FieldComment{{Field: "attachments", Comment: "unggah ulang dengan resolusi lebih tinggi"}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE achievement_references").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO achievement_status_history").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE achievement_rounds").
+		WithArgs(model.StatusRejected, tr.ActorID, sqlmock.AnyArg(), tr.Note,
+			[]byte(`["unreadable_evidence"]`),
+			[]byte(`[{"field":"attachments","comment":"unggah ulang dengan resolusi lebih tinggi"}]`),
+			tr.AchievementID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO outbox_events").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(2)))
+	mock.ExpectCommit()
+
+	err := repo.ApplyTransition(tr)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLastRejectedRound(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery("FROM achievement_rounds").
+		WithArgs("665f1c2e9b1e8a3d4c5b6a79").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "round", "snapshot", "submitted_by", "submitted_at", "outcome", "reviewed_by", "reviewed_at", "rejection_note",
+			"rejection_reasons", "field_comments",
+		}).AddRow(uuid.New(), 2, []byte(`{"title":"Juara 2 Hackathon","details":{"rank":2}}`), nil, now, "rejected", uuid.New(), now, "bukti kurang",
+			[]byte(`["missing_evidence"]`), []byte(`[{"field":"details.rank","comment":"tidak sesuai sertifikat"}]`)))
+
+	round, err := repo.GetLastRejectedRound("665f1c2e9b1e8a3d4c5b6a79")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, round.Round)
+	assert.Equal(t, "Juara 2 Hackathon", round.Snapshot.Title)
+	assert.Equal(t, "bukti kurang", *round.RejectionNote)
+	assert.Equal(t, []string{"missing_evidence"}, round.RejectionReasons)
+	assert.Equal(t, "details.rank", round.FieldComments[0].Field)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLastRejectedRound_NotFound(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+
+	mock.ExpectQuery("FROM achievement_rounds").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := repo.GetLastRejectedRound("665f1c2e9b1e8a3d4c5b6a79")
+
+	assert.EqualError(t, err, "rejected round not found")
+}
+
+func TestCreateReference_RecordsInitialHistory(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+	studentID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO achievement_references").
+		WithArgs(sqlmock.AnyArg(), studentID, "665f1c2e9b1e8a3d4c5b6a79", "draft", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "student_id", "mongo_achievement_id", "status", "submitted_at", "verified_at", "verified_by", "rejection_note", "created_at", "updated_at",
+		}).AddRow(uuid.New(), studentID, "665f1c2e9b1e8a3d4c5b6a79", "draft", nil, nil, nil, nil, now, now))
+	mock.ExpectExec("INSERT INTO achievement_status_history").
+		WithArgs(sqlmock.AnyArg(), "665f1c2e9b1e8a3d4c5b6a79", model.EventCreate, "", model.StatusDraft, "8f14e45f-ceea-4e7a-9b1e-2b9a6c1d3e4f", "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ref, err := repo.CreateReference(studentID, "665f1c2e9b1e8a3d4c5b6a79", "8f14e45f-ceea-4e7a-9b1e-2b9a6c1d3e4f")
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusDraft, ref.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReferenceHistory_Paginated(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+	actorID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM achievement_status_history").
+		WithArgs("665f1c2e9b1e8a3d4c5b6a79").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("FROM achievement_status_history").
+		WithArgs("665f1c2e9b1e8a3d4c5b6a79", 2, 0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "event", "from_status", "to_status", "actor_id", "actor_role", "note", "created_at",
+		}).
+			AddRow(uuid.New(), "create", nil, "draft", actorID, "Mahasiswa", nil, now).
+			AddRow(uuid.New(), "submit", "draft", "submitted", actorID, "Mahasiswa", nil, now.Add(time.Minute)))
+
+	history, total, err := repo.History("665f1c2e9b1e8a3d4c5b6a79", 2, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, history, 2)
+	assert.Nil(t, history[0].FromStatus)
+	assert.Equal(t, "draft", *history[1].FromStatus)
+	assert.Equal(t, "Mahasiswa", *history[1].ActorRole)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxMarkFailed_SchedulesRetry(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewOutboxRepository(db)
+
+	mock.ExpectExec("UPDATE outbox_events").
+		WithArgs("mongo timeout", sqlmock.AnyArg(), 20, "dead", int64(7), "pending").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.MarkFailed(7, errors.New("mongo timeout"), time.Second, 20)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReferenceEnqueueStatusSync_UsesCurrentRow(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewAchievementReferenceRepository(db)
+	mongoID := "665f1c2e9b1e8a3d4c5b6a79"
+	now := time.Now()
+
+	// baris terkini sudah verified dengan 40 poin, apa pun yang dilihat pemanggil sebelumnya
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .* FROM achievement_references WHERE mongo_achievement_id = \\$1 FOR UPDATE").
+		WithArgs(mongoID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "student_id", "mongo_achievement_id", "status", "submitted_at", "verified_at", "verified_by",
+			"rejection_note", "deleted_at", "submission_round", "points", "created_at", "updated_at",
+		}).AddRow(uuid.New(), uuid.New(), mongoID, "verified", now, now, nil, nil, nil, 1, 40, now, now))
+	mock.ExpectQuery("INSERT INTO outbox_events").
+		WithArgs(mongoID, "achievement.status", jsonContains{`"status":"verified"`, `"points":40`}).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(3)))
+	mock.ExpectCommit()
+
+	err := repo.EnqueueStatusSync(mongoID)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// jsonContains mencocokkan argumen JSON ([]byte) yang memuat semua potongan teks
+type jsonContains []string
+
+func (j jsonContains) Match(v driver.Value) bool {
+	b, ok := v.([]byte)
+	if !ok {
+		return false
+	}
+	for _, part := range j {
+		if !strings.Contains(string(b), part) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package repository_test
+
+import (
+	"testing"
+
+	"UAS/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func fieldsOf(errs []model.FieldError) []string {
+	fields := []string{}
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	return fields
+}
+
+func TestValidateAchievementCreate_Competition(t *testing.T) {
+	set, errs := model.ValidateAchievementCreate(map[string]interface{}{
+		"achievementType": "competition",
+		"title":           "  Juara 1 Gemastik  ",
+		"tags":            []interface{}{"IT", "it", "nasional"},
+		"details": map[string]interface{}{
+			"level":     "National",
+			"rank":      float64(1),
+			"organizer": "Puspresnas",
+			"date":      "2024-10-12",
+		},
+	})
+
+	assert.Empty(t, errs)
+	assert.Equal(t, "Juara 1 Gemastik", set["title"])
+	assert.Equal(t, []string{"IT", "nasional"}, set["tags"])
+	assert.Equal(t, bson.M{"level": "national", "rank": 1, "organizer": "Puspresnas", "date": "2024-10-12"}, set["details"])
+}
+
+func TestValidateAchievementCreate_FieldErrors(t *testing.T) {
+	_, errs := model.ValidateAchievementCreate(map[string]interface{}{
+		"achievementType": "competition",
+		"title":           "Lomba",
+		"status":          "verified",
+		"points":          float64(100),
+		"details": map[string]interface{}{
+			"level": "galaxy",
+			"rank":  float64(1.5),
+			"date":  "12-10-2024",
+			"prize": "10jt",
+		},
+	})
+
+	assert.Equal(t, []string{
+		"points",
+		"status",
+		"details.prize",
+		"details.date",
+		"details.level",
+		"details.organizer",
+		"details.rank",
+	}, fieldsOf(errs))
+}
+
+func TestValidateAchievementCreate_UnknownType(t *testing.T) {
+	_, errs := model.ValidateAchievementCreate(map[string]interface{}{
+		"achievementType": "hobby",
+		"title":           "Mancing",
+	})
+
+	assert.Equal(t, []string{"achievementType"}, fieldsOf(errs))
+}
+
+func TestValidateAchievementCreate_OtherSchemas(t *testing.T) {
+	cases := []map[string]interface{}{
+		{"achievementType": "publication", "title": "Paper", "details": map[string]interface{}{"venue": "ICACSIS", "doi": "https://doi.org/10.1109/ICACSIS.2023.1"}},
+		{"achievementType": "certification", "title": "AWS", "details": map[string]interface{}{"issuer": "Amazon", "expiry": "2027-01-31"}},
+		{"achievementType": "organization", "title": "BEM", "details": map[string]interface{}{"position": "Ketua", "period": map[string]interface{}{"start": "2023-01-01"}}},
+	}
+
+	for _, input := range cases {
+		_, errs := model.ValidateAchievementCreate(input)
+		assert.Empty(t, errs, input["achievementType"])
+	}
+
+	_, errs := model.ValidateAchievementCreate(map[string]interface{}{
+		"achievementType": "organization",
+		"title":           "BEM",
+		"details": map[string]interface{}{
+			"position": "Ketua",
+			"period":   map[string]interface{}{"start": "2024-01-01", "end": "2023-01-01"},
+		},
+	})
+	assert.Equal(t, []string{"details.period"}, fieldsOf(errs))
+}
+
+func TestValidateAchievementUpdate_Partial(t *testing.T) {
+	current := &model.Achievement{
+		AchievementType: "certification",
+		Title:           "AWS",
+		Details:         bson.M{"issuer": "Amazon"},
+	}
+
+	set, errs := model.ValidateAchievementUpdate(map[string]interface{}{"title": "AWS Solutions Architect"}, current)
+	assert.Empty(t, errs)
+	assert.Equal(t, bson.M{"title": "AWS Solutions Architect"}, set)
+
+	// ganti tipe tanpa details baru: details lama tidak cocok dengan schema competition
+	_, errs = model.ValidateAchievementUpdate(map[string]interface{}{"achievementType": "competition"}, current)
+	assert.Contains(t, fieldsOf(errs), "details.issuer")
+	assert.Contains(t, fieldsOf(errs), "details.level")
+
+	_, errs = model.ValidateAchievementUpdate(map[string]interface{}{"studentId": "20221030999"}, current)
+	assert.Equal(t, []string{"studentId"}, fieldsOf(errs))
+}
@@ -0,0 +1,106 @@
+package repository_test
+
+import (
+	"testing"
+
+	"UAS/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNextStatus_AllowedTransitions(t *testing.T) {
+	cases := []struct {
+		from  model.AchievementStatus
+		event model.AchievementEvent
+		to    model.AchievementStatus
+	}{
+		{model.StatusDraft, model.EventSubmit, model.StatusSubmitted},
+		{model.StatusSubmitted, model.EventVerify, model.StatusVerified},
+		{model.StatusSubmitted, model.EventReject, model.StatusRejected},
+		{model.StatusRejected, model.EventRevise, model.StatusDraft},
+		{model.StatusDraft, model.EventDelete, model.StatusDeleted},
+	}
+
+	for _, tc := range cases {
+		to, err := model.NextStatus(tc.from, tc.event)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.to, to)
+	}
+}
+
+func TestNextStatus_RejectsInvalidTransitions(t *testing.T) {
+	_, err := model.NextStatus(model.StatusVerified, model.EventReject)
+	assert.EqualError(t, err, "cannot reject achievement with status verified")
+
+	_, err = model.NextStatus(model.StatusSubmitted, model.EventDelete)
+	assert.Error(t, err)
+
+	_, err = model.NextStatus(model.StatusDeleted, model.EventSubmit)
+	assert.Error(t, err)
+
+	_, err = model.NextStatus(model.StatusDraft, model.AchievementEvent("archive"))
+	assert.EqualError(t, err, `unknown achievement event "archive"`)
+}
+
+func TestDiffSnapshots_SinceRejection(t *testing.T) {
+	rejected := model.AchievementSnapshot{
+		Title:   "Juara 2 Hackathon",
+		Details: map[string]interface{}{"rank": float64(2), "organizer": "Kemdikbud"},
+		Tags:    []string{"it"},
+	}
+
+	// details dari MongoDB (int32) harus dianggap sama dengan hasil JSON (float64)
+	current := model.SnapshotOf(&model.Achievement{
+		Title:   "Juara 2 Hackathon Nasional",
+		Details: bson.M{"rank": int32(2), "organizer": "Kemdikbudristek", "level": "national"},
+		Tags:    []string{"it"},
+	})
+
+	changes := model.DiffSnapshots(rejected, current)
+
+	fields := []string{}
+	for _, ch := range changes {
+		fields = append(fields, ch.Field)
+	}
+	assert.Equal(t, []string{"title", "details.level", "details.organizer"}, fields)
+}
+
+func TestDiffSnapshots_NoChanges(t *testing.T) {
+	a := &model.Achievement{Title: "Lomba", Details: bson.M{"rank": 1}}
+
+	assert.Empty(t, model.DiffSnapshots(model.SnapshotOf(a), model.SnapshotOf(a)))
+}
+
+func TestRestoreInput_KeepsAttachmentsAndPointsOut(t *testing.T) {
+	snapshot := model.SnapshotOf(&model.Achievement{
+		AchievementType: model.TypeCompetition,
+		Title:           "Juara 1 Lomba Debat",
+		Details:         bson.M{"level": "national", "rank": 1, "organizer": "Kemdikbud", "date": "2024-05-01"},
+		Attachments:     []model.Attachment{{FileName: "sertifikat.pdf"}},
+		Points:          60,
+	})
+
+	input := model.RestoreInput(snapshot)
+
+	assert.NotContains(t, input, "attachments")
+	assert.NotContains(t, input, "status")
+	assert.NotContains(t, input, "points")
+
+	// lolos validasi edit yang sama dengan UpdateAchievement
+	set, errs := model.ValidateAchievementUpdate(input, &model.Achievement{AchievementType: model.TypeCompetition})
+	assert.Empty(t, errs)
+	assert.Equal(t, "Juara 1 Lomba Debat", set["title"])
+	assert.Equal(t, []string{}, set["tags"])
+}
+
+func TestRestoreInput_RejectsSnapshotInvalidUnderCurrentSchema(t *testing.T) {
+	snapshot := model.SnapshotOf(&model.Achievement{
+		AchievementType: model.TypeCompetition,
+		Title:           "Lomba lama",
+		Details:         bson.M{"rank": 1},
+	})
+
+	_, errs := model.ValidateAchievementUpdate(model.RestoreInput(snapshot), &model.Achievement{AchievementType: model.TypeCompetition})
+	assert.NotEmpty(t, errs)
+}
@@ -0,0 +1,211 @@
+package repository_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+	"UAS/app/scanner"
+	"UAS/app/service"
+	"UAS/app/storage"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	pdfBytes = []byte("%PDF-1.7\n1 0 obj << /Type /Catalog >> endobj\n%%EOF")
+	exeBytes = append([]byte("MZ\x90\x00\x03\x00\x00\x00"), bytes.Repeat([]byte{0}, 64)...)
+)
+
+func formFile(t *testing.T, name string, content []byte) *multipart.FileHeader {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile("file", name)
+	require.NoError(t, err)
+	part.Write(content)
+	w.Close()
+
+	form, err := multipart.NewReader(&buf, w.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	return form.File["file"][0]
+}
+
+type stubScanner struct {
+	result scanner.Result
+	err    error
+}
+
+func (s stubScanner) Scan(ctx context.Context, r io.Reader) (scanner.Result, error) {
+	io.Copy(io.Discard, r)
+	return s.result, s.err
+}
+
+func TestSniffAttachment(t *testing.T) {
+	ct, err := model.SniffAttachment(".pdf", pdfBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, "application/pdf", ct)
+
+	ct, err = model.SniffAttachment(".PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", ct)
+
+	_, err = model.SniffAttachment(".pdf", exeBytes)
+	assert.ErrorIs(t, err, model.ErrFileContentMismatch)
+
+	_, err = model.SniffAttachment(".jpg", pdfBytes)
+	assert.ErrorIs(t, err, model.ErrFileContentMismatch)
+
+	_, err = model.SniffAttachment(".exe", exeBytes)
+	assert.ErrorIs(t, err, model.ErrUnsupportedFileType)
+}
+
+func newTestAttachmentManager(t *testing.T, scan scanner.Scanner) (*service.AttachmentManager, *storage.LocalStorage, sqlmock.Sqlmock) {
+	db, mock, _ := sqlmock.New()
+	t.Cleanup(func() { db.Close() })
+
+	local := storage.NewLocalStorage(t.TempDir(), "/api/v1/files", []byte("secret"))
+	uploader := service.NewAttachmentManager(nil, local, scan, repository.NewQuarantineRepository(db), nil, 1024, 2)
+	return uploader, local, mock
+}
+
+func uploadStatus(err error) int {
+	var rejected *service.UploadError
+	if errors.As(err, &rejected) {
+		return rejected.Status
+	}
+	return 0
+}
+
+func TestAttachmentManager_RejectsBeforeStoring(t *testing.T) {
+	uploader, _, _ := newTestAttachmentManager(t, scanner.NoopScanner{})
+	achievement := &model.Achievement{ID: primitive.NewObjectID()}
+
+	_, err := uploader.Upload(context.Background(), achievement, formFile(t, "sertifikat.pdf", exeBytes), "")
+	assert.Equal(t, 400, uploadStatus(err), "renamed executable")
+
+	_, err = uploader.Upload(context.Background(), achievement, formFile(t, "besar.pdf", append(pdfBytes, bytes.Repeat([]byte("x"), 2048)...)), "")
+	assert.Equal(t, 413, uploadStatus(err), "size limit")
+
+	achievement.Attachments = []model.Attachment{{FileName: "lama.pdf", Checksum: checksumOf(pdfBytes)}}
+	_, err = uploader.Upload(context.Background(), achievement, formFile(t, "baru.pdf", pdfBytes), "")
+	assert.Equal(t, 409, uploadStatus(err), "duplicate")
+
+	achievement.Attachments = []model.Attachment{{ID: "a"}, {ID: "b"}}
+	_, err = uploader.Upload(context.Background(), achievement, formFile(t, "ketiga.pdf", pdfBytes), "")
+	assert.Equal(t, 409, uploadStatus(err), "count limit")
+}
+
+func TestAttachmentManager_ScannerUnavailableFailsClosed(t *testing.T) {
+	uploader, _, _ := newTestAttachmentManager(t, stubScanner{err: errors.New("connection refused")})
+	achievement := &model.Achievement{ID: primitive.NewObjectID()}
+
+	_, err := uploader.Upload(context.Background(), achievement, formFile(t, "sertifikat.pdf", pdfBytes), "")
+	assert.Equal(t, 503, uploadStatus(err))
+}
+
+func TestAttachmentManager_QuarantinesFlaggedFile(t *testing.T) {
+	uploader, local, mock := newTestAttachmentManager(t, stubScanner{result: scanner.Result{Clean: false, Signature: "Eicar-Test-Signature"}})
+	achievement := &model.Achievement{ID: primitive.NewObjectID()}
+	actorID := "8f14e45f-ceea-4e7a-9b1e-2b9a6c1d3e4f"
+
+	mock.ExpectQuery("INSERT INTO attachment_quarantine").
+		WithArgs(sqlmock.AnyArg(), achievement.ID.Hex(), sqlmock.AnyArg(), "sertifikat.pdf", int64(len(pdfBytes)), checksumOf(pdfBytes), "Eicar-Test-Signature", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+
+	_, err := uploader.Upload(context.Background(), achievement, formFile(t, "sertifikat.pdf", pdfBytes), actorID)
+
+	assert.Equal(t, 422, uploadStatus(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.DirExists(t, local.Root+"/quarantine/"+achievement.ID.Hex())
+	assert.NoDirExists(t, local.Root+"/achievements")
+}
+
+func TestAttachmentManager_Replace(t *testing.T) {
+	uploader, local, _ := newTestAttachmentManager(t, stubScanner{err: errors.New("connection refused")})
+	achievement := &model.Achievement{
+		ID: primitive.NewObjectID(),
+		Attachments: []model.Attachment{
+			{ID: "a", FileName: "lama.pdf", Checksum: checksumOf(pdfBytes)},
+			{ID: "b", FileName: "lain.pdf", Checksum: checksumOf([]byte("lain"))},
+		},
+	}
+
+	_, err := uploader.Replace(context.Background(), achievement, "tidak-ada", formFile(t, "baru.pdf", pdfBytes), "")
+	assert.Equal(t, 404, uploadStatus(err), "unknown attachment")
+
+	_, err = uploader.Replace(context.Background(), achievement, "b", formFile(t, "baru.pdf", pdfBytes), "")
+	assert.Equal(t, 409, uploadStatus(err), "same file as another attachment")
+
+	// file yang sama dengan lampiran yang diganti bukan duplikat; lolos sampai scanner
+	_, err = uploader.Replace(context.Background(), achievement, "a", formFile(t, "baru.pdf", pdfBytes), "")
+	assert.Equal(t, 503, uploadStatus(err))
+
+	assert.NoDirExists(t, local.Root+"/achievements")
+}
+
+// fakeClamd menjawab satu perintah INSTREAM
+func fakeClamd(t *testing.T, reply string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		cmd := make([]byte, len("zINSTREAM\x00"))
+		io.ReadFull(conn, cmd)
+		for {
+			size := make([]byte, 4)
+			if _, err := io.ReadFull(conn, size); err != nil {
+				return
+			}
+			n := int(size[0])<<24 | int(size[1])<<16 | int(size[2])<<8 | int(size[3])
+			if n == 0 {
+				break
+			}
+			io.CopyN(io.Discard, conn, int64(n))
+		}
+		conn.Write([]byte(reply + "\x00"))
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestClamAVScanner(t *testing.T) {
+	clean := scanner.NewClamAVScanner("tcp", fakeClamd(t, "stream: OK"), time.Second)
+	result, err := clean.Scan(context.Background(), bytes.NewReader(pdfBytes))
+	assert.NoError(t, err)
+	assert.True(t, result.Clean)
+
+	infected := scanner.NewClamAVScanner("tcp", fakeClamd(t, "stream: Eicar-Test-Signature FOUND"), time.Second)
+	result, err = infected.Scan(context.Background(), strings.NewReader("X5O!P%@AP"))
+	assert.NoError(t, err)
+	assert.False(t, result.Clean)
+	assert.Equal(t, "Eicar-Test-Signature", result.Signature)
+
+	broken := scanner.NewClamAVScanner("tcp", fakeClamd(t, "INSTREAM size limit exceeded. ERROR"), time.Second)
+	_, err = broken.Scan(context.Background(), bytes.NewReader(pdfBytes))
+	assert.Error(t, err)
+}
+
+func checksumOf(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,95 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordFailure_LocksAfterMaxAttempts(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewLoginAttemptRepository(db, 5, 50, 15*time.Minute, 30*time.Minute, time.Second)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT failed_count, first_failed_at FROM login_attempts").
+		WithArgs(model.LoginKeyAccount, "user1@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"failed_count", "first_failed_at"}).AddRow(4, time.Now().Add(-time.Minute)))
+	mock.ExpectExec("INSERT INTO login_lockouts").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO login_attempts").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	attempt, err := repo.RecordFailure(model.LoginKeyAccount, "user1@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, attempt.FailedCount)
+	assert.NotNil(t, attempt.LockedUntil)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordFailure_ResetsOutsideWindow(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewLoginAttemptRepository(db, 5, 50, 15*time.Minute, 30*time.Minute, time.Second)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT failed_count, first_failed_at FROM login_attempts").
+		WithArgs(model.LoginKeyIP, "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"failed_count", "first_failed_at"}).AddRow(4, time.Now().Add(-time.Hour)))
+	mock.ExpectExec("INSERT INTO login_attempts").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	attempt, err := repo.RecordFailure(model.LoginKeyIP, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempt.FailedCount)
+	assert.Nil(t, attempt.NextAttemptAt)
+	assert.Nil(t, attempt.LockedUntil)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordFailure_IPUsesItsOwnThreshold(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewLoginAttemptRepository(db, 5, 50, 15*time.Minute, 30*time.Minute, time.Second)
+
+	// kegagalan ke-5 dari satu IP (NAT kampus) belum mengunci dan tidak memberi back-off
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT failed_count, first_failed_at FROM login_attempts").
+		WithArgs(model.LoginKeyIP, "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"failed_count", "first_failed_at"}).AddRow(4, time.Now().Add(-time.Minute)))
+	mock.ExpectExec("INSERT INTO login_attempts").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	attempt, err := repo.RecordFailure(model.LoginKeyIP, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, attempt.FailedCount)
+	assert.Nil(t, attempt.NextAttemptAt)
+	assert.Nil(t, attempt.LockedUntil)
+
+	// batas IP tercapai -> dikunci
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT failed_count, first_failed_at FROM login_attempts").
+		WithArgs(model.LoginKeyIP, "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"failed_count", "first_failed_at"}).AddRow(49, time.Now().Add(-time.Minute)))
+	mock.ExpectExec("INSERT INTO login_lockouts").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO login_attempts").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	attempt, err = repo.RecordFailure(model.LoginKeyIP, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.NotNil(t, attempt.LockedUntil)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
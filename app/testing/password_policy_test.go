@@ -0,0 +1,47 @@
+package repository_test
+
+import (
+	"testing"
+
+	"UAS/app/utils"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := utils.PasswordPolicy{MinLength: 8, RequireUpper: true, RequireLower: true, RequireDigit: true}
+
+	assert.Empty(t, policy.Validate("Prestasi2025"))
+	assert.NotEmpty(t, policy.Validate(""))
+	assert.Contains(t, policy.Validate("Password1"), "password terlalu umum")
+	assert.Contains(t, policy.Validate("prestasi2025"), "password harus mengandung huruf besar")
+}
+
+func TestCheckPasswordAndRehash_UpgradesCost(t *testing.T) {
+	oldHash, _ := bcrypt.GenerateFromPassword([]byte("Prestasi2025"), bcrypt.MinCost)
+
+	utils.BcryptCost = bcrypt.MinCost + 1
+	defer func() { utils.BcryptCost = bcrypt.DefaultCost }()
+
+	ok, newHash := utils.CheckPasswordAndRehash(string(oldHash), "Prestasi2025")
+	assert.True(t, ok)
+	assert.NotEmpty(t, newHash)
+
+	cost, _ := bcrypt.Cost([]byte(newHash))
+	assert.Equal(t, bcrypt.MinCost+1, cost)
+
+	ok, _ = utils.CheckPasswordAndRehash(string(oldHash), "salah")
+	assert.False(t, ok)
+}
+
+func TestSetBcryptCost_RejectsOutOfRange(t *testing.T) {
+	defer func() { utils.BcryptCost = bcrypt.DefaultCost }()
+
+	assert.Error(t, utils.SetBcryptCost(bcrypt.MaxCost+1))
+	assert.Error(t, utils.SetBcryptCost(bcrypt.MinCost-1))
+	assert.Equal(t, bcrypt.DefaultCost, utils.BcryptCost)
+
+	assert.NoError(t, utils.SetBcryptCost(12))
+	assert.Equal(t, 12, utils.BcryptCost)
+}
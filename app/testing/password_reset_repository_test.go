@@ -0,0 +1,91 @@
+package repository_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"UAS/app/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumeResetToken_Success(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPasswordResetRepository(db)
+	userID := uuid.New()
+
+	mock.ExpectQuery("UPDATE password_reset_tokens SET used_at = NOW\\(\\) WHERE token_hash = \\$1 AND used_at IS NULL").
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(userID))
+
+	got, err := repo.Consume("hash")
+	assert.NoError(t, err)
+	assert.Equal(t, userID, got)
+}
+
+func TestConsumeResetToken_AlreadyUsed(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPasswordResetRepository(db)
+
+	mock.ExpectQuery("UPDATE password_reset_tokens").
+		WithArgs("hash").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.Consume("hash")
+	assert.EqualError(t, err, "invalid or expired reset token")
+}
+
+func TestResetPassword_ConsumesTokenAndUpdatesPasswordTogether(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPasswordResetRepository(db)
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE password_reset_tokens").
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(userID))
+	mock.ExpectQuery("UPDATE users").
+		WithArgs("new-hash", userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "full_name", "role_id", "is_active", "created_at", "updated_at"}).
+			AddRow(userID, "budi", "budi@mail.com", "Budi", uuid.New(), true, time.Now(), time.Now()))
+	mock.ExpectExec("INSERT INTO password_history").
+		WithArgs(sqlmock.AnyArg(), userID, "new-hash").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	got, err := repo.ResetPassword("hash", "new-hash")
+	assert.NoError(t, err)
+	assert.Equal(t, userID, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResetPassword_UpdateFailureKeepsToken(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewPasswordResetRepository(db)
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE password_reset_tokens").
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(userID))
+	mock.ExpectQuery("UPDATE users").
+		WithArgs("new-hash", userID).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	_, err := repo.ResetPassword("hash", "new-hash")
+	assert.EqualError(t, err, "connection reset")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,55 @@
+package repository_test
+
+import (
+	"testing"
+
+	"UAS/app/model"
+	"UAS/app/policy"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorCan_OwnershipAndAdvisor(t *testing.T) {
+	ownerUserID := uuid.New()
+	advisorID := uuid.New()
+
+	student := &model.StudentResponse{
+		ID:        uuid.New(),
+		UserID:    ownerUserID,
+		StudentID: "20221030001",
+		AdvisorID: &advisorID,
+	}
+
+	owner := &policy.Actor{UserID: ownerUserID.String(), Students: []model.StudentResponse{*student}}
+	otherStudent := &policy.Actor{UserID: uuid.New().String()}
+	advisor := &policy.Actor{UserID: uuid.New().String(), Lecturer: &model.LecturerResponse{ID: advisorID}}
+	otherLecturer := &policy.Actor{UserID: uuid.New().String(), Lecturer: &model.LecturerResponse{ID: uuid.New()}}
+	admin := &policy.Actor{UserID: uuid.New().String(), Permissions: []string{"user:manage"}}
+
+	assert.True(t, owner.Can(policy.View, student))
+	assert.True(t, owner.Can(policy.Modify, student))
+	assert.False(t, owner.Can(policy.Verify, student))
+
+	assert.False(t, otherStudent.Can(policy.View, student))
+	assert.False(t, otherStudent.Can(policy.Modify, student))
+
+	assert.True(t, advisor.Can(policy.View, student))
+	assert.True(t, advisor.Can(policy.Verify, student))
+	assert.False(t, advisor.Can(policy.Modify, student))
+
+	// dosen lain dengan permission achievement:verify tetap tidak boleh
+	assert.False(t, otherLecturer.Can(policy.View, student))
+	assert.False(t, otherLecturer.Can(policy.Verify, student))
+
+	assert.True(t, admin.Can(policy.View, student))
+	assert.False(t, admin.Can(policy.Verify, student))
+}
+
+func TestActorCan_StudentWithoutAdvisor(t *testing.T) {
+	student := &model.StudentResponse{ID: uuid.New(), UserID: uuid.New()}
+	lecturer := &policy.Actor{UserID: uuid.New().String(), Lecturer: &model.LecturerResponse{ID: uuid.New()}}
+
+	assert.False(t, lecturer.Can(policy.View, student))
+	assert.False(t, lecturer.Can(policy.Verify, student))
+}
@@ -0,0 +1,190 @@
+package repository_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"UAS/app/model"
+	"UAS/app/preview"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewFit(t *testing.T) {
+	w, h := preview.Fit(4000, 3000, 160)
+	assert.Equal(t, []int{160, 120}, []int{w, h})
+
+	w, h = preview.Fit(1000, 3000, 640)
+	assert.Equal(t, []int{213, 640}, []int{w, h})
+
+	// tidak pernah diperbesar
+	w, h = preview.Fit(100, 50, 640)
+	assert.Equal(t, []int{100, 50}, []int{w, h})
+}
+
+func TestPreviewThumbnails(t *testing.T) {
+	// kiri merah, kanan transparan (harus jadi putih)
+	src := image.NewNRGBA(image.Rect(0, 0, 800, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 400; x++ {
+			src.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, src))
+
+	thumbs, err := preview.Thumbnails(buf.Bytes(), preview.DefaultSizes)
+	require.NoError(t, err)
+	require.Len(t, thumbs, 2)
+
+	assert.Equal(t, "small", thumbs[0].Size.Name)
+	assert.Equal(t, []int{160, 80}, []int{thumbs[0].Width, thumbs[0].Height})
+	assert.Equal(t, []int{640, 320}, []int{thumbs[1].Width, thumbs[1].Height})
+
+	img, err := jpeg.Decode(bytes.NewReader(thumbs[0].Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 160, 80), img.Bounds())
+
+	r, g, _, _ := img.At(20, 40).RGBA()
+	assert.Greater(t, r>>8, uint32(200))
+	assert.Less(t, g>>8, uint32(60))
+
+	r, g, _, _ = img.At(140, 40).RGBA()
+	assert.Greater(t, r>>8, uint32(240))
+	assert.Greater(t, g>>8, uint32(240))
+}
+
+func TestPreviewThumbnails_RejectsHugeImages(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 200, 200))))
+
+	old := preview.MaxPixels
+	preview.MaxPixels = 100 * 100
+	defer func() { preview.MaxPixels = old }()
+
+	_, err := preview.Thumbnails(buf.Bytes(), preview.DefaultSizes)
+	assert.ErrorIs(t, err, preview.ErrImageTooLarge)
+
+	_, err = preview.Thumbnails(pdfBytes, preview.DefaultSizes)
+	assert.Error(t, err)
+}
+
+func TestReadPDFInfo(t *testing.T) {
+	doc := []byte("%PDF-1.4\n" +
+		"1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n" +
+		"2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 /MediaBox [0 0 595.28 841.89] >> endobj\n" +
+		"3 0 obj << /Type /Page /Parent 2 0 R >> endobj\n" +
+		"4 0 obj << /Type /Page /Parent 2 0 R >> endobj\n" +
+		"5 0 obj << /Type /Page /Parent 2 0 R /MediaBox [0 0 842 595] >> endobj\n" +
+		"6 0 obj << /Title (Sertifikat Juara \\(1\\)) /Producer (Test) >> endobj\n" +
+		"trailer << /Size 7 /Root 1 0 R /Info 6 0 R >>\n%%EOF")
+
+	info, err := preview.ReadPDFInfo(doc)
+	require.NoError(t, err)
+	assert.Equal(t, 3, info.PageCount)
+	assert.Equal(t, "Sertifikat Juara (1)", info.Title)
+	assert.InDelta(t, 595.28, info.PageWidth, 0.001)
+	assert.InDelta(t, 841.89, info.PageHeight, 0.001)
+
+	_, err = preview.ReadPDFInfo(exeBytes)
+	assert.ErrorIs(t, err, preview.ErrNotPDF)
+}
+
+// objectStreamPDF membuat PDF 1.5 yang objeknya ada di object stream terkompresi;
+// copies > 1 mengulang stream yang sama (objek duplikat diabaikan pembaca)
+func objectStreamPDF(objs []string, copies int) []byte {
+	header, body := "", ""
+	for i, o := range objs {
+		header += fmt.Sprintf("%d %d ", i+1, len(body))
+		body += o + "\n"
+	}
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write([]byte(header + body))
+	zw.Close()
+
+	var doc bytes.Buffer
+	doc.WriteString("%PDF-1.5\n")
+	for i := 0; i < copies; i++ {
+		fmt.Fprintf(&doc, "%d 0 obj << /Type /ObjStm /N %d /First %d /Filter /FlateDecode /Length %d >>\nstream\n", 100+i, len(objs), len(header), z.Len())
+		doc.Write(z.Bytes())
+		doc.WriteString("\nendstream\nendobj\n")
+	}
+	doc.WriteString("99 0 obj << /Type /XRef /Root 1 0 R /Info 4 0 R /Size 100 >>\nstream\n\nendstream\nendobj\n%%EOF")
+	return doc.Bytes()
+}
+
+var objectStreamObjects = []string{
+	"<< /Type /Catalog /Pages 2 0 R >>",
+	"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+	"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+	"<< /Title <FEFF00500069006100670061006D> >>",
+}
+
+func TestReadPDFInfo_ObjectStream(t *testing.T) {
+	// PDF 1.5: catalog, pages, halaman dan info ada di object stream terkompresi
+	info, err := preview.ReadPDFInfo(objectStreamPDF(objectStreamObjects, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 1, info.PageCount)
+	assert.Equal(t, "Piagam", info.Title)
+	assert.Equal(t, 612.0, info.PageWidth)
+	assert.Equal(t, 792.0, info.PageHeight)
+}
+
+func TestReadPDFInfo_Limits(t *testing.T) {
+	oldBytes, oldObjects := preview.MaxPDFInflatedBytes, preview.MaxPDFObjects
+	defer func() { preview.MaxPDFInflatedBytes, preview.MaxPDFObjects = oldBytes, oldObjects }()
+
+	// setiap stream masih di bawah batas, tetapi totalnya tidak
+	doc := objectStreamPDF(objectStreamObjects, 20)
+	preview.MaxPDFInflatedBytes = 1000
+	_, err := preview.ReadPDFInfo(doc)
+	assert.ErrorIs(t, err, preview.ErrPDFTooLarge)
+
+	preview.MaxPDFInflatedBytes = oldBytes
+	_, err = preview.ReadPDFInfo(doc)
+	assert.NoError(t, err)
+
+	// objek di dalam stream ikut dihitung
+	preview.MaxPDFObjects = 3
+	_, err = preview.ReadPDFInfo(objectStreamPDF(objectStreamObjects, 1))
+	assert.ErrorIs(t, err, preview.ErrPDFTooManyObjects)
+}
+
+func TestReadPDFInfo_NegativeObjectStreamOffset(t *testing.T) {
+	// header object stream dengan offset negatif tidak boleh membuat panic
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write([]byte("1 0 5 -20 << /Type /Catalog >>\n<< /Title (x) >>"))
+	zw.Close()
+
+	var doc bytes.Buffer
+	doc.WriteString("%PDF-1.5\n")
+	fmt.Fprintf(&doc, "10 0 obj << /Type /ObjStm /N 2 /First 10 /Filter /FlateDecode /Length %d >>\nstream\n", z.Len())
+	doc.Write(z.Bytes())
+	doc.WriteString("\nendstream\nendobj\n%%EOF")
+
+	require.NotPanics(t, func() {
+		info, err := preview.ReadPDFInfo(doc.Bytes())
+		require.NoError(t, err)
+		assert.Empty(t, info.Title)
+	})
+}
+
+func TestSnapshotOfIgnoresPreviews(t *testing.T) {
+	a := &model.Achievement{Attachments: []model.Attachment{{ID: "a", FileName: "x.png"}}}
+	before := model.SnapshotOf(a)
+
+	a.Attachments[0].Preview = &model.AttachmentPreview{Status: model.PreviewReady}
+	after := model.SnapshotOf(a)
+
+	assert.Empty(t, model.DiffSnapshots(before, after))
+	assert.NotNil(t, a.Attachments[0].Preview)
+}
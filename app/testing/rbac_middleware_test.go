@@ -0,0 +1,77 @@
+package repository_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"UAS/app/utils"
+	"UAS/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRBACTestApp(perms []string, guard fiber.Handler) *fiber.App {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Locals("permissions", perms)
+		return c.Next()
+	}, guard, func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestPermissionMatches_Wildcard(t *testing.T) {
+	assert.True(t, utils.PermissionMatches("achievement:*", "achievement:verify"))
+	assert.True(t, utils.PermissionMatches("*", "user:manage"))
+	assert.False(t, utils.PermissionMatches("achievement:read", "achievement:*"))
+	assert.False(t, utils.PermissionMatches("achievement:read", "*"))
+	assert.False(t, utils.PermissionMatches("*:read", "*"))
+	assert.True(t, utils.PermissionMatches("achievement:*", "achievement:*"))
+	assert.True(t, utils.PermissionMatches("*:read", "achievement:read"))
+	assert.False(t, utils.PermissionMatches("achievement:*", "user:manage"))
+	assert.False(t, utils.PermissionMatches("achievement", "achievement:read"))
+}
+
+func TestRequireAll_ReportsMissingPermissions(t *testing.T) {
+	app := newRBACTestApp([]string{"achievement:read"}, middleware.RequireAll("achievement:read", "achievement:verify"))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	var body struct {
+		Missing []string `json:"missing"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, []string{"achievement:verify"}, body.Missing)
+}
+
+func TestRequireAny_Wildcard(t *testing.T) {
+	app := newRBACTestApp([]string{"achievement:*"}, middleware.RequireAny("achievement:verify"))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestRequireAll_WildcardRequirementNeedsWildcardGrant(t *testing.T) {
+	app := newRBACTestApp([]string{"achievement:read"}, middleware.RequireAll("achievement:*"))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+func TestRequireAny_WithoutAuthLayer(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
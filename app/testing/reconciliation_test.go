@@ -0,0 +1,78 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+	"UAS/app/service"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reference yang dokumen MongoDB-nya hilang: draft, diajukan, diverifikasi
+func missingDocumentRefs() []model.AchievementReference {
+	return []model.AchievementReference{
+		{ID: uuid.New(), MongoAchievementID: "665f1c2e9b1e8a3d4c5b6a01", Status: model.StatusDraft},
+		{ID: uuid.New(), MongoAchievementID: "665f1c2e9b1e8a3d4c5b6a02", Status: model.StatusSubmitted, SubmissionRound: 1},
+		{ID: uuid.New(), MongoAchievementID: "665f1c2e9b1e8a3d4c5b6a03", Status: model.StatusVerified, SubmissionRound: 1, Points: 50},
+	}
+}
+
+func TestReconcile_ApplyNeverDeletesReferencesWithoutForce(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	reconciler := service.NewReconciler(repository.NewAchievementReferenceRepository(db), nil, nil)
+	report := reconciler.Reconcile(missingDocumentRefs(), nil, model.ReconcileOptions{Apply: true})
+
+	require.Len(t, report.MissingReferences, 3)
+	assert.Equal(t, 0, report.Repaired)
+	for _, item := range report.MissingReferences {
+		assert.False(t, item.Repaired)
+		assert.Empty(t, item.Error)
+	}
+	// tidak ada query sama sekali: sqlmock gagal jika ada DELETE
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReconcile_ForceDeletesOnlyUnsubmittedDrafts(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM achievement_references WHERE mongo_achievement_id = \\$1 AND status = 'draft' AND submission_round = 0").
+		WithArgs("665f1c2e9b1e8a3d4c5b6a01").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	reconciler := service.NewReconciler(repository.NewAchievementReferenceRepository(db), nil, nil)
+	report := reconciler.Reconcile(missingDocumentRefs(), nil, model.ReconcileOptions{Apply: true, Force: true})
+
+	require.Len(t, report.MissingReferences, 3)
+	assert.Equal(t, 1, report.Repaired)
+	assert.True(t, report.MissingReferences[0].Repaired)
+	assert.False(t, report.MissingReferences[1].Repaired)
+	assert.False(t, report.MissingReferences[2].Repaired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReconcile_DryRunReportsWithoutChanges(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	docs := []model.AchievementSyncState{
+		{ID: "665f1c2e9b1e8a3d4c5b6aff", Status: "draft", CreatedAt: time.Now().Add(-time.Hour)},
+	}
+
+	reconciler := service.NewReconciler(repository.NewAchievementReferenceRepository(db), nil, nil)
+	report := reconciler.Reconcile(missingDocumentRefs(), docs, model.ReconcileOptions{Force: true})
+
+	assert.Equal(t, "dry-run", report.Mode)
+	assert.Len(t, report.MissingReferences, 3)
+	assert.Len(t, report.OrphanDocuments, 1)
+	assert.Equal(t, 0, report.Repaired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,52 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotateRefreshToken_Success(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewRefreshTokenRepository(db)
+	old := &model.RefreshToken{ID: uuid.New(), UserID: uuid.New(), FamilyID: uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO refresh_tokens").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	mock.ExpectExec("UPDATE refresh_tokens").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	newToken, err := repo.Rotate(old, "newhash", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, old.FamilyID, newToken.FamilyID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRotateRefreshToken_Reused(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewRefreshTokenRepository(db)
+	old := &model.RefreshToken{ID: uuid.New(), UserID: uuid.New(), FamilyID: uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO refresh_tokens").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	mock.ExpectExec("UPDATE refresh_tokens").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	_, err := repo.Rotate(old, "newhash", time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, repository.ErrRefreshTokenReused)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
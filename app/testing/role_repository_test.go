@@ -0,0 +1,63 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"UAS/app/repository"
+	"UAS/app/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleDelete_StillAssigned(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewRoleRepository(db)
+	roleID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(roleID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	err := repo.Delete(roleID)
+
+	assert.EqualError(t, err, "role is still assigned to users")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPermissionCache_InvalidateReloads(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewRoleRepository(db)
+	cache := utils.NewPermissionCache(repo, time.Minute)
+	roleID := uuid.New().String()
+
+	mock.ExpectQuery("SELECT p.resource").
+		WithArgs(roleID).
+		WillReturnRows(sqlmock.NewRows([]string{"perm"}).AddRow("achievement:read"))
+	mock.ExpectQuery("SELECT p.resource").
+		WithArgs(roleID).
+		WillReturnRows(sqlmock.NewRows([]string{"perm"}).AddRow("achievement:read").AddRow("achievement:verify"))
+
+	perms, err := cache.Get(roleID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"achievement:read"}, perms)
+
+	// masih dalam TTL -> tidak query ulang
+	perms, _ = cache.Get(roleID)
+	assert.Equal(t, []string{"achievement:read"}, perms)
+
+	cache.Invalidate()
+
+	perms, err = cache.Get(roleID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"achievement:read", "achievement:verify"}, perms)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
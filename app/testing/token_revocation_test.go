@@ -0,0 +1,42 @@
+package repository_test
+
+import (
+	"testing"
+
+	"UAS/app/repository"
+	"UAS/app/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateJWT_RevokedToken(t *testing.T) {
+	utils.SetRevocationStore(utils.NewMemoryRevocationStore())
+
+	token, err := utils.GenerateJWT("user-1", "role-1", []string{"achievement:read"}, 0)
+	assert.NoError(t, err)
+
+	claims, err := utils.ValidateJWT(token)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, claims.ID)
+
+	assert.NoError(t, utils.RevokeToken(claims))
+
+	_, err = utils.ValidateJWT(token)
+	assert.EqualError(t, err, "token has been logged out")
+}
+
+func TestTokenRevocationRepository_IsRevoked(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewTokenRevocationRepository(db)
+
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs("jti-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	revoked, err := repo.IsRevoked("jti-1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
@@ -0,0 +1,144 @@
+package repository_test
+
+import (
+	"database/sql"
+	"encoding/base32"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+	"UAS/app/service"
+	"UAS/app/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Vektor uji RFC 6238 (SHA1, secret "12345678901234567890"), 6 digit terakhir
+func TestTOTPCode_RFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		code, err := utils.TOTPCode(secret, unix/utils.TOTPPeriod)
+		assert.NoError(t, err)
+		assert.Equal(t, want, code)
+	}
+}
+
+func TestValidateTOTP_Skew(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	previous, _ := utils.TOTPCode(secret, now.Unix()/utils.TOTPPeriod-1)
+
+	step, ok := utils.ValidateTOTP(secret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/utils.TOTPPeriod-1, step)
+
+	_, ok = utils.ValidateTOTP(secret, "000000x", now)
+	assert.False(t, ok)
+}
+
+func TestEncryptSecret_RoundTrip(t *testing.T) {
+	enc, err := utils.EncryptSecret("JBSWY3DPEHPK3PXP")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "JBSWY3DPEHPK3PXP", enc)
+
+	plain, err := utils.DecryptSecret(enc)
+	assert.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", plain)
+}
+
+func twoFactorLoginApp(db *sql.DB, maxAttempts int) *fiber.App {
+	repo := repository.NewUserRepository(db)
+	tfRepo := repository.NewTwoFactorRepository(db)
+	attemptRepo := repository.NewLoginAttemptRepository(db, maxAttempts, 50, 15*time.Minute, 30*time.Minute, time.Second)
+
+	app := fiber.New()
+	app.Post("/login/2fa", func(c *fiber.Ctx) error {
+		return service.VerifyLoginTwoFactor(c, repo, nil, tfRepo, attemptRepo)
+	})
+	return app
+}
+
+func postTwoFactor(t *testing.T, app *fiber.App, body string) int {
+	req := httptest.NewRequest("POST", "/login/2fa", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func expectChallengeAndUser(mock sqlmock.Sqlmock, userID uuid.UUID) {
+	mock.ExpectQuery("FROM login_challenges").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "purpose", "expires_at", "attempts", "used_at"}).
+			AddRow(uuid.New(), userID, model.ChallengeTOTP, time.Now().Add(time.Minute), 0, nil))
+	mock.ExpectQuery("FROM users").
+		WithArgs(userID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "password", "full_name", "role_id", "is_active"}).
+			AddRow(userID, "budi", "Budi@Mail.com", "hash", "Budi", uuid.New(), true))
+}
+
+var loginAttemptColumns = []string{"key_type", "key_value", "failed_count", "first_failed_at", "last_failed_at", "next_attempt_at", "locked_until"}
+
+func TestVerifyLoginTwoFactor_RepeatedFailuresLockAccount(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	app := twoFactorLoginApp(db, 2)
+	userID := uuid.New()
+
+	// Percobaan pertama: recovery code salah -> dicatat sebagai login gagal, akun terkunci
+	expectChallengeAndUser(mock, userID)
+	mock.ExpectQuery("FROM login_attempts").WithArgs(model.LoginKeyAccount, "budi@mail.com").
+		WillReturnRows(sqlmock.NewRows(loginAttemptColumns))
+	mock.ExpectQuery("FROM login_attempts").WithArgs(model.LoginKeyIP, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(loginAttemptColumns))
+	mock.ExpectQuery("FROM user_totp").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "secret_enc", "enabled", "last_used_step", "enabled_at", "created_at"}).
+			AddRow(userID, "enc", true, 0, time.Now(), time.Now()))
+	mock.ExpectExec("UPDATE user_recovery_codes").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE login_challenges").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT failed_count, first_failed_at FROM login_attempts").
+		WithArgs(model.LoginKeyAccount, "budi@mail.com").
+		WillReturnRows(sqlmock.NewRows([]string{"failed_count", "first_failed_at"}).AddRow(1, time.Now().Add(-time.Minute)))
+	mock.ExpectExec("INSERT INTO login_lockouts").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO login_attempts").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT failed_count, first_failed_at FROM login_attempts").
+		WithArgs(model.LoginKeyIP, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"failed_count", "first_failed_at"}))
+	mock.ExpectExec("INSERT INTO login_attempts").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	status := postTwoFactor(t, app, `{"challengeToken":"c1","recoveryCode":"AAAA-BBBB"}`)
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+
+	// Challenge baru (password benar lagi) tetap ditolak selama akun terkunci
+	expectChallengeAndUser(mock, userID)
+	lockedUntil := time.Now().Add(30 * time.Minute)
+	mock.ExpectQuery("FROM login_attempts").WithArgs(model.LoginKeyAccount, "budi@mail.com").
+		WillReturnRows(sqlmock.NewRows(loginAttemptColumns).
+			AddRow(model.LoginKeyAccount, "budi@mail.com", 2, time.Now(), time.Now(), nil, lockedUntil))
+	mock.ExpectQuery("FROM login_attempts").WithArgs(model.LoginKeyIP, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(loginAttemptColumns))
+
+	status = postTwoFactor(t, app, `{"challengeToken":"c2","code":"123456"}`)
+	assert.Equal(t, fiber.StatusTooManyRequests, status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,6 +2,8 @@ package repository_test
 
 import (
 	"testing"
+	"time"
+	"UAS/app/model"
 	"UAS/app/repository"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -19,7 +21,7 @@ func TestGetUserByEmail_Success(t *testing.T) {
 	rows := sqlmock.NewRows([]string{"id", "username", "email", "password", "full_name", "role_id", "is_active"}).
 		AddRow(userID, "user1", "user1@example.com", "hashedpass", "User One", uuid.New(), true)
 
-	mock.ExpectQuery("SELECT id, username, email, password, full_name, role_id, is_active FROM users WHERE email = \\$1").
+	mock.ExpectQuery("SELECT id, username, email, password, full_name, role_id, is_active FROM users WHERE LOWER\\(email\\) = LOWER\\(\\$1\\)").
 		WithArgs("user1@example.com").
 		WillReturnRows(rows)
 
@@ -27,3 +29,48 @@ func TestGetUserByEmail_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "User One", user.FullName)
 }
+
+func TestRevokeAllSessions_UserNotFound(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewUserRepository(db)
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET token_version = token_version \\+ 1").
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.RevokeAllSessions(userID)
+	assert.EqualError(t, err, "user not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateUser_RoleChangeBumpsTokenVersion(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewUserRepository(db)
+	userID, roleID := uuid.New(), uuid.New()
+
+	// role berubah -> token_version naik, access token lama (berisi role lama) ditolak
+	mock.ExpectQuery(`UPDATE users SET .* token_version = token_version \+ CASE WHEN role_id IS DISTINCT FROM \$4 THEN 1 ELSE 0 END`).
+		WithArgs("user1", "user1@example.com", "User One", roleID, true, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "full_name", "role_id", "is_active", "created_at", "updated_at"}).
+			AddRow(userID, "user1", "user1@example.com", "User One", roleID, true, time.Now(), time.Now()))
+
+	updated, err := repo.UpdateUser(&model.User{
+		ID:       userID,
+		Username: "user1",
+		Email:    "user1@example.com",
+		FullName: "User One",
+		RoleID:   roleID,
+		IsActive: true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, roleID, updated.RoleID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
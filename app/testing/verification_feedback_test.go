@@ -0,0 +1,58 @@
+package repository_test
+
+import (
+	"testing"
+
+	"UAS/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func feedbackFields(errs []model.FieldError) []string {
+	fields := []string{}
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	return fields
+}
+
+func TestValidateVerifyPayload_OverrideNeedsJustification(t *testing.T) {
+	assert.Empty(t, model.ValidateVerifyPayload(model.TransitionPayload{}))
+
+	errs := model.ValidateVerifyPayload(model.TransitionPayload{Points: intPtr(45)})
+	assert.Equal(t, []string{"justification"}, feedbackFields(errs))
+
+	errs = model.ValidateVerifyPayload(model.TransitionPayload{Points: intPtr(-5), Justification: "salah hitung"})
+	assert.Equal(t, []string{"points"}, feedbackFields(errs))
+
+	assert.Empty(t, model.ValidateVerifyPayload(model.TransitionPayload{Points: intPtr(45), Justification: "tingkat regional"}))
+}
+
+func TestValidateRejectPayload(t *testing.T) {
+	achievement := &model.Achievement{
+		AchievementType: "competition",
+		Details:         bson.M{"level": "national", "rank": 1},
+	}
+	active := map[string]bool{"missing_evidence": true, "wrong_category": true}
+
+	errs := model.ValidateRejectPayload(model.TransitionPayload{}, achievement, active)
+	assert.Equal(t, []string{"reasons"}, feedbackFields(errs))
+
+	errs = model.ValidateRejectPayload(model.TransitionPayload{
+		Reasons: []string{"missing_evidence", "missing_evidence", "retired"},
+		FieldComments: []model.FieldComment{
+			{Field: "details.rank", Comment: "tidak sesuai sertifikat"},
+			{Field: "details.doi", Comment: "bukan field kompetisi"},
+			{Field: "title", Comment: "  "},
+		},
+	}, achievement, active)
+	assert.Equal(t, []string{
+		"reasons[1]", "reasons[2]", "fieldComments[1].field", "fieldComments[2].comment",
+	}, feedbackFields(errs))
+
+	assert.Empty(t, model.ValidateRejectPayload(model.TransitionPayload{
+		Reasons:       []string{"wrong_category"},
+		FieldComments: []model.FieldComment{{Field: "attachments", Comment: "unggah sertifikat"}},
+	}, achievement, active))
+}
@@ -0,0 +1,69 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"UAS/app/model"
+	"UAS/app/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerificationQueuePending_FiltersAndPaginates(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewVerificationQueueRepository(db)
+	advisorID := uuid.New()
+	submittedAt := time.Now().Add(-72 * time.Hour)
+
+	mock.ExpectQuery("SELECT COUNT").
+		WithArgs(advisorID, "competition", "Teknik Informatika").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("ORDER BY submitted_at ASC").
+		WithArgs(advisorID, "competition", "Teknik Informatika", 2, 0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"mongo_achievement_id", "title", "type", "round", "id", "student_id", "full_name", "program_study", "submitted_at",
+		}).AddRow("665f1c2e9b1e8a3d4c5b6a79", "Juara 1 Hackathon", "competition", 2, uuid.New(), "2111001", "Budi", "Teknik Informatika", submittedAt))
+
+	items, total, err := repo.Pending(model.VerificationQueueFilter{
+		AdvisorID:       advisorID,
+		AchievementType: "competition",
+		ProgramStudy:    "Teknik Informatika",
+		Limit:           2,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "Juara 1 Hackathon", items[0].Title)
+	assert.Equal(t, 3, items[0].WaitingDays)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVerificationQueueCounts(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repository.NewVerificationQueueRepository(db)
+	advisorID := uuid.New()
+
+	mock.ExpectQuery("GROUP BY").
+		WithArgs(advisorID, model.OverdueAfterDays).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "program_study", "count", "overdue"}).
+			AddRow("competition", "Teknik Informatika", 2, 1).
+			AddRow("publication", "Teknik Informatika", 1, 0).
+			AddRow("", "Sistem Informasi", 1, 1))
+
+	counts, err := repo.Counts(advisorID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, counts.Total)
+	assert.Equal(t, 2, counts.Overdue)
+	assert.Equal(t, map[string]int{"competition": 2, "publication": 1}, counts.ByType)
+	assert.Equal(t, map[string]int{"Teknik Informatika": 3, "Sistem Informasi": 1}, counts.ByProgramStudy)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
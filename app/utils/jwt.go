@@ -4,25 +4,43 @@ import (
 	"errors"
 	"os"
 	"strings"
-	"sync"
 	"time"
 
 	"UAS/app/model"
 
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 var JWTSecret = []byte(os.Getenv("API_KEY"))
 
+// Masa berlaku access token, bisa diubah dari config saat startup
+var AccessTokenTTL = 15 * time.Minute
+
+// TokenVersionStore mengembalikan versi token user saat ini.
+// Token dengan versi berbeda dianggap sudah dicabut.
+type TokenVersionStore interface {
+	GetTokenVersion(userID string) (int, error)
+}
+
+var tokenVersionStore TokenVersionStore
+
+// SetTokenVersionStore mengaktifkan pengecekan versi token di ValidateJWT
+func SetTokenVersionStore(store TokenVersionStore) {
+	tokenVersionStore = store
+}
+
 // ----------------- JWT -----------------
-func GenerateJWT(userID, roleID string, perms []string) (string, error) {
+func GenerateJWT(userID, roleID string, perms []string, tokenVersion int) (string, error) {
 	claims := model.JWTClaims{
-		UserID:      userID,
-		RoleID:      roleID,
-		Permissions: perms,
+		UserID:       userID,
+		RoleID:       roleID,
+		Permissions:  perms,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        uuid.NewString(), // jti, dipakai sebagai key revocation
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -32,11 +50,6 @@ func GenerateJWT(userID, roleID string, perms []string) (string, error) {
 }
 
 func ValidateJWT(tokenStr string) (*model.JWTClaims, error) {
-	// Cek apakah token ada di blacklist
-	if IsBlacklisted(tokenStr) {
-		return nil, errors.New("token has been logged out")
-	}
-
 	// Parse token dengan claims yang sesuai
 	token, err := jwt.ParseWithClaims(tokenStr, &model.JWTClaims{}, func(t *jwt.Token) (interface{}, error) {
 		return JWTSecret, nil
@@ -50,38 +63,34 @@ func ValidateJWT(tokenStr string) (*model.JWTClaims, error) {
 	}
 
 	claims, ok := token.Claims.(*model.JWTClaims)
-	if !ok {
+	if !ok || claims.ID == "" {
 		return nil, errors.New("invalid token claims")
 	}
 
-	// Jangan cek permissions di sini, hanya kembalikan claims
-	return claims, nil
-}
-
+	// Cek apakah token sudah dicabut (logout)
+	revoked, err := IsRevoked(claims)
+	if err != nil {
+		return nil, errors.New("failed to check token revocation")
+	}
+	if revoked {
+		return nil, errors.New("token has been logged out")
+	}
 
-// ----------------- BLACKLIST -----------------
-var blacklist = make(map[string]time.Time)
-var mu sync.Mutex
+	// Cek versi token user (semua sesi dicabut saat versi dinaikkan)
+	if tokenVersionStore != nil {
+		version, err := tokenVersionStore.GetTokenVersion(claims.UserID)
+		if err != nil {
+			return nil, errors.New("invalid token")
+		}
+		if claims.TokenVersion != version {
+			return nil, errors.New("session has been revoked")
+		}
+	}
 
-func AddToBlacklist(token string, exp time.Time) {
-	mu.Lock()
-	defer mu.Unlock()
-	blacklist[token] = exp
+	// Jangan cek permissions di sini, hanya kembalikan claims
+	return claims, nil
 }
 
-func IsBlacklisted(token string) bool {
-	mu.Lock()
-	defer mu.Unlock()
-	exp, exists := blacklist[token]
-	if !exists {
-		return false
-	}
-	if time.Now().After(exp) {
-		delete(blacklist, token)
-		return false
-	}
-	return true
-}
 
 // ----------------- UTILITY -----------------
 func ExtractTokenFromHeader(authHeader string) (string, error) {
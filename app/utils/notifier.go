@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Notifier mengirim pesan ke user (email, dsb). Driver log dan file dipakai
+// untuk development lokal.
+type Notifier interface {
+	Send(to, subject, body string) error
+}
+
+var notifier Notifier = LogNotifier{}
+
+// SetNotifier mengganti driver notifier yang dipakai aplikasi
+func SetNotifier(n Notifier) {
+	notifier = n
+}
+
+// Notify mengirim pesan lewat notifier aktif
+func Notify(to, subject, body string) error {
+	return notifier.Send(to, subject, body)
+}
+
+// LogNotifier hanya menulis pesan ke log aplikasi
+type LogNotifier struct{}
+
+func (LogNotifier) Send(to, subject, body string) error {
+	log.Printf("📧 [notifier] to=%s subject=%q\n%s\n", to, subject, body)
+	return nil
+}
+
+// FileNotifier menambahkan setiap pesan ke sebuah file (outbox lokal)
+type FileNotifier struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{Path: path}
+}
+
+func (n *FileNotifier) Send(to, subject, body string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "=== %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), to, subject, body)
+	return err
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// Masa berlaku token opaque, bisa diubah dari config saat startup
+var (
+	RefreshTokenTTL  = 7 * 24 * time.Hour
+	PasswordResetTTL = 30 * time.Minute
+)
+
+// URL halaman reset password di frontend, token ditambahkan sebagai query ?token=
+var PasswordResetURL = "http://localhost:3000/reset-password"
+
+// GenerateOpaqueToken membuat token acak (refresh token, reset password, ...).
+// Nilai mentah dikirim ke user, sedangkan yang disimpan di database hanya hash-nya.
+func GenerateOpaqueToken() (raw string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", err
+	}
+	raw = base64.RawURLEncoding.EncodeToString(b)
+	return raw, HashOpaqueToken(raw), nil
+}
+
+// HashOpaqueToken menghasilkan SHA-256 hex dari token mentah
+func HashOpaqueToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,12 +2,27 @@
 package utils
 
 import (
+	"fmt"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Cost bcrypt untuk hash baru, bisa diubah dari config saat startup lewat SetBcryptCost
+var BcryptCost = bcrypt.DefaultCost
+
+// SetBcryptCost mengganti cost untuk hash baru. Nilai di luar rentang bcrypt ditolak:
+// cost seperti itu membuat setiap HashPassword gagal.
+func SetBcryptCost(cost int) error {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, cost)
+	}
+	BcryptCost = cost
+	return nil
+}
+
 // HashPassword meng-hash password plain text
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), BcryptCost)
 	return string(bytes), err
 }
 
@@ -16,3 +31,23 @@ func CheckPassword(hash, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// CheckPasswordAndRehash membandingkan hash dengan password. Jika cocok dan
+// hash masih memakai cost lebih rendah dari BcryptCost, hash baru ikut dikembalikan
+// supaya bisa disimpan (newHash kosong jika tidak perlu re-hash).
+func CheckPasswordAndRehash(hash, password string) (ok bool, newHash string) {
+	if !CheckPassword(hash, password) {
+		return false, ""
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil || cost >= BcryptCost {
+		return true, ""
+	}
+
+	newHash, err = HashPassword(password)
+	if err != nil {
+		return true, ""
+	}
+	return true, newHash
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy aturan password yang berlaku di CreateUser, UpdatePassword
+// dan perubahan password mandiri
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	HistorySize   int // jumlah hash terakhir yang tidak boleh dipakai ulang
+}
+
+var Policy = PasswordPolicy{
+	MinLength:    8,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+	HistorySize:  5,
+}
+
+// Daftar password umum yang selalu ditolak (dibandingkan case-insensitive)
+var commonPasswords = map[string]bool{
+	"password": true, "password1": true, "password123": true, "passw0rd": true,
+	"123456": true, "12345678": true, "123456789": true, "1234567890": true,
+	"qwerty": true, "qwerty123": true, "qwertyuiop": true, "abc123": true,
+	"111111": true, "000000": true, "iloveyou": true, "admin": true,
+	"admin123": true, "administrator": true, "welcome": true, "welcome1": true,
+	"letmein": true, "monkey": true, "dragon": true, "football": true,
+	"sunshine": true, "princess": true, "login": true, "master": true,
+	"changeme": true, "secret": true, "p@ssw0rd": true, "p@ssword": true,
+	"mahasiswa": true, "mahasiswa123": true, "dosen123": true, "rahasia": true,
+	"rahasia123": true, "indonesia": true, "bismillah": true, "sayang": true,
+}
+
+// Validate mengembalikan daftar pelanggaran policy (kosong jika valid)
+func (p PasswordPolicy) Validate(password string) []string {
+	var violations []string
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("password minimal %d karakter", p.MinLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		violations = append(violations, "password harus mengandung huruf besar")
+	}
+	if p.RequireLower && !hasLower {
+		violations = append(violations, "password harus mengandung huruf kecil")
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, "password harus mengandung angka")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, "password harus mengandung simbol")
+	}
+
+	if commonPasswords[strings.ToLower(password)] {
+		violations = append(violations, "password terlalu umum")
+	}
+
+	return violations
+}
+
+// IsReused mengecek apakah password sama dengan salah satu hash sebelumnya
+func (p PasswordPolicy) IsReused(password string, previousHashes []string) bool {
+	for _, h := range previousHashes {
+		if CheckPassword(h, password) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package utils
+
+import "strings"
+
+// PermissionMatches mengecek apakah permission yang dimiliki (granted) memenuhi
+// permission yang dibutuhkan (required). Format "resource:action"; "*" pada
+// resource atau action berarti wildcard, mis. "achievement:*" atau "*".
+// Wildcard hanya berlaku di sisi granted: required "achievement:*" hanya dipenuhi
+// oleh "achievement:*" atau "*", bukan oleh satu permission sempit.
+func PermissionMatches(granted, required string) bool {
+	if granted == required || granted == "*" {
+		return true
+	}
+
+	gRes, gAct, gOk := strings.Cut(granted, ":")
+	rRes, rAct, rOk := strings.Cut(required, ":")
+	if !gOk || !rOk {
+		return false
+	}
+
+	return matchPart(gRes, rRes) && matchPart(gAct, rAct)
+}
+
+func matchPart(granted, required string) bool {
+	return granted == "*" || granted == required
+}
+
+// HasPermission mengecek apakah salah satu permission user memenuhi required
+func HasPermission(perms []string, required string) bool {
+	for _, p := range perms {
+		if PermissionMatches(p, required) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"sync"
+	"time"
+
+	"UAS/app/model"
+)
+
+// PermissionSource memuat permission terbaru sebuah role dari database
+type PermissionSource interface {
+	GetPermissionsByRoleID(roleID string) ([]string, error)
+}
+
+type cachedPermissions struct {
+	perms    []string
+	loadedAt time.Time
+}
+
+// PermissionCache menyimpan permission per role selama TTL. Perubahan role di
+// instance ini langsung berlaku (Invalidate), instance lain paling lambat setelah TTL.
+type PermissionCache struct {
+	source  PermissionSource
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[string]cachedPermissions
+}
+
+func NewPermissionCache(source PermissionSource, ttl time.Duration) *PermissionCache {
+	return &PermissionCache{
+		source:  source,
+		ttl:     ttl,
+		entries: make(map[string]cachedPermissions),
+	}
+}
+
+func (pc *PermissionCache) Get(roleID string) ([]string, error) {
+	pc.mu.RLock()
+	entry, ok := pc.entries[roleID]
+	pc.mu.RUnlock()
+	if ok && time.Since(entry.loadedAt) < pc.ttl {
+		return entry.perms, nil
+	}
+
+	perms, err := pc.source.GetPermissionsByRoleID(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	pc.mu.Lock()
+	pc.entries[roleID] = cachedPermissions{perms: perms, loadedAt: time.Now()}
+	pc.mu.Unlock()
+
+	return perms, nil
+}
+
+// Invalidate menghapus seluruh cache (dipanggil setelah role / permission berubah)
+func (pc *PermissionCache) Invalidate() {
+	pc.mu.Lock()
+	pc.entries = make(map[string]cachedPermissions)
+	pc.mu.Unlock()
+}
+
+var permissionCache *PermissionCache
+
+// SetPermissionCache mengaktifkan pemuatan permission dari database.
+// Tanpa cache, permission diambil dari claims JWT.
+func SetPermissionCache(cache *PermissionCache) {
+	permissionCache = cache
+}
+
+// ResolvePermissions mengembalikan permission terkini untuk claims token
+func ResolvePermissions(claims *model.JWTClaims) ([]string, error) {
+	if permissionCache == nil {
+		return claims.Permissions, nil
+	}
+	return permissionCache.Get(claims.RoleID)
+}
+
+// InvalidatePermissions membuang cache permission jika aktif
+func InvalidatePermissions() {
+	if permissionCache != nil {
+		permissionCache.Invalidate()
+	}
+}
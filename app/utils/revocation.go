@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"UAS/app/model"
+)
+
+// RevocationStore menyimpan jti token yang sudah dicabut sampai token tersebut expired.
+// Implementasi default in-memory; production memakai store bersama (PostgreSQL)
+// supaya revocation tetap berlaku setelah restart dan antar instance.
+type RevocationStore interface {
+	Revoke(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+	PurgeExpired() (int64, error)
+}
+
+var revocationStore RevocationStore = NewMemoryRevocationStore()
+
+// SetRevocationStore mengganti store yang dipakai ValidateJWT
+func SetRevocationStore(store RevocationStore) {
+	revocationStore = store
+}
+
+// RevokeToken mencabut token berdasarkan jti sampai waktu expired-nya
+func RevokeToken(claims *model.JWTClaims) error {
+	exp := time.Now().Add(AccessTokenTTL)
+	if claims.ExpiresAt != nil {
+		exp = claims.ExpiresAt.Time
+	}
+	return revocationStore.Revoke(claims.ID, exp)
+}
+
+// IsRevoked mengecek apakah token sudah dicabut
+func IsRevoked(claims *model.JWTClaims) (bool, error) {
+	return revocationStore.IsRevoked(claims.ID)
+}
+
+// StartRevocationSweeper menghapus entry yang sudah expired secara berkala
+// sampai ctx dibatalkan
+func StartRevocationSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := revocationStore.PurgeExpired()
+				if err != nil {
+					log.Println("❌ Revocation sweep failed:", err)
+					continue
+				}
+				if n > 0 {
+					log.Printf("🧹 Revocation sweep removed %d expired entries\n", n)
+				}
+			}
+		}
+	}()
+}
+
+// ----------------- IN-MEMORY STORE -----------------
+
+// MemoryRevocationStore hanya berlaku di satu proses, cocok untuk testing
+type MemoryRevocationStore struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{revoked: make(map[string]time.Time)}
+}
+
+func (s *MemoryRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exp, exists := s.revoked[jti]
+	if !exists {
+		return false, nil
+	}
+	if time.Now().After(exp) {
+		delete(s.revoked, jti)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *MemoryRevocationStore) PurgeExpired() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	now := time.Now()
+	for jti, exp := range s.revoked {
+		if now.After(exp) {
+			delete(s.revoked, jti)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -10,6 +12,62 @@ type Config struct {
 	DB_DSN   string
 	AppPort  string
 	ApiKey   string
+
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	RevocationStore         string // "postgres" (default) atau "memory"
+	RevocationSweepInterval time.Duration
+
+	LoginMaxAttempts     int
+	LoginIPMaxAttempts   int
+	LoginWindow          time.Duration
+	LoginLockoutDuration time.Duration
+	LoginBackoffBase     time.Duration
+
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+	Notifier         string // "log" (default) atau "file"
+	NotifierFile     string
+
+	BcryptCost            int
+	PasswordMinLength     int
+	PasswordRequireUpper  bool
+	PasswordRequireLower  bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
+	PasswordHistorySize   int
+
+	PermissionCacheTTL time.Duration
+
+	OutboxInterval    time.Duration
+	OutboxMaxAttempts int
+
+	ReconcileInterval  time.Duration // 0 = job berkala nonaktif
+	ReconcileAutoApply bool
+
+	StorageDriver     string // "local" (default) atau "s3"
+	StorageLocalRoot  string
+	StorageSigningKey string        // HMAC link sementara driver local (wajib, terpisah dari API_KEY)
+	StorageURLTTL     time.Duration // masa berlaku link sementara
+	PublicBaseURL     string        // prefix URL publik aplikasi untuk link sementara
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool // true untuk MinIO
+
+	UploadMaxBytes          int64
+	UploadMaxPerAchievement int
+	Scanner                 string // "noop" (default) atau "clamav"
+	ClamAVNetwork           string // "unix" atau "tcp"
+	ClamAVAddress           string
+	ClamAVTimeout           time.Duration
+	PreviewWorkers          int
+	PreviewQueueSize        int
+	PreviewSweepInterval    time.Duration
 }
 
 func Load() *Config {
@@ -19,5 +77,101 @@ func Load() *Config {
 		DB_DSN:  os.Getenv("DB_DSN"),
 		AppPort: os.Getenv("APP_PORT"),
 		ApiKey:  os.Getenv("API_KEY"),
+
+		AccessTokenTTL:  getDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getDuration("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+
+		RevocationStore:         getString("REVOCATION_STORE", "postgres"),
+		RevocationSweepInterval: getDuration("REVOCATION_SWEEP_INTERVAL", 10*time.Minute),
+
+		LoginMaxAttempts:     getInt("LOGIN_MAX_ATTEMPTS", 5),
+		LoginIPMaxAttempts:   getInt("LOGIN_IP_MAX_ATTEMPTS", 100),
+		LoginWindow:          getDuration("LOGIN_WINDOW", 15*time.Minute),
+		LoginLockoutDuration: getDuration("LOGIN_LOCKOUT_DURATION", 30*time.Minute),
+		LoginBackoffBase:     getDuration("LOGIN_BACKOFF_BASE", time.Second),
+
+		PasswordResetTTL: getDuration("PASSWORD_RESET_TTL", 30*time.Minute),
+		PasswordResetURL: getString("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+		Notifier:         getString("NOTIFIER", "log"),
+		NotifierFile:     getString("NOTIFIER_FILE", "./outbox.log"),
+
+		BcryptCost:            getInt("BCRYPT_COST", 10),
+		PasswordMinLength:     getInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordRequireUpper:  getBool("PASSWORD_REQUIRE_UPPER", true),
+		PasswordRequireLower:  getBool("PASSWORD_REQUIRE_LOWER", true),
+		PasswordRequireDigit:  getBool("PASSWORD_REQUIRE_DIGIT", true),
+		PasswordRequireSymbol: getBool("PASSWORD_REQUIRE_SYMBOL", false),
+		PasswordHistorySize:   getInt("PASSWORD_HISTORY_SIZE", 5),
+
+		PermissionCacheTTL: getDuration("PERMISSION_CACHE_TTL", 30*time.Second),
+
+		OutboxInterval:    getDuration("OUTBOX_INTERVAL", 5*time.Second),
+		OutboxMaxAttempts: getInt("OUTBOX_MAX_ATTEMPTS", 20),
+
+		ReconcileInterval:  getDuration("RECONCILE_INTERVAL", time.Hour),
+		ReconcileAutoApply: getBool("RECONCILE_AUTO_APPLY", false),
+
+		StorageDriver:     getString("STORAGE_DRIVER", "local"),
+		StorageLocalRoot:  getString("STORAGE_LOCAL_ROOT", "./uploads"),
+		StorageSigningKey: os.Getenv("STORAGE_SIGNING_KEY"),
+		StorageURLTTL:     getDuration("STORAGE_URL_TTL", 15*time.Minute),
+		PublicBaseURL:     getString("PUBLIC_BASE_URL", ""),
+
+		S3Endpoint:  getString("S3_ENDPOINT", "http://localhost:9000"),
+		S3Region:    getString("S3_REGION", "us-east-1"),
+		S3Bucket:    os.Getenv("S3_BUCKET"),
+		S3AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("S3_SECRET_KEY"),
+		S3PathStyle: getBool("S3_PATH_STYLE", true),
+
+		UploadMaxBytes:          int64(getInt("UPLOAD_MAX_BYTES", 5<<20)),
+		UploadMaxPerAchievement: getInt("UPLOAD_MAX_PER_ACHIEVEMENT", 10),
+		Scanner:                 getString("SCANNER", "noop"),
+		ClamAVNetwork:           getString("CLAMAV_NETWORK", "unix"),
+		ClamAVAddress:           getString("CLAMAV_ADDRESS", "/var/run/clamav/clamd.ctl"),
+		ClamAVTimeout:           getDuration("CLAMAV_TIMEOUT", 30*time.Second),
+		PreviewWorkers:          getInt("PREVIEW_WORKERS", 2),
+		PreviewQueueSize:        getInt("PREVIEW_QUEUE_SIZE", 100),
+		PreviewSweepInterval:    getDuration("PREVIEW_SWEEP_INTERVAL", time.Minute),
+	}
+}
+
+// getString membaca string dari env atau memakai nilai default jika kosong
+func getString(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// getInt membaca integer dari env atau memakai nilai default jika kosong / tidak valid
+func getInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// getBool membaca boolean dari env atau memakai nilai default jika kosong / tidak valid
+func getBool(key string, def bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// getDuration membaca durasi dari env (format time.ParseDuration, mis. "15m"),
+// atau memakai nilai default jika kosong / tidak valid
+func getDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def
 	}
+	return d
 }
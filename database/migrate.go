@@ -0,0 +1,308 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+)
+
+// migrations berisi DDL tambahan di luar skema awal (users, roles, students, ...).
+// Semua statement harus idempotent karena dijalankan setiap kali aplikasi start.
+var migrations = []string{
+	// refresh token (opaque, disimpan dalam bentuk hash)
+	`CREATE TABLE IF NOT EXISTS refresh_tokens (
+		id          UUID PRIMARY KEY,
+		user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		family_id   UUID NOT NULL,
+		token_hash  VARCHAR(64) NOT NULL UNIQUE,
+		expires_at  TIMESTAMP NOT NULL,
+		revoked_at  TIMESTAMP NULL,
+		replaced_by UUID NULL,
+		created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user ON refresh_tokens(user_id)`,
+
+	// token yang sudah dicabut (logout), key = jti
+	`CREATE TABLE IF NOT EXISTS revoked_tokens (
+		jti        VARCHAR(64) PRIMARY KEY,
+		expires_at TIMESTAMP NOT NULL,
+		revoked_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires ON revoked_tokens(expires_at)`,
+
+	// versi token per user, dinaikkan untuk mencabut semua sesi ("logout everywhere")
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0`,
+
+	// percobaan login gagal per akun / IP
+	`CREATE TABLE IF NOT EXISTS login_attempts (
+		key_type        VARCHAR(16) NOT NULL,
+		key_value       VARCHAR(255) NOT NULL,
+		failed_count    INT NOT NULL DEFAULT 0,
+		first_failed_at TIMESTAMP NOT NULL,
+		last_failed_at  TIMESTAMP NOT NULL,
+		next_attempt_at TIMESTAMP NULL,
+		locked_until    TIMESTAMP NULL,
+		PRIMARY KEY (key_type, key_value)
+	)`,
+	`CREATE TABLE IF NOT EXISTS login_lockouts (
+		id           UUID PRIMARY KEY,
+		key_type     VARCHAR(16) NOT NULL,
+		key_value    VARCHAR(255) NOT NULL,
+		failed_count INT NOT NULL,
+		locked_until TIMESTAMP NOT NULL,
+		cleared_by   UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+		cleared_at   TIMESTAMP NULL,
+		created_at   TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+
+	// token reset password (sekali pakai, disimpan dalam bentuk hash)
+	`CREATE TABLE IF NOT EXISTS password_reset_tokens (
+		id         UUID PRIMARY KEY,
+		user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		token_hash VARCHAR(64) NOT NULL UNIQUE,
+		expires_at TIMESTAMP NOT NULL,
+		used_at    TIMESTAMP NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+
+	// riwayat hash password untuk aturan "no reuse"
+	`CREATE TABLE IF NOT EXISTS password_history (
+		id            UUID PRIMARY KEY,
+		user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		password_hash VARCHAR(255) NOT NULL,
+		created_at    TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_password_history_user ON password_history(user_id, created_at DESC)`,
+
+	// two-factor authentication (TOTP)
+	`CREATE TABLE IF NOT EXISTS user_totp (
+		user_id        UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		secret_enc     TEXT NOT NULL,
+		enabled        BOOLEAN NOT NULL DEFAULT FALSE,
+		last_used_step BIGINT NOT NULL DEFAULT 0,
+		enabled_at     TIMESTAMP NULL,
+		created_at     TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS user_recovery_codes (
+		id         UUID PRIMARY KEY,
+		user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		code_hash  VARCHAR(64) NOT NULL,
+		used_at    TIMESTAMP NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user ON user_recovery_codes(user_id)`,
+	`CREATE TABLE IF NOT EXISTS login_challenges (
+		id         UUID PRIMARY KEY,
+		user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		purpose    VARCHAR(16) NOT NULL,
+		token_hash VARCHAR(64) NOT NULL UNIQUE,
+		expires_at TIMESTAMP NOT NULL,
+		attempts   INT NOT NULL DEFAULT 0,
+		used_at    TIMESTAMP NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`ALTER TABLE roles ADD COLUMN IF NOT EXISTS require_2fa BOOLEAN NOT NULL DEFAULT FALSE`,
+
+	// outbox untuk sinkronisasi achievement_references -> MongoDB
+	`CREATE TABLE IF NOT EXISTS outbox_events (
+		id              BIGSERIAL PRIMARY KEY,
+		aggregate_id    VARCHAR(64) NOT NULL,
+		event_type      VARCHAR(64) NOT NULL,
+		payload         JSONB NOT NULL DEFAULT '{}',
+		status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+		attempts        INT NOT NULL DEFAULT 0,
+		last_error      TEXT NULL,
+		next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+		processed_at    TIMESTAMP NULL,
+		created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_outbox_events_due ON outbox_events(status, next_attempt_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate ON outbox_events(aggregate_id)`,
+
+	// soft delete prestasi (status workflow "deleted")
+	`ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL`,
+
+	// riwayat status prestasi (append-only)
+	`CREATE TABLE IF NOT EXISTS achievement_status_history (
+		id                   UUID PRIMARY KEY,
+		mongo_achievement_id VARCHAR(64) NOT NULL,
+		event                VARCHAR(32) NOT NULL,
+		from_status          VARCHAR(32) NULL,
+		to_status            VARCHAR(32) NOT NULL,
+		actor_id             UUID NULL,
+		actor_role           VARCHAR(100) NULL,
+		note                 TEXT NULL,
+		created_at           TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_achievement_status_history_achievement ON achievement_status_history(mongo_achievement_id, created_at)`,
+	`CREATE OR REPLACE FUNCTION achievement_status_history_append_only() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'achievement_status_history is append-only';
+	END;
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS trg_achievement_status_history_append_only ON achievement_status_history`,
+	`CREATE TRIGGER trg_achievement_status_history_append_only
+		BEFORE UPDATE OR DELETE ON achievement_status_history
+		FOR EACH ROW EXECUTE FUNCTION achievement_status_history_append_only()`,
+	// prestasi yang dibuat sebelum riwayat status ada: satu baris dari status & waktu saat ini
+	// (hanya untuk reference yang belum punya riwayat sama sekali, jadi aman diulang)
+	`INSERT INTO achievement_status_history
+		(id, mongo_achievement_id, event, from_status, to_status, actor_id, actor_role, note, created_at)
+	SELECT
+		md5(random()::text || clock_timestamp()::text || r.mongo_achievement_id)::uuid,
+		r.mongo_achievement_id,
+		'backfill',
+		NULL,
+		CASE WHEN r.deleted_at IS NOT NULL THEN 'deleted' ELSE r.status END,
+		CASE WHEN r.status IN ('verified', 'rejected') THEN r.verified_by END,
+		(SELECT ro.name FROM users u JOIN roles ro ON ro.id = u.role_id
+		 WHERE r.status IN ('verified', 'rejected') AND u.id = r.verified_by),
+		CASE WHEN r.status = 'rejected' THEN r.rejection_note END,
+		CASE
+			WHEN r.deleted_at IS NOT NULL THEN r.deleted_at
+			WHEN r.status IN ('verified', 'rejected') THEN COALESCE(r.verified_at, r.updated_at)
+			WHEN r.status = 'submitted' THEN COALESCE(r.submitted_at, r.updated_at)
+			ELSE r.updated_at
+		END
+	FROM achievement_references r
+	WHERE NOT EXISTS (
+		SELECT 1 FROM achievement_status_history h
+		WHERE h.mongo_achievement_id = r.mongo_achievement_id
+	)`,
+
+	// putaran pengajuan & revisi prestasi
+	`ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS submission_round INT NOT NULL DEFAULT 0`,
+	`CREATE TABLE IF NOT EXISTS achievement_rounds (
+		id                   UUID PRIMARY KEY,
+		mongo_achievement_id VARCHAR(64) NOT NULL,
+		round                INT NOT NULL,
+		snapshot             JSONB NOT NULL,
+		submitted_by         UUID NULL,
+		submitted_at         TIMESTAMP NOT NULL,
+		outcome              VARCHAR(32) NULL,
+		reviewed_by          UUID NULL,
+		reviewed_at          TIMESTAMP NULL,
+		rejection_note       TEXT NULL,
+		UNIQUE (mongo_achievement_id, round)
+	)`,
+
+	// katalog jenis prestasi & aturan poin
+	`CREATE TABLE IF NOT EXISTS achievement_types (
+		code        VARCHAR(32) PRIMARY KEY,
+		name        VARCHAR(100) NOT NULL,
+		description TEXT NULL,
+		is_active   BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+		updated_at  TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS achievement_point_rules (
+		id         UUID PRIMARY KEY,
+		type_code  VARCHAR(32) NOT NULL REFERENCES achievement_types(code) ON DELETE CASCADE,
+		level      VARCHAR(32) NULL,
+		rank_from  INT NULL,
+		rank_to    INT NULL,
+		label      VARCHAR(100) NOT NULL,
+		points     INT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_achievement_point_rules_type ON achievement_point_rules(type_code)`,
+	// aturan bawaan hanya ditanam saat tipenya pertama kali dibuat, jadi perubahan admin tidak tertimpa
+	`WITH inserted AS (
+		INSERT INTO achievement_types (code, name) VALUES
+			('competition', 'Kompetisi'),
+			('publication', 'Publikasi'),
+			('certification', 'Sertifikasi'),
+			('organization', 'Organisasi')
+		ON CONFLICT (code) DO NOTHING
+		RETURNING code
+	)
+	INSERT INTO achievement_point_rules (id, type_code, level, rank_from, rank_to, label, points)
+	SELECT md5(random()::text || clock_timestamp()::text || seed.label)::uuid, seed.type_code, seed.level, seed.rank_from, seed.rank_to, seed.label, seed.points
+	FROM (VALUES
+			('competition', 'international', 1, 1, 'Internasional - Juara 1', 100),
+			('competition', 'international', 2, 2, 'Internasional - Juara 2', 90),
+			('competition', 'international', 3, 3, 'Internasional - Juara 3', 80),
+			('competition', 'international', 4, NULL, 'Internasional - Finalis', 50),
+			('competition', 'national', 1, 1, 'Nasional - Juara 1', 60),
+			('competition', 'national', 2, 2, 'Nasional - Juara 2', 50),
+			('competition', 'national', 3, 3, 'Nasional - Juara 3', 40),
+			('competition', 'national', 4, NULL, 'Nasional - Finalis', 25),
+			('competition', 'regional', 1, 1, 'Regional - Juara 1', 40),
+			('competition', 'regional', 2, 2, 'Regional - Juara 2', 30),
+			('competition', 'regional', 3, 3, 'Regional - Juara 3', 25),
+			('competition', 'regional', 4, NULL, 'Regional - Finalis', 15),
+			('competition', 'local', 1, 1, 'Lokal - Juara 1', 20),
+			('competition', 'local', 2, 2, 'Lokal - Juara 2', 15),
+			('competition', 'local', 3, 3, 'Lokal - Juara 3', 10),
+			('competition', 'local', 4, NULL, 'Lokal - Finalis', 5),
+			('publication', NULL, NULL, NULL, 'Publikasi', 50),
+			('certification', NULL, NULL, NULL, 'Sertifikasi', 20),
+			('organization', NULL, NULL, NULL, 'Organisasi', 15)
+	) AS seed(type_code, level, rank_from, rank_to, label, points)
+	JOIN inserted ON inserted.code = seed.type_code`,
+
+	// poin prestasi & jejak perubahannya
+	`ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS points INT NOT NULL DEFAULT 0`,
+	`CREATE TABLE IF NOT EXISTS achievement_point_audit (
+		id                   UUID PRIMARY KEY,
+		mongo_achievement_id VARCHAR(64) NOT NULL,
+		old_points           INT NOT NULL,
+		new_points           INT NOT NULL,
+		rule_id              UUID NULL,
+		reason               VARCHAR(32) NOT NULL,
+		actor_id             UUID NULL,
+		created_at           TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_achievement_point_audit_achievement ON achievement_point_audit(mongo_achievement_id, created_at)`,
+
+	// override poin oleh dosen wali & umpan balik penolakan terstruktur
+	`ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS points_overridden BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE achievement_point_audit ADD COLUMN IF NOT EXISTS note TEXT NULL`,
+	`ALTER TABLE achievement_rounds ADD COLUMN IF NOT EXISTS rejection_reasons JSONB NULL`,
+	`ALTER TABLE achievement_rounds ADD COLUMN IF NOT EXISTS field_comments JSONB NULL`,
+	`CREATE TABLE IF NOT EXISTS rejection_reasons (
+		code        VARCHAR(64) PRIMARY KEY,
+		label       VARCHAR(150) NOT NULL,
+		description TEXT NULL,
+		is_active   BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+		updated_at  TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`INSERT INTO rejection_reasons (code, label) VALUES
+		('missing_evidence', 'Bukti pendukung kurang / tidak ada'),
+		('unreadable_evidence', 'Bukti pendukung tidak terbaca'),
+		('wrong_category', 'Jenis prestasi tidak sesuai'),
+		('invalid_details', 'Detail prestasi tidak sesuai bukti'),
+		('duplicate', 'Prestasi sudah pernah diajukan'),
+		('not_eligible', 'Prestasi tidak memenuhi syarat')
+	ON CONFLICT (code) DO NOTHING`,
+
+	// antrean verifikasi dosen wali
+	`CREATE INDEX IF NOT EXISTS idx_students_advisor ON students(advisor_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_achievement_references_pending ON achievement_references(student_id, submitted_at) WHERE status = 'submitted' AND deleted_at IS NULL`,
+
+	// lampiran yang ditandai malware scanner
+	`CREATE TABLE IF NOT EXISTS attachment_quarantine (
+		id                   UUID PRIMARY KEY,
+		mongo_achievement_id VARCHAR(64) NOT NULL,
+		storage_key          TEXT NOT NULL,
+		file_name            TEXT NOT NULL,
+		size                 BIGINT NOT NULL,
+		sha256               CHAR(64) NOT NULL,
+		signature            TEXT NOT NULL,
+		uploaded_by          UUID NULL REFERENCES users(id),
+		created_at           TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_attachment_quarantine_created ON attachment_quarantine(created_at DESC)`,
+}
+
+// Migrate menjalankan seluruh migrasi tambahan secara berurutan
+func Migrate(db *sql.DB) {
+	for _, stmt := range migrations {
+		if _, err := db.Exec(stmt); err != nil {
+			log.Fatal("❌ Migration failed:", err)
+		}
+	}
+	log.Println("✅ Database migrated")
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -8,7 +10,13 @@ import (
 
 	"UAS/config"
 	"UAS/database"
+	"UAS/app/model"
+	"UAS/app/policy"
 	"UAS/app/repository"
+	"UAS/app/scanner"
+	"UAS/app/service"
+	"UAS/app/storage"
+	"UAS/app/utils"
 	"UAS/route"
 		_ "UAS/docs"
 
@@ -39,22 +47,171 @@ func main() {
 	db := database.Connect(cfg.DB_DSN)
 	fmt.Println("✅ Database connected successfully!")
 
+	database.Migrate(db)
+
 	database.ConnectMongo()
 	fmt.Println("✅ MongoDB connected successfully!")
 
 	// =========================
 
+	// Secret JWT dibaca setelah .env dimuat
+	utils.JWTSecret = []byte(cfg.ApiKey)
+
+	// Masa berlaku token
+	utils.AccessTokenTTL = cfg.AccessTokenTTL
+	utils.RefreshTokenTTL = cfg.RefreshTokenTTL
+	utils.PasswordResetTTL = cfg.PasswordResetTTL
+	utils.PasswordResetURL = cfg.PasswordResetURL
+
+	// Password policy & cost bcrypt
+	if err := utils.SetBcryptCost(cfg.BcryptCost); err != nil {
+		log.Fatal("❌ Invalid BCRYPT_COST:", err)
+	}
+	utils.Policy = utils.PasswordPolicy{
+		MinLength:     cfg.PasswordMinLength,
+		RequireUpper:  cfg.PasswordRequireUpper,
+		RequireLower:  cfg.PasswordRequireLower,
+		RequireDigit:  cfg.PasswordRequireDigit,
+		RequireSymbol: cfg.PasswordRequireSymbol,
+		HistorySize:   cfg.PasswordHistorySize,
+	}
+
+	// Notifier (log / file untuk development)
+	if cfg.Notifier == "file" {
+		utils.SetNotifier(utils.NewFileNotifier(cfg.NotifierFile))
+	}
+
+	// Storage lampiran: local (development) atau S3-compatible (multi instance)
+	var store storage.Storage
+	switch cfg.StorageDriver {
+	case "s3":
+		s3, err := storage.NewS3Storage(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3PathStyle)
+		if err != nil {
+			log.Fatal("❌ Invalid S3 storage config:", err)
+		}
+		store = s3
+	default:
+		// link sementara ditandatangani kunci sendiri; jangan pernah memakai API_KEY
+		if cfg.StorageSigningKey == "" {
+			log.Fatal("❌ STORAGE_SIGNING_KEY is required for the local storage driver")
+		}
+		store = storage.NewLocalStorage(cfg.StorageLocalRoot, cfg.PublicBaseURL+"/api/v1/files", []byte(cfg.StorageSigningKey))
+	}
+
+	service.AttachmentLinkTTL = cfg.StorageURLTTL
+
+	// Malware scanner untuk upload lampiran
+	var scan scanner.Scanner = scanner.NoopScanner{}
+	if cfg.Scanner == "clamav" {
+		scan = scanner.NewClamAVScanner(cfg.ClamAVNetwork, cfg.ClamAVAddress, cfg.ClamAVTimeout)
+	}
+
 	// Init repository
 	userRepo := repository.NewUserRepository(db)
 	achievementRepo := repository.NewAchievementRepository(
     database.MongoDB.Collection("achievements"),)
 	refRepo := repository.NewAchievementReferenceRepository(db)
+	versionRepo := repository.NewAchievementVersionRepository(
+		database.MongoDB.Collection("achievement_versions"))
+	if err := versionRepo.EnsureIndexes(); err != nil {
+		log.Println("❌ Failed to create achievement_versions index:", err)
+	}
+	if n, err := achievementRepo.BackfillAttachmentIDs(); err != nil {
+		log.Println("❌ Failed to backfill attachment IDs:", err)
+	} else if n > 0 {
+		log.Printf("✅ Assigned IDs to %d legacy attachments\n", n)
+	}
 	studentRepo := repository.NewStudentRepository(database.DB)
 	lecturerRepo := repository.NewLecturerRepository(db)
 	reportRepo := repository.NewReportRepository(db)
+	refreshRepo := repository.NewRefreshTokenRepository(db)
+	attemptRepo := repository.NewLoginAttemptRepository(
+		db,
+		cfg.LoginMaxAttempts,
+		cfg.LoginIPMaxAttempts,
+		cfg.LoginWindow,
+		cfg.LoginLockoutDuration,
+		cfg.LoginBackoffBase,
+	)
+	resetRepo := repository.NewPasswordResetRepository(db)
+	tfRepo := repository.NewTwoFactorRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	permRepo := repository.NewPermissionRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	catalogRepo := repository.NewAchievementTypeRepository(db)
+	reasonRepo := repository.NewRejectionReasonRepository(db)
+	queueRepo := repository.NewVerificationQueueRepository(db)
+	quarantineRepo := repository.NewQuarantineRepository(db)
+
+	// Revocation store untuk token yang sudah logout
+	if cfg.RevocationStore != "memory" {
+		utils.SetRevocationStore(repository.NewTokenRevocationRepository(db))
+	}
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	utils.StartRevocationSweeper(bgCtx, cfg.RevocationSweepInterval)
+
+	// Versi token per user untuk "logout everywhere"
+	utils.SetTokenVersionStore(userRepo)
+
+	// Permission dibaca dari DB (dengan cache) agar perubahan role langsung berlaku
+	utils.SetPermissionCache(utils.NewPermissionCache(roleRepo, cfg.PermissionCacheTTL))
+
+	// Policy kepemilikan data (mahasiswa pemilik / dosen wali / admin)
+	pol := policy.New(studentRepo, lecturerRepo)
+
+	// Outbox: sinkronisasi achievement_references -> MongoDB (diulang jika gagal)
+	dispatcher := service.NewOutboxDispatcher(outboxRepo, achievementRepo, cfg.OutboxMaxAttempts)
+	dispatcher.Start(bgCtx, cfg.OutboxInterval)
+
+	// Poin prestasi dari katalog aturan poin
+	points := service.NewPointCalculator(catalogRepo, refRepo, achievementRepo, dispatcher)
+
+	// State machine workflow prestasi
+	workflow := service.NewAchievementWorkflow(achievementRepo, refRepo, dispatcher, pol, points, reasonRepo)
+	workflow.OnTransition(service.LogTransition)
+
+	// Thumbnail gambar & metadata PDF dibuat di background
+	previews := service.NewPreviewWorker(achievementRepo, store, cfg.PreviewQueueSize)
+	previews.Start(bgCtx, cfg.PreviewWorkers, cfg.PreviewSweepInterval)
+
+	// Upload lampiran: validasi isi, checksum, scan malware
+	attachments := service.NewAttachmentManager(achievementRepo, store, scan, quarantineRepo, previews, cfg.UploadMaxBytes, cfg.UploadMaxPerAchievement)
+
+	// Rekonsiliasi MongoDB / PostgreSQL
+	reconciler := service.NewReconciler(refRepo, achievementRepo, dispatcher)
+
+	// Mode command: `go run . reconcile [--apply] [--force]` lalu keluar
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		var opts model.ReconcileOptions
+		for _, arg := range os.Args[2:] {
+			switch arg {
+			case "--apply":
+				opts.Apply = true
+			case "--force":
+				opts.Force = true
+			}
+		}
+		report, err := reconciler.Run(context.Background(), opts)
+		if err != nil {
+			log.Fatal("❌ Reconciliation failed:", err)
+		}
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+		stopBackground()
+		return
+	}
+
+	if cfg.ReconcileInterval > 0 {
+		reconciler.Start(bgCtx, cfg.ReconcileInterval, cfg.ReconcileAutoApply)
+	}
 
 	// Init Fiber
-	app := fiber.New()
+	// body request harus muat satu lampiran (multipart menambah sedikit overhead)
+	bodyLimit := 4 << 20
+	if limit := int(cfg.UploadMaxBytes) + 1<<20; limit > bodyLimit {
+		bodyLimit = limit
+	}
+	app := fiber.New(fiber.Config{BodyLimit: bodyLimit})
 
 	// Middleware CORS
 	app.Use(cors.New(cors.Config{
@@ -70,12 +227,22 @@ func main() {
 	// Route Swagger
 	app.Get("/swagger/*", fiberSwagger.WrapHandler)
 
-	route.AuthRoute(app, userRepo)
-	route.UserRoute(app, userRepo)
-	route.AchievementRoute(app, achievementRepo, refRepo, studentRepo)
-	route.StudentRoute(app, studentRepo)
-	route.LecturerRoute(app, lecturerRepo)
-	route.ReportRoute(app, reportRepo, achievementRepo)
+	route.AuthRoute(app, userRepo, refreshRepo, attemptRepo, resetRepo, tfRepo)
+	route.UserRoute(app, userRepo, attemptRepo)
+	route.RoleRoute(app, roleRepo, permRepo, tfRepo)
+	route.PermissionRoute(app, permRepo)
+	route.AchievementRoute(app, achievementRepo, refRepo, versionRepo, catalogRepo, reasonRepo, store, attachments, workflow, pol)
+	route.AchievementTypeRoute(app, catalogRepo, points)
+	route.RejectionReasonRoute(app, reasonRepo)
+	route.VerificationRoute(app, queueRepo, achievementRepo, pol)
+	route.AttachmentRoute(app, quarantineRepo)
+	if local, ok := store.(*storage.LocalStorage); ok {
+		route.FileRoute(app, local)
+	}
+	route.StudentRoute(app, studentRepo, pol)
+	route.LecturerRoute(app, lecturerRepo, pol)
+	route.ReportRoute(app, reportRepo, achievementRepo, pol)
+	route.ReconciliationRoute(app, reconciler)
 
 	// Channel untuk Ctrl+C
 	c := make(chan os.Signal, 1)
@@ -92,6 +259,7 @@ func main() {
 
 	// Tunggu Ctrl+C
 	<-c
+	stopBackground()
 	fmt.Println("\n🛑 Server stopped gracefully.")
 	os.Exit(0)
 }
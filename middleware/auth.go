@@ -54,10 +54,18 @@ func JWTBlacklistMiddleware() fiber.Handler {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
         }
 
-        // Simpan data user di Locals Fiber
+        // Permission diambil dari role terkini, bukan dari isi token,
+        // supaya perubahan role berlaku tanpa login ulang
+        perms, err := utils.ResolvePermissions(claims)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load permissions"})
+        }
+
+        // Simpan data user di Locals Fiber (dibaca RequireAny / RequireAll)
+        c.Locals("claims", claims)
         c.Locals("userID", claims.UserID)
         c.Locals("roleID", claims.RoleID)
-        c.Locals("permissions", claims.Permissions)
+        c.Locals("permissions", perms)
 
         return c.Next()
     }
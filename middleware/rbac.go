@@ -2,52 +2,61 @@ package middleware
 
 import (
 	"github.com/gofiber/fiber/v2"
-	"strings"
 	"UAS/app/utils"
 )
 
-// RBACMiddleware menerima permission yang dibutuhkan
-func RBACMiddleware(requiredPerms ...string) fiber.Handler {
-    return func(c *fiber.Ctx) error {
-        authHeader := c.Get("Authorization")
-        if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-                "error": "Missing or invalid Authorization header",
-            })
-        }
-
-        tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-        claims, err := utils.ValidateJWT(tokenStr)
-        if err != nil {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-                "error": "Invalid or expired token",
-            })
-        }
-
-        c.Locals("userID", claims.UserID)
-        c.Locals("roleID", claims.RoleID)
-        c.Locals("permissions", claims.Permissions)
-
-        hasPerm := false
-        for _, p := range claims.Permissions {
-            for _, rp := range requiredPerms {
-                if p == rp {
-                    hasPerm = true
-                    break
-                }
-            }
-            if hasPerm {
-                break
-            }
-        }
-
-        if !hasPerm {
-            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-                "error": "Insufficient permissions",
-            })
-        }
-
-        return c.Next()
-    }
+// RequireAny lolos jika user memiliki minimal satu dari permission yang diminta.
+// Claims dibaca dari c.Locals yang diisi JWTBlacklistMiddleware (token tidak di-parse ulang).
+func RequireAny(requiredPerms ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		perms, ok := c.Locals("permissions").([]string)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Missing or invalid Authorization header",
+			})
+		}
+
+		for _, rp := range requiredPerms {
+			if utils.HasPermission(perms, rp) {
+				return c.Next()
+			}
+		}
+
+		return forbidden(c, "any", requiredPerms, requiredPerms)
+	}
 }
 
+// RequireAll lolos hanya jika user memiliki semua permission yang diminta
+func RequireAll(requiredPerms ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		perms, ok := c.Locals("permissions").([]string)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Missing or invalid Authorization header",
+			})
+		}
+
+		var missing []string
+		for _, rp := range requiredPerms {
+			if !utils.HasPermission(perms, rp) {
+				missing = append(missing, rp)
+			}
+		}
+
+		if len(missing) > 0 {
+			return forbidden(c, "all", requiredPerms, missing)
+		}
+
+		return c.Next()
+	}
+}
+
+// forbidden mengembalikan 403 terstruktur beserta permission yang kurang
+func forbidden(c *fiber.Ctx, mode string, required, missing []string) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error":    "Insufficient permissions",
+		"mode":     mode,
+		"required": required,
+		"missing":  missing,
+	})
+}
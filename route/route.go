@@ -1,157 +1,440 @@
 package route
 
 import (
+	"UAS/app/policy"
 	"UAS/app/repository"
 	"UAS/app/service"
+	"UAS/app/storage"
 	"UAS/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // AuthRoute menangani login
-func AuthRoute(app *fiber.App, repo *repository.UserRepository) {
+func AuthRoute(
+	app *fiber.App,
+	repo *repository.UserRepository,
+	refreshRepo *repository.RefreshTokenRepository,
+	attemptRepo *repository.LoginAttemptRepository,
+	resetRepo *repository.PasswordResetRepository,
+	tfRepo *repository.TwoFactorRepository,
+) {
 	auth := app.Group("/api/v1/auth")
 
 	auth.Post("/login", func(c *fiber.Ctx) error {
-		return service.Login(c, repo)
+		return service.Login(c, repo, refreshRepo, attemptRepo, tfRepo)
 	})
 
-	auth.Post("/refresh", middleware.JWTBlacklistMiddleware() ,func(c *fiber.Ctx) error {
-		return service.RefreshToken(c, repo)
+	// Langkah kedua login (2FA)
+	auth.Post("/login/2fa", func(c *fiber.Ctx) error {
+		return service.VerifyLoginTwoFactor(c, repo, refreshRepo, tfRepo, attemptRepo)
+	})
+
+	auth.Post("/login/2fa/setup", func(c *fiber.Ctx) error {
+		return service.SetupLoginTwoFactor(c, repo, tfRepo)
+	})
+
+	// Refresh tidak butuh access token: access token boleh sudah expired
+	auth.Post("/refresh", func(c *fiber.Ctx) error {
+		return service.RefreshToken(c, repo, refreshRepo)
 	})
 
 	auth.Get("/profile", middleware.JWTBlacklistMiddleware(), func(c *fiber.Ctx) error {
 		return service.Profile(c, repo)
 	})
 
-	auth.Post("/logout", service.Logout)
+	auth.Post("/logout", func(c *fiber.Ctx) error {
+		return service.Logout(c, refreshRepo)
+	})
+
+	auth.Post("/logout-all", middleware.JWTBlacklistMiddleware(), func(c *fiber.Ctx) error {
+		return service.LogoutAll(c, repo)
+	})
+
+	// Password (self-service)
+	auth.Put("/password", middleware.JWTBlacklistMiddleware(), func(c *fiber.Ctx) error {
+		return service.ChangePassword(c, repo, refreshRepo)
+	})
+
+	auth.Post("/forgot-password", func(c *fiber.Ctx) error {
+		return service.ForgotPassword(c, repo, resetRepo)
+	})
+
+	auth.Post("/reset-password", func(c *fiber.Ctx) error {
+		return service.ResetPassword(c, repo, resetRepo)
+	})
+
+	// Two-factor authentication (TOTP)
+	auth.Post("/2fa/setup", middleware.JWTBlacklistMiddleware(), func(c *fiber.Ctx) error {
+		return service.SetupTwoFactor(c, repo, tfRepo)
+	})
+
+	auth.Post("/2fa/enable", middleware.JWTBlacklistMiddleware(), func(c *fiber.Ctx) error {
+		return service.EnableTwoFactor(c, tfRepo)
+	})
+
+	auth.Post("/2fa/disable", middleware.JWTBlacklistMiddleware(), func(c *fiber.Ctx) error {
+		return service.DisableTwoFactor(c, repo, tfRepo)
+	})
+
+	auth.Post("/2fa/recovery-codes", middleware.JWTBlacklistMiddleware(), func(c *fiber.Ctx) error {
+		return service.RegenerateRecoveryCodes(c, tfRepo)
+	})
 
 }
 
 // UserRoute menangani CRUD user (admin only)
-func UserRoute(app *fiber.App, repo *repository.UserRepository) {
+func UserRoute(app *fiber.App, repo *repository.UserRepository, attemptRepo *repository.LoginAttemptRepository) {
 	users := app.Group("/api/v1/users", middleware.JWTBlacklistMiddleware())
 
 	// users.Use(middleware.JWTBlacklistMiddleware())
 
+	// Lockout login (didaftarkan sebelum /:id)
+	users.Get("/lockouts", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
+		return service.GetLoginLockouts(c, attemptRepo)
+	})
+
+	users.Delete("/lockouts", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
+		return service.ClearLoginLockout(c, attemptRepo)
+	})
+
 	// GET all users - hanya admin dengan permission user:read
-	users.Get("/", middleware.RBACMiddleware("user:manage"), func(c *fiber.Ctx) error {
+	users.Get("/", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
 		return service.GetAllUsers(c, repo)
 	})
 
 	// GET user by ID - admin dan user bisa baca sendiri
-	users.Get("/:id", middleware.RBACMiddleware("user:manage"), func(c *fiber.Ctx) error {
+	users.Get("/:id", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
 		return service.GetUserByID(c, repo)
 	})
 
 	// POST create user
-	users.Post("/", middleware.RBACMiddleware("user:manage"), func(c *fiber.Ctx) error {
+	users.Post("/", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
 		return service.CreateUser(c, repo)
 	})
 
 	// PUT update user
-	users.Put("/:id", middleware.RBACMiddleware("user:manage"), func(c *fiber.Ctx) error {
+	users.Put("/:id", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
 		return service.UpdateUser(c, repo)
 	})
 
 	// DELETE user
-	users.Delete("/:id", middleware.RBACMiddleware("user:manage"), func(c *fiber.Ctx) error {
+	users.Delete("/:id", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
 		return service.DeleteUser(c, repo)
 	})
 
-	// // PUT untuk update password
-	users.Put("/:id/role", middleware.RBACMiddleware("user:manage"),func(c *fiber.Ctx) error {
+	// POST cabut semua sesi user
+	users.Post("/:id/revoke-sessions", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
+		return service.RevokeUserSessions(c, repo)
+	})
+
+	// PUT untuk update password (admin)
+	users.Put("/:id/password", middleware.RequireAny("user:manage"),func(c *fiber.Ctx) error {
 	return service.UpdatePassword(c, repo)
 	})
 }
 
-func AchievementRoute(app *fiber.App, achievementRepo *repository.AchievementRepository, refRepo *repository.AchievementReferenceRepository, studentRepo *repository.StudentRepository) {
+// RoleRoute menangani pengaturan role (admin only)
+func RoleRoute(app *fiber.App, roleRepo *repository.RoleRepository, permRepo *repository.PermissionRepository, tfRepo *repository.TwoFactorRepository) {
+	roles := app.Group("/api/v1/roles", middleware.JWTBlacklistMiddleware(), middleware.RequireAny("user:manage"))
+
+	roles.Get("/", func(c *fiber.Ctx) error {
+		return service.GetRoles(c, roleRepo)
+	})
+
+	roles.Get("/:id", func(c *fiber.Ctx) error {
+		return service.GetRoleByID(c, roleRepo)
+	})
+
+	roles.Post("/", func(c *fiber.Ctx) error {
+		return service.CreateRole(c, roleRepo)
+	})
+
+	roles.Put("/:id", func(c *fiber.Ctx) error {
+		return service.UpdateRole(c, roleRepo)
+	})
+
+	roles.Delete("/:id", func(c *fiber.Ctx) error {
+		return service.DeleteRole(c, roleRepo)
+	})
+
+	// Attach / detach permission pada role
+	roles.Post("/:id/permissions", func(c *fiber.Ctx) error {
+		return service.AttachRolePermission(c, roleRepo, permRepo)
+	})
+
+	roles.Delete("/:id/permissions/:permissionId", func(c *fiber.Ctx) error {
+		return service.DetachRolePermission(c, roleRepo)
+	})
+
+	roles.Put("/:id/require-2fa", func(c *fiber.Ctx) error {
+		return service.SetRoleTwoFactorRequirement(c, tfRepo)
+	})
+}
+
+// PermissionRoute menangani CRUD permission (admin only)
+func PermissionRoute(app *fiber.App, permRepo *repository.PermissionRepository) {
+	perms := app.Group("/api/v1/permissions", middleware.JWTBlacklistMiddleware(), middleware.RequireAny("user:manage"))
+
+	perms.Get("/", func(c *fiber.Ctx) error {
+		return service.GetPermissions(c, permRepo)
+	})
+
+	perms.Post("/", func(c *fiber.Ctx) error {
+		return service.CreatePermission(c, permRepo)
+	})
+
+	perms.Put("/:id", func(c *fiber.Ctx) error {
+		return service.UpdatePermission(c, permRepo)
+	})
+
+	perms.Delete("/:id", func(c *fiber.Ctx) error {
+		return service.DeletePermission(c, permRepo)
+	})
+}
+
+func AchievementRoute(app *fiber.App, achievementRepo *repository.AchievementRepository, refRepo *repository.AchievementReferenceRepository, versionRepo *repository.AchievementVersionRepository, catalog *repository.AchievementTypeRepository, reasons *repository.RejectionReasonRepository, store storage.Storage, attachments *service.AttachmentManager, workflow *service.AchievementWorkflow, pol *policy.Policy) {
 	ach := app.Group("/api/v1/achievements", middleware.JWTBlacklistMiddleware())
 
-	ach.Get("/", middleware.RBACMiddleware("user:manage", "achievement:read"),func(c *fiber.Ctx) error {
-		return service.ListAchievements(c, achievementRepo, studentRepo)
+	ach.Get("/", middleware.RequireAny("user:manage", "achievement:read"),func(c *fiber.Ctx) error {
+		return service.ListAchievements(c, achievementRepo, pol)
 	})
 
-	ach.Get("/:id", middleware.RBACMiddleware("achievement:read", "user:manage"),func(c *fiber.Ctx) error {
-		return service.GetAchievementDetail(c, achievementRepo) 
+	ach.Get("/:id", middleware.RequireAny("achievement:read", "user:manage"),func(c *fiber.Ctx) error {
+		return service.GetAchievementDetail(c, achievementRepo, pol)
 	})
 
-	ach.Post("/", middleware.RBACMiddleware("achievement:create"), func(c *fiber.Ctx) error {
-		return service.CreateAchievement(c, achievementRepo, refRepo, studentRepo)
+	ach.Post("/", middleware.RequireAny("achievement:create"), func(c *fiber.Ctx) error {
+		return service.CreateAchievement(c, achievementRepo, refRepo, versionRepo, workflow)
 	})
 
-	ach.Put("/:id", middleware.RBACMiddleware("achievement:update"), func(c *fiber.Ctx) error {
-		return service.UpdateAchievement(c, achievementRepo, studentRepo)
+	ach.Put("/:id", middleware.RequireAny("achievement:update"), func(c *fiber.Ctx) error {
+		return service.UpdateAchievement(c, achievementRepo, refRepo, versionRepo, catalog, pol)
 	})
 
-	ach.Delete("/:id", middleware.RBACMiddleware("achievement:delete"), func(c *fiber.Ctx) error {
-		return service.DeleteAchievement(c, achievementRepo, studentRepo)
+	ach.Delete("/:id", middleware.RequireAny("achievement:delete"), func(c *fiber.Ctx) error {
+		return service.DeleteAchievement(c, workflow)
 	})
 
 	// Submit for verification (Mahasiswa)
-	ach.Post("/:id/submit", middleware.RBACMiddleware("achievement:submit"), func(c *fiber.Ctx) error {
-		return service.SubmitAchievement(c, achievementRepo, refRepo, studentRepo)
+	ach.Post("/:id/submit", middleware.RequireAny("achievement:submit"), func(c *fiber.Ctx) error {
+		return service.SubmitAchievement(c, workflow)
 	})
 
 	// Verify & Reject (Dosen Wali)
-	ach.Post("/:id/verify", middleware.RBACMiddleware("achievement:verify"), func(c *fiber.Ctx) error {
-		return service.VerifyAchievement(c, refRepo)
+	ach.Post("/:id/verify", middleware.RequireAny("achievement:verify"), func(c *fiber.Ctx) error {
+		return service.VerifyAchievement(c, workflow)
 	})
 
-	ach.Post("/:id/reject", middleware.RBACMiddleware("achievement:verify"), func(c *fiber.Ctx) error {
-		return service.RejectAchievement(c, achievementRepo, refRepo)
-	}) 
+	ach.Post("/:id/reject", middleware.RequireAny("achievement:verify"), func(c *fiber.Ctx) error {
+		return service.RejectAchievement(c, workflow)
+	})
+
+	// Revisi prestasi yang ditolak (Mahasiswa)
+	ach.Post("/:id/revise", middleware.RequireAny("achievement:update"), func(c *fiber.Ctx) error {
+		return service.ReviseAchievement(c, workflow)
+	})
 
 	// History & Attachments
-	ach.Get("/:id/history", middleware.RBACMiddleware("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
-		return service.GetAchievementHistory(c, refRepo) // function service belum dibuat
+	ach.Get("/:id/history", middleware.RequireAny("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.GetAchievementHistory(c, achievementRepo, refRepo, pol)
+	})
+
+	// Putaran pengajuan & perubahan sejak penolakan terakhir
+	ach.Get("/:id/rounds", middleware.RequireAny("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.GetAchievementRounds(c, achievementRepo, refRepo, pol)
+	})
+
+	ach.Get("/:id/diff", middleware.RequireAny("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.GetAchievementRevisionDiff(c, achievementRepo, refRepo, pol)
+	})
+
+	// Umpan balik penolakan terakhir (alasan & komentar per field)
+	ach.Get("/:id/feedback", middleware.RequireAny("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.GetAchievementFeedback(c, achievementRepo, refRepo, reasons, pol)
+	})
+
+	// Jejak perubahan poin
+	ach.Get("/:id/points", middleware.RequireAny("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.GetAchievementPointAudit(c, achievementRepo, catalog, pol)
+	})
+
+	// Versi isi prestasi
+	ach.Get("/:id/versions", middleware.RequireAny("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.GetAchievementVersions(c, achievementRepo, versionRepo, pol)
+	})
+
+	ach.Get("/:id/versions/diff", middleware.RequireAny("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.DiffAchievementVersions(c, achievementRepo, versionRepo, pol)
+	})
+
+	ach.Get("/:id/versions/:version", middleware.RequireAny("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.GetAchievementVersion(c, achievementRepo, versionRepo, pol)
+	})
+
+	ach.Post("/:id/versions/:version/restore", middleware.RequireAny("achievement:update"), func(c *fiber.Ctx) error {
+		return service.RestoreAchievementVersion(c, achievementRepo, refRepo, versionRepo, catalog, pol)
+	})
+
+	ach.Post("/:id/attachments", middleware.RequireAny("achievement:update"), func(c *fiber.Ctx) error {
+		return service.UploadAchievementAttachment(c, achievementRepo, refRepo, attachments, pol)
+	})
+
+	ach.Get("/:id/attachments/:attachmentId", middleware.RequireAny("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.DownloadAchievementAttachment(c, achievementRepo, store, pol)
+	})
+
+	ach.Put("/:id/attachments/:attachmentId", middleware.RequireAny("achievement:update"), func(c *fiber.Ctx) error {
+		return service.ReplaceAchievementAttachment(c, achievementRepo, refRepo, attachments, pol)
+	})
+
+	ach.Delete("/:id/attachments/:attachmentId", middleware.RequireAny("achievement:update"), func(c *fiber.Ctx) error {
+		return service.DeleteAchievementAttachment(c, achievementRepo, refRepo, attachments, pol)
+	})
+
+	ach.Get("/:id/attachments/:attachmentId/thumbnails/:size", middleware.RequireAny("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.GetAchievementAttachmentThumbnail(c, achievementRepo, store, pol)
 	})
 
-	ach.Post("/:id/attachments", middleware.RBACMiddleware("achievement:update"), func(c *fiber.Ctx) error {
-		return service.UploadAchievementAttachment(c, achievementRepo) // function service belum dibuat
+	ach.Get("/:id/attachments/:attachmentId/link", middleware.RequireAny("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.GetAchievementAttachmentLink(c, achievementRepo, store, pol)
 	})
 
 }
 
-func StudentRoute(app *fiber.App, repo *repository.StudentRepository) {
+// AchievementTypeRoute menangani katalog jenis prestasi & aturan poin (perubahan: admin only)
+func AchievementTypeRoute(app *fiber.App, catalog *repository.AchievementTypeRepository, points *service.PointCalculator) {
+	types := app.Group("/api/v1/achievement-types", middleware.JWTBlacklistMiddleware())
+
+	types.Get("/", middleware.RequireAny("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.GetAchievementTypes(c, catalog)
+	})
+
+	types.Get("/:code", middleware.RequireAny("achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.GetAchievementType(c, catalog)
+	})
+
+	types.Post("/", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
+		return service.CreateAchievementType(c, catalog)
+	})
+
+	types.Put("/:code", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
+		return service.UpdateAchievementType(c, catalog)
+	})
+
+	// Aturan poin (setiap perubahan memicu hitung ulang)
+	types.Post("/:code/rules", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
+		return service.CreatePointRule(c, catalog, points)
+	})
+
+	types.Put("/:code/rules/:ruleId", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
+		return service.UpdatePointRule(c, catalog, points)
+	})
+
+	types.Delete("/:code/rules/:ruleId", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
+		return service.DeletePointRule(c, catalog, points)
+	})
+
+	types.Post("/:code/recalculate", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
+		return service.RecalculateAchievementPoints(c, catalog, points)
+	})
+}
+
+// RejectionReasonRoute menangani daftar alasan penolakan (perubahan: admin only)
+func RejectionReasonRoute(app *fiber.App, reasons *repository.RejectionReasonRepository) {
+	rr := app.Group("/api/v1/rejection-reasons", middleware.JWTBlacklistMiddleware())
+
+	rr.Get("/", middleware.RequireAny("achievement:verify", "achievement:read", "user:manage"), func(c *fiber.Ctx) error {
+		return service.GetRejectionReasons(c, reasons)
+	})
+
+	rr.Post("/", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
+		return service.CreateRejectionReason(c, reasons)
+	})
+
+	rr.Put("/:code", middleware.RequireAny("user:manage"), func(c *fiber.Ctx) error {
+		return service.UpdateRejectionReason(c, reasons)
+	})
+}
+
+// VerificationRoute menangani antrean verifikasi dosen wali
+func VerificationRoute(app *fiber.App, queue *repository.VerificationQueueRepository, achievementRepo *repository.AchievementRepository, pol *policy.Policy) {
+	v := app.Group("/api/v1/verifications", middleware.JWTBlacklistMiddleware())
+
+	v.Get("/pending", middleware.RequireAny("achievement:verify"), func(c *fiber.Ctx) error {
+		return service.GetPendingVerifications(c, queue, achievementRepo, pol)
+	})
+}
+
+// AttachmentRoute menangani lampiran yang dikarantina malware scanner (admin only)
+func AttachmentRoute(app *fiber.App, quarantine *repository.QuarantineRepository) {
+	att := app.Group("/api/v1/attachments", middleware.JWTBlacklistMiddleware(), middleware.RequireAny("user:manage"))
+
+	att.Get("/quarantine", func(c *fiber.Ctx) error {
+		return service.GetQuarantinedAttachments(c, quarantine)
+	})
+}
+
+// FileRoute melayani link sementara driver storage local (tanpa token, ditandatangani HMAC)
+func FileRoute(app *fiber.App, local *storage.LocalStorage) {
+	app.Get("/api/v1/files/*", func(c *fiber.Ctx) error {
+		return service.ServeSignedFile(c, local)
+	})
+}
+
+func StudentRoute(app *fiber.App, repo *repository.StudentRepository, pol *policy.Policy) {
 	students := app.Group("/api/v1/students", middleware.JWTBlacklistMiddleware())
 
 	// GET all students
 	students.Get("/", func(c *fiber.Ctx) error {
-		return service.GetStudents(c, repo)
+		return service.GetStudents(c, repo, pol)
 	})
 
 	students.Get("/:id", func(c *fiber.Ctx) error {
-		return service.GetStudentByID(c, repo)
+		return service.GetStudentByID(c, pol)
 	})
 
-	students.Put("/:id/advisor", middleware.RBACMiddleware("user:manage"),func(c *fiber.Ctx) error {
-		return service.UpdateStudentAdvisor(c, repo)
+	students.Put("/:id/advisor", middleware.RequireAny("user:manage"),func(c *fiber.Ctx) error {
+		return service.UpdateStudentAdvisor(c, repo, pol)
 	})
 
 }
 
-func LecturerRoute(app *fiber.App, repo *repository.LecturerRepository) {
+func LecturerRoute(app *fiber.App, repo *repository.LecturerRepository, pol *policy.Policy) {
 	lec := app.Group("/api/v1/lecturers", middleware.JWTBlacklistMiddleware())
 
 	lec.Get("/", func(c *fiber.Ctx) error {
 		return service.GetLecturers(c, repo)
 	})
 
-	lec.Get("/:id/advisees", middleware.RBACMiddleware("achievement:read", "user:manage"),func(c *fiber.Ctx) error {
-		return service.GetLecturerAdvisees(c, repo)
+	lec.Get("/:id/advisees", middleware.RequireAny("achievement:read", "user:manage"),func(c *fiber.Ctx) error {
+		return service.GetLecturerAdvisees(c, repo, pol)
 	})
 }
 
-func ReportRoute( app *fiber.App, reportRepo *repository.ReportRepository, achievementRepo *repository.AchievementRepository,) {
+func ReportRoute( app *fiber.App, reportRepo *repository.ReportRepository, achievementRepo *repository.AchievementRepository, pol *policy.Policy) {
 	r := app.Group( "/api/v1/reports", middleware.JWTBlacklistMiddleware())
 
 	r.Get("/statistics",
-		service.GetReportStatistics(reportRepo, achievementRepo),
+		service.GetReportStatistics(reportRepo, achievementRepo, pol),
 	)
 
 	r.Get("/student/:id",
-		service.GetStudentReport(reportRepo, achievementRepo),
+		service.GetStudentReport(reportRepo, achievementRepo, pol),
 	)
 }
 
+// ReconciliationRoute: laporan & perbaikan selisih MongoDB / PostgreSQL (admin only)
+func ReconciliationRoute(app *fiber.App, reconciler *service.Reconciler) {
+	admin := app.Group("/api/v1/admin", middleware.JWTBlacklistMiddleware(), middleware.RequireAny("user:manage"))
+
+	// dry-run
+	admin.Get("/reconciliation", func(c *fiber.Ctx) error {
+		return service.GetReconciliationReport(c, reconciler)
+	})
+
+	// apply
+	admin.Post("/reconciliation", func(c *fiber.Ctx) error {
+		return service.ApplyReconciliation(c, reconciler)
+	})
+}